@@ -62,6 +62,19 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.LogLevelStr, "loglevel", "warn", "specify a canonical log level")
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.AuthRaw, "auth", `{}`, "auth maps json string, keys are provider names")
 	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.AllowInsecure, dto.AllowInsecureKey, false, "Allow trust of insecure certificates (not recommended)")
+	rootCmd.PersistentFlags().IntVar(&runtimeCtx.ExecutionConcurrencyLimit, dto.ExecutionConcurrencyLimitKey, 1, "max concurrent requests per query; 1 is sequential, negative values are unbounded")
+	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.ExecutionConcurrencyUnordered, dto.ExecutionConcurrencyUnorderedKey, false, "emit concurrent request output in completion order rather than request order")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.HTTPCassettePath, dto.HTTPCassettePathKey, "", "cassette file to record HTTP interactions to or replay them from, empty to disable")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.HTTPCassetteMode, dto.HTTPCassetteModeKey, "replay", "cassette mode, one of 'record' or 'replay'")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.HTTPCassetteMatch, dto.HTTPCassetteMatchKey, "", "comma separated request attributes matched on replay, from method, host, path, query and body; empty for all")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.HTTPCassetteScrub, dto.HTTPCassetteScrubKey, "", "comma separated header, query parameter and body field names to redact from cassettes, in addition to credentials")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.AuditLogPath, dto.AuditLogPathKey, "", "JSON lines file recording every mutating call, empty to disable")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.AuditRedact, dto.AuditRedactKey, "", "comma separated parameter names to redact from the audit log, in addition to credentials")
+	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.DryRunFlag, dto.DryRunFlagKey, false, "build requests without sending them, and write them out for review")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.DryRunFormat, dto.DryRunFormatKey, "curl", "dry run output format, one of 'curl', 'http' or 'har'")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.DryRunOutput, dto.DryRunOutputKey, "", "dry run output file, empty for stdout; required for 'har'")
+	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.DryRunShowSecrets, dto.DryRunShowSecretsKey, false, "write credential headers and query parameters unmasked in dry run output")
+	rootCmd.PersistentFlags().StringVar(&runtimeCtx.ResponseValidation, dto.ResponseValidationKey, "off", "check response bodies against their schema, one of 'off', 'warn' or 'error'")
 	// CLI specific flags
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.CLIPayload, "payload", ``, "string payload eg for HTTP request body")
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.CLIPayloadType, "payload-type", `application/json`, "request payload type, eg HTTP request Content-Type such as application/json")
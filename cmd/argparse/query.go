@@ -1,12 +1,14 @@
 package argparse
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"runtime/pprof"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,6 +19,7 @@ import (
 	"github.com/stackql/any-sdk/pkg/client"
 	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/fanout"
 	"github.com/stackql/any-sdk/pkg/internaldto"
 	"github.com/stackql/any-sdk/pkg/local_template_executor"
 	"github.com/stackql/any-sdk/pkg/stream_transform"
@@ -151,9 +154,13 @@ func runQueryCommand(authCtx *dto.AuthCtx, payload *queryCmdPayload) error {
 			inlines[1:],
 			nil,
 		)
+		start := time.Now()
 		resp, err := executor.Execute(
 			map[string]any{"parameters": payload.parameters},
 		)
+		if auditErr := anysdk.AuditLocalExecution(payload.rtCtx, opStore, payload.parameters, err, start); auditErr != nil {
+			return auditErr
+		}
 		if err != nil {
 			return err
 		}
@@ -214,59 +221,88 @@ func runQueryCommand(authCtx *dto.AuthCtx, payload *queryCmdPayload) error {
 		if err != nil {
 			return err
 		}
-		for _, v := range armoury.GetRequestParams() {
+		reqParams := armoury.GetRequestParams()
+		outputs := make([][]byte, len(reqParams))
+		tasks := make([]fanout.Task, len(reqParams))
+		for i, v := range reqParams {
+			idx := i
 			argList := v.GetArgList()
+			tasks[idx] = fanout.NewTask(
+				func(context.Context) error {
+					bodyBytes, err := runQueryRequest(authCtx, payload, prov, opStore, argList)
+					outputs[idx] = bodyBytes
+					return err
+				},
+				func() error {
+					fmt.Fprintf(os.Stdout, "%s", string(outputs[idx]))
+					return nil
+				},
+			)
+		}
+		executor := fanout.NewExecutor(
+			payload.rtCtx.ExecutionConcurrencyLimit,
+			!payload.rtCtx.ExecutionConcurrencyUnordered,
+		)
+		return executor.Execute(context.Background(), tasks)
+	default:
+		return fmt.Errorf("protocol type = '%v' not supported", protocolType)
+	}
+}
 
-			cc := anysdk.NewAnySdkClientConfigurator(
-				payload.rtCtx,
-				prov.GetName(),
-				payload.defaultHttpClient,
+// runQueryRequest performs a single armoury request and returns the
+// (optionally transformed) response body. It is safe to call concurrently.
+func runQueryRequest(
+	authCtx *dto.AuthCtx,
+	payload *queryCmdPayload,
+	prov anysdk.Provider,
+	opStore anysdk.OperationStore,
+	argList client.AnySdkArgList,
+) ([]byte, error) {
+	cc := anysdk.NewAnySdkClientConfigurator(
+		payload.rtCtx,
+		prov.GetName(),
+		payload.defaultHttpClient,
+	)
+	response, apiErr := anysdk.CallFromSignature(
+		cc, payload.rtCtx, authCtx, authCtx.Type, false, os.Stderr, prov, anysdk.NewAnySdkOpStoreDesignation(opStore), argList)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	httpResponse, httpResponseErr := response.GetHttpResponse()
+	if httpResponseErr != nil {
+		return nil, httpResponseErr
+	}
+	defer httpResponse.Body.Close()
+	bodyBytes, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+	expectedResponse, isExpectedResponse := opStore.GetResponse()
+	if isExpectedResponse {
+		responseTransform, responseTransformExists := expectedResponse.GetTransform()
+		if responseTransformExists {
+			streamTransformerFactory := stream_transform.NewStreamTransformerFactory(
+				responseTransform.GetType(),
+				responseTransform.GetBody(),
 			)
-			response, apiErr := anysdk.CallFromSignature(
-				cc, payload.rtCtx, authCtx, authCtx.Type, false, os.Stderr, prov, anysdk.NewAnySdkOpStoreDesignation(opStore), argList)
-			if apiErr != nil {
-				return err
-			}
-			httpResponse, httpResponseErr := response.GetHttpResponse()
-			if httpResponseErr != nil {
-				return httpResponseErr
+			if !streamTransformerFactory.IsTransformable() {
+				return nil, fmt.Errorf("unsupported template type: %s", responseTransform.GetType())
 			}
-			defer httpResponse.Body.Close()
-			bodyBytes, err := io.ReadAll(httpResponse.Body)
+			tfm, err := streamTransformerFactory.GetTransformer(string(bodyBytes))
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("template stream transform error: %v", err)
 			}
-			expectedResponse, isExpectedResponse := opStore.GetResponse()
-			if isExpectedResponse {
-				responseTransform, responseTransformExists := expectedResponse.GetTransform()
-				if responseTransformExists {
-					streamTransformerFactory := stream_transform.NewStreamTransformerFactory(
-						responseTransform.GetType(),
-						responseTransform.GetBody(),
-					)
-					if !streamTransformerFactory.IsTransformable() {
-						return fmt.Errorf("unsupported template type: %s", responseTransform.GetType())
-					}
-					tfm, err := streamTransformerFactory.GetTransformer(string(bodyBytes))
-					if err != nil {
-						return fmt.Errorf("template stream transform error: %v", err)
-					}
-					if err := tfm.Transform(); err != nil {
-						return fmt.Errorf("failed to transform: %v", err)
-					}
-					outStream := tfm.GetOutStream()
-					bodyBytes, err = io.ReadAll(outStream)
-					if err != nil {
-						return fmt.Errorf("failed to read out stream: %v", err)
-					}
-				}
+			if err := tfm.Transform(); err != nil {
+				return nil, fmt.Errorf("failed to transform: %v", err)
+			}
+			outStream := tfm.GetOutStream()
+			bodyBytes, err = io.ReadAll(outStream)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read out stream: %v", err)
 			}
-			fmt.Fprintf(os.Stdout, "%s", string(bodyBytes))
 		}
-		return nil
-	default:
-		return fmt.Errorf("protocol type = '%v' not supported", protocolType)
 	}
+	return bodyBytes, nil
 }
 
 func transformOpenapiStackqlAuthToLocal(authDTO authsurface.AuthDTO) *dto.AuthCtx {
@@ -0,0 +1,25 @@
+package anysdk
+
+import (
+	"net/http"
+
+	"github.com/stackql/any-sdk/pkg/apierror"
+)
+
+// ParseAPIError reads a non-2xx response into an APIError, using the
+// operation's errorFormat for custom bodies. An error is also retryable
+// when the operation's retry policy would retry it. The response body is
+// restored, so the response can still be processed.
+func (op *standardOpenAPIOperationStore) ParseAPIError(httpResponse *http.Response) (*apierror.APIError, bool) {
+	format, _ := op.GetErrorFormat()
+	apiErr, isAPIError := apierror.FromResponse(httpResponse, format)
+	if !isAPIError {
+		return nil, false
+	}
+	if !apiErr.Retryable {
+		policy := op.GetRetryPolicy()
+		apiErr.Retryable = policy.IsStatusRetryable(apiErr.StatusCode) ||
+			policy.GetRetryableConditions().IsBodyRetryable(apiErr.Body)
+	}
+	return apiErr, true
+}
@@ -0,0 +1,72 @@
+package anysdk
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/apierror"
+)
+
+func errorResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestParseAPIError_UsesConfiguredFormat(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	op.StackQLConfig = &standardStackQLConfig{
+		ErrorFormat: &apierror.Format{Code: "$.result.errCode", Message: "$.result.errMsg"},
+	}
+	apiErr, isAPIError := op.ParseAPIError(errorResponse(http.StatusBadRequest, `{"result": {"errCode": "E1001", "errMsg": "bad zone"}}`))
+	if !isAPIError || apiErr.Code != "E1001" || apiErr.Message != "bad zone" || apiErr.Format != apierror.FormatCustom {
+		t.Fatalf("unexpected error %+v", apiErr)
+	}
+	if _, isAPIError = op.ParseAPIError(errorResponse(http.StatusOK, `{}`)); isAPIError {
+		t.Fatalf("expected no error for a successful response")
+	}
+}
+
+func TestParseAPIError_RetryableByRetryPolicy(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	body := `{"error": {"code": "QuotaHit", "message": "slow down"}}`
+	apiErr, _ := op.ParseAPIError(errorResponse(http.StatusBadRequest, body))
+	if apiErr.Retryable {
+		t.Fatalf("expected a 400 to be final by default")
+	}
+	op.StackQLConfig = &standardStackQLConfig{
+		Retry: &standardRetryPolicy{
+			RetryableConditions: &standardRetryConditions{
+				BodyPredicates: []*standardRetryBodyPredicate{{JSONPath: "$.error.code", Values: []string{"QuotaHit"}}},
+			},
+		},
+	}
+	apiErr, _ = op.ParseAPIError(errorResponse(http.StatusBadRequest, body))
+	if !apiErr.Retryable {
+		t.Fatalf("expected the retry policy's body predicate to mark the error retryable")
+	}
+}
+
+func TestProcessResponse_AttachesAPIError(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	processed, err := op.ProcessResponse(errorResponse(http.StatusNotFound,
+		`{"error": {"code": 404, "message": "Instance not found", "status": "NOT_FOUND"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, _ := processed.GetResponse()
+	if !r.HasError() {
+		t.Fatalf("expected an error response")
+	}
+	apiErr, hasAPIError := r.GetAPIError()
+	if !hasAPIError || apiErr.Code != "NOT_FOUND" || apiErr.Message != "Instance not found" {
+		t.Fatalf("unexpected error %+v", apiErr)
+	}
+	if !strings.Contains(r.Error(), "Instance not found") {
+		t.Fatalf("expected the error string to be unchanged, got %q", r.Error())
+	}
+}
@@ -0,0 +1,156 @@
+package anysdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/audit"
+	"github.com/stackql/any-sdk/pkg/client"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/logging"
+)
+
+type auditParametersKey struct{}
+
+// auditor writes an audit record for each mutating call made through a
+// client.
+type auditor struct {
+	sink     audit.Sink
+	redactor *audit.Redactor
+}
+
+// newAuditor returns nil when the runtime context names no audit log.
+func newAuditor(runtimeCtx dto.RuntimeCtx) (*auditor, error) {
+	if runtimeCtx.AuditLogPath == "" {
+		return nil, nil //nolint:nilnil // no audit log configured
+	}
+	sink, err := audit.OpenFileSink(runtimeCtx.AuditLogPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	return &auditor{
+		sink:     sink,
+		redactor: audit.NewRedactor(runtimeCtx.GetAuditRedact()...),
+	}, nil
+}
+
+// withAuditParameters carries the parameters a request was built from, so
+// that the audit record can list them.
+func withAuditParameters(ctx context.Context, params map[string]interface{}) context.Context {
+	return context.WithValue(ctx, auditParametersKey{}, params)
+}
+
+func getAuditParameters(req *http.Request) map[string]interface{} {
+	params, isMap := req.Context().Value(auditParametersKey{}).(map[string]interface{})
+	if isMap {
+		return params
+	}
+	if req.URL == nil || req.URL.RawQuery == "" {
+		return nil
+	}
+	rv := make(map[string]interface{})
+	for k, v := range req.URL.Query() {
+		if len(v) == 1 {
+			rv[k] = v[0]
+			continue
+		}
+		rv[k] = v
+	}
+	return rv
+}
+
+// record writes an audit record when the designation is an operation with
+// a mutating SQL verb. A failure to write is logged rather than returned,
+// since the call itself has already been made.
+func (a *auditor) record(
+	designation client.AnySdkDesignation,
+	protocol string,
+	req *http.Request,
+	resp *http.Response,
+	callErr error,
+	start time.Time,
+) {
+	if a == nil || designation == nil {
+		return
+	}
+	raw, ok := designation.GetDesignation()
+	if !ok {
+		return
+	}
+	op, isOp := raw.(OperationStore)
+	if !isOp {
+		return
+	}
+	var params map[string]interface{}
+	if req != nil {
+		params = getAuditParameters(req)
+	}
+	a.recordOperation(op, protocol, req, resp, params, callErr, start)
+}
+
+// AuditLocalExecution writes an audit record for a local templated call of
+// op, made with params, to the audit log named by runtimeCtx, if any.
+// Local calls are not made through a client, so their callers record them
+// once the command has run; callErr is the command's error.
+func AuditLocalExecution(
+	runtimeCtx dto.RuntimeCtx,
+	op OperationStore,
+	params map[string]interface{},
+	callErr error,
+	start time.Time,
+) error {
+	a, err := newAuditor(runtimeCtx)
+	if err != nil || a == nil {
+		return err
+	}
+	a.recordOperation(op, client.ClientProtocolTypeLocalTemplated, nil, nil, params, callErr, start)
+	return nil
+}
+
+func (a *auditor) recordOperation(
+	op OperationStore,
+	protocol string,
+	req *http.Request,
+	resp *http.Response,
+	params map[string]interface{},
+	callErr error,
+	start time.Time,
+) {
+	if op == nil || !audit.IsMutatingVerb(op.GetSQLVerb()) {
+		return
+	}
+	rec := audit.Record{
+		Timestamp:  start.UTC(),
+		Service:    op.getServiceNameForProvider(),
+		Method:     op.GetMethodKey(),
+		SQLVerb:    op.GetSQLVerb(),
+		Protocol:   protocol,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000, //nolint:mnd // microseconds to milliseconds
+	}
+	if rec.Method == "" {
+		rec.Method = op.GetName()
+	}
+	if prov := op.GetProvider(); prov != nil {
+		rec.Provider = prov.GetName()
+	}
+	if rsc := op.GetResource(); rsc != nil {
+		rec.Resource = rsc.GetName()
+	}
+	if req != nil {
+		rec.HTTPMethod = req.Method
+		rec.URL = a.redactor.RedactURL(req.URL)
+	}
+	rec.Parameters = a.redactor.RedactParameters(params)
+	if resp != nil {
+		rec.StatusCode = resp.StatusCode
+		rec.RequestIDs = audit.GetRequestIDs(resp.Header)
+	}
+	if callErr != nil {
+		rec.Error = callErr.Error()
+	}
+	if err := a.sink.Write(rec); err != nil {
+		logging.GetLogger().Errorf("audit log write failed for %s: %v", op.GetName(), err)
+	}
+}
@@ -0,0 +1,106 @@
+package anysdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/audit"
+	"github.com/stackql/any-sdk/pkg/client"
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+func newTestAuditor(buf *bytes.Buffer) *auditor {
+	return &auditor{sink: audit.NewJSONLinesSink(buf), redactor: audit.NewRedactor()}
+}
+
+func TestAuditor_RecordsMutatingCall(t *testing.T) {
+	var buf bytes.Buffer
+	a := newTestAuditor(&buf)
+	req := mustReq(t, http.MethodPost, "https://example.com/v1/instances?access_token=abc", `{}`)
+	req = req.WithContext(withAuditParameters(req.Context(), map[string]interface{}{
+		"name":     "vm1",
+		"password": "hunter2",
+	}))
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-Request-Id", "req-1")
+
+	a.record(newAnySdkOpStoreDesignation(mustLoadWidgetsMethod(t, "insert_widget")), client.ClientProtocolTypeHTTP, req, resp, nil, time.Now())
+
+	var rec audit.Record
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("bad record %q: %v", buf.String(), err)
+	}
+	if rec.Provider != "contrivedprovider" || rec.Service != "widgets" || rec.Resource != "widgets" || rec.Method != "insert_widget" {
+		t.Fatalf("unexpected designation %+v", rec)
+	}
+	if rec.SQLVerb != "insert" || rec.HTTPMethod != http.MethodPost || rec.StatusCode != http.StatusOK || rec.RequestIDs["X-Request-Id"] != "req-1" {
+		t.Fatalf("unexpected call details %+v", rec)
+	}
+	if rec.Parameters["name"] != "vm1" || rec.Parameters["password"] != audit.Redacted || strings.Contains(rec.URL, "abc") {
+		t.Fatalf("expected redaction %+v", rec)
+	}
+}
+
+func TestAuditor_SkipsReadsAndNil(t *testing.T) {
+	var buf bytes.Buffer
+	a := newTestAuditor(&buf)
+	req := mustReq(t, http.MethodGet, "https://example.com/v1/instances", "")
+	a.record(newAnySdkOpStoreDesignation(mustLoadWidgetsMethod(t, "list_widgets")), client.ClientProtocolTypeHTTP, req, nil, nil, time.Now())
+	if buf.Len() != 0 {
+		t.Fatalf("expected no record for select, got %s", buf.String())
+	}
+	var none *auditor
+	none.record(newAnySdkOpStoreDesignation(mustLoadWidgetsMethod(t, "delete_widget")), client.ClientProtocolTypeHTTP, req, nil, nil, time.Now())
+}
+
+func TestAuditor_RecordsThroughClient(t *testing.T) {
+	srv, _ := newScriptedServer(t, http.StatusInternalServerError)
+	var buf bytes.Buffer
+	hc, _ := newAnySdkHttpClient(http.DefaultClient).(*anySdkHttpClient)
+	hc.auditor = newTestAuditor(&buf)
+	req := mustReq(t, http.MethodDelete, srv.URL+"/instances/vm1", "")
+	resp, _ := hc.Do(newAnySdkOpStoreDesignation(mustLoadWidgetsMethod(t, "delete_widget")), NewwHTTPAnySdkArgList(req))
+	if resp != nil {
+		if r, err := resp.GetHttpResponse(); err == nil && r != nil {
+			r.Body.Close()
+		}
+	}
+	var rec audit.Record
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("bad record %q: %v", buf.String(), err)
+	}
+	if rec.SQLVerb != "delete" || rec.StatusCode != http.StatusInternalServerError || rec.HTTPMethod != http.MethodDelete {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
+
+func TestAuditLocalExecution_RecordsMutatingCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	rtCtx := dto.RuntimeCtx{AuditLogPath: path}
+	params := map[string]interface{}{"name": "vm1", "password": "hunter2"}
+	if err := AuditLocalExecution(rtCtx, mustLoadWidgetsMethod(t, "reset_widget"), params, errors.New("exit status 1"), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := AuditLocalExecution(rtCtx, mustLoadWidgetsMethod(t, "list_widgets"), params, nil, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rec audit.Record
+	if err := json.Unmarshal(b, &rec); err != nil {
+		t.Fatalf("expected exactly one record, got %q: %v", string(b), err)
+	}
+	if rec.Protocol != client.ClientProtocolTypeLocalTemplated || rec.SQLVerb != "exec" ||
+		rec.Parameters["password"] != "REDACTED" || rec.Error != "exit status 1" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
@@ -0,0 +1,204 @@
+package anysdk
+
+import (
+	"crypto/md5" //nolint:gosec // md5 is offered for ETag comparison, not security
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/media"
+	"github.com/stackql/any-sdk/pkg/response"
+)
+
+var (
+	_ BinaryResponse            = &standardBinaryResponse{}
+	_ jsonpointer.JSONPointable = standardBinaryResponse{}
+)
+
+const (
+	BinaryResponseModeInline string = "inline"
+	BinaryResponseModeFile   string = "file"
+
+	BinaryChecksumSHA256 string = "sha256"
+	BinaryChecksumMD5    string = "md5"
+
+	BinaryColumnContentType   string = "content_type"
+	BinaryColumnContentLength string = "content_length"
+	BinaryColumnChecksum      string = "checksum"
+	BinaryColumnContent       string = "content"
+	BinaryColumnPath          string = "path"
+)
+
+// BinaryResponse declares that a successful response body is opaque bytes.
+// It is returned as a single row of metadata columns, with the content
+// either inline as base64 or written to a local file.
+type BinaryResponse interface {
+	GetMode() string
+	GetPathParam() string
+	GetChecksumAlgorithm() string
+}
+
+type standardBinaryResponse struct {
+	Mode      string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	PathParam string `json:"path_param,omitempty" yaml:"path_param,omitempty"`
+	Checksum  string `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+}
+
+func (br standardBinaryResponse) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "mode":
+		return br.Mode, nil
+	case "path_param":
+		return br.PathParam, nil
+	case "checksum":
+		return br.Checksum, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from BinaryResponse doc object", token)
+	}
+}
+
+// GetMode defaults to inline.
+func (br *standardBinaryResponse) GetMode() string {
+	if br.Mode == "" {
+		return BinaryResponseModeInline
+	}
+	return br.Mode
+}
+
+func (br *standardBinaryResponse) GetPathParam() string {
+	return br.PathParam
+}
+
+// GetChecksumAlgorithm defaults to sha256.
+func (br *standardBinaryResponse) GetChecksumAlgorithm() string {
+	if br.Checksum == "" {
+		return BinaryChecksumSHA256
+	}
+	return br.Checksum
+}
+
+func (br *standardBinaryResponse) newHash() (hash.Hash, error) {
+	switch br.GetChecksumAlgorithm() {
+	case BinaryChecksumSHA256:
+		return sha256.New(), nil
+	case BinaryChecksumMD5:
+		return md5.New(), nil //nolint:gosec // see import
+	default:
+		return nil, fmt.Errorf("unsupported binary response checksum '%s'", br.Checksum)
+	}
+}
+
+// getBinaryRowSchema returns the schema of the single row a binary response
+// produces, used in place of the response body schema for column discovery.
+func (br *standardBinaryResponse) getBinaryRowSchema() Schema {
+	sc := openapi3.NewObjectSchema().
+		WithProperty(BinaryColumnContentType, openapi3.NewStringSchema()).
+		WithProperty(BinaryColumnContentLength, openapi3.NewInt64Schema()).
+		WithProperty(BinaryColumnChecksum, openapi3.NewStringSchema())
+	if br.GetMode() == BinaryResponseModeFile {
+		sc = sc.WithProperty(BinaryColumnPath, openapi3.NewStringSchema())
+	} else {
+		sc = sc.WithProperty(BinaryColumnContent, openapi3.NewStringSchema())
+	}
+	return newSchema(sc, nil, "", "")
+}
+
+// getBinaryResponse returns the operation's binary response declaration, if any.
+func (op *standardOpenAPIOperationStore) getBinaryResponse() (*standardBinaryResponse, bool) {
+	if op.Response == nil || op.Response.Binary == nil {
+		return nil, false
+	}
+	return op.Response.Binary, true
+}
+
+// processBinaryResponse reads a successful binary response into a single
+// row. In file mode the destination is the context parameter named by
+// path_param, read from the request context.
+func (op *standardOpenAPIOperationStore) processBinaryResponse(
+	br *standardBinaryResponse,
+	httpResponse *http.Response,
+) (response.Response, error) {
+	body := httpResponse.Body
+	defer body.Close()
+	h, err := br.newHash()
+	if err != nil {
+		return nil, err
+	}
+	contentType := media.MediaTypeOctetStream
+	if ct := httpResponse.Header.Get("Content-Type"); ct != "" {
+		contentType = ct
+	}
+	row := map[string]interface{}{
+		BinaryColumnContentType: contentType,
+	}
+	switch br.GetMode() {
+	case BinaryResponseModeInline:
+		b, readErr := io.ReadAll(io.TeeReader(body, h))
+		if readErr != nil {
+			return nil, readErr
+		}
+		row[BinaryColumnContentLength] = int64(len(b))
+		row[BinaryColumnContent] = base64.StdEncoding.EncodeToString(b)
+	case BinaryResponseModeFile:
+		path, pathErr := getBinaryResponsePath(br, httpResponse)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		n, writeErr := writeBinaryResponseFile(path, io.TeeReader(body, h))
+		if writeErr != nil {
+			return nil, writeErr
+		}
+		row[BinaryColumnContentLength] = n
+		row[BinaryColumnPath] = path
+	default:
+		return nil, fmt.Errorf("unsupported binary response mode '%s'", br.Mode)
+	}
+	row[BinaryColumnChecksum] = hex.EncodeToString(h.Sum(nil))
+	return response.NewResponse(row, row, httpResponse), nil
+}
+
+func getBinaryResponsePath(br *standardBinaryResponse, httpResponse *http.Response) (string, error) {
+	if br.GetPathParam() == "" {
+		return "", fmt.Errorf("binary response in file mode requires a path_param")
+	}
+	if httpResponse.Request == nil {
+		return "", fmt.Errorf("binary response path parameter '%s' not available", br.GetPathParam())
+	}
+	contextKey := dto.ContextKey(fmt.Sprintf("%s%s", dto.ContextPrefixStackqlRequest, br.GetPathParam()))
+	path, isString := httpResponse.Request.Context().Value(contextKey).(string)
+	if !isString || path == "" {
+		return "", fmt.Errorf("binary response path parameter '%s' not supplied", br.GetPathParam())
+	}
+	return path, nil
+}
+
+// writeBinaryResponseFile writes r to a temporary file beside path and
+// renames it into place, so that a failed download leaves no partial file.
+func writeBinaryResponseFile(path string, r io.Reader) (int64, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,114 @@
+package anysdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+func binaryHTTPResponse(status int, body string, ctx context.Context) *http.Response {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/obj", nil)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"image/png"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestProcessResponse_BinaryInline(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "download_widget")
+	op.Response.Binary = &standardBinaryResponse{}
+	resp, err := op.ProcessResponse(binaryHTTPResponse(200, "\x89PNG\x00\xff", context.Background()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, _ := resp.GetResponse()
+	row, ok := r.GetProcessedBody().(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a single row, got %T", r.GetProcessedBody())
+	}
+	if row[BinaryColumnContentType] != "image/png" || row[BinaryColumnContentLength] != int64(6) ||
+		row[BinaryColumnContent] != "iVBORwD/" {
+		t.Fatalf("unexpected row %v", row)
+	}
+	if row[BinaryColumnChecksum] != "ffdb519b788f33bddc8647611c3a24cc5e1a9ef5780c1f6f828a637c309ad8f1" {
+		t.Fatalf("unexpected checksum %v", row[BinaryColumnChecksum])
+	}
+}
+
+func TestProcessResponse_BinaryFile(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "report.csv")
+	op := mustLoadWidgetsMethod(t, "download_widget")
+	op.Response.Binary.Checksum = BinaryChecksumMD5
+	ctx := context.WithValue(context.Background(), dto.ContextKey(dto.ContextPrefixStackqlRequest+"destination"), dest) //nolint:staticcheck // mirrors request.go
+	resp, err := op.ProcessResponse(binaryHTTPResponse(200, "a,b\n1,2\n", ctx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, _ := resp.GetResponse()
+	row := r.GetProcessedBody().(map[string]interface{})
+	if row[BinaryColumnPath] != dest || row[BinaryColumnContentLength] != int64(8) ||
+		row[BinaryColumnChecksum] != "e5ebd4c02cefbe7955977c67ada242b7" {
+		t.Fatalf("unexpected row %v", row)
+	}
+	if _, hasContent := row[BinaryColumnContent]; hasContent {
+		t.Fatalf("file mode should not inline content")
+	}
+	b, err := os.ReadFile(dest)
+	if err != nil || string(b) != "a,b\n1,2\n" {
+		t.Fatalf("unexpected file content %q %v", b, err)
+	}
+}
+
+func TestProcessResponse_BinaryFileRequiresPath(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "download_widget")
+	if _, err := op.ProcessResponse(binaryHTTPResponse(200, "x", context.Background())); err == nil {
+		t.Fatalf("expected an error for a missing path parameter")
+	}
+}
+
+func TestGetSelectSchemaAndObjectPath_Binary(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "download_widget")
+	s, path, err := op.GetSelectSchemaAndObjectPath()
+	if err != nil || path != "" {
+		t.Fatalf("unexpected result %q %v", path, err)
+	}
+	props := s.getProperties()
+	for _, col := range []string{BinaryColumnContentType, BinaryColumnContentLength, BinaryColumnChecksum, BinaryColumnPath} {
+		if _, ok := props[col]; !ok {
+			t.Fatalf("missing column %s", col)
+		}
+	}
+	if _, ok := props[BinaryColumnContent]; ok {
+		t.Fatalf("file mode should not declare a content column")
+	}
+}
+
+func TestBinaryResponse_DeclaredByDocument(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "download_widget")
+	br, isBinary := op.getBinaryResponse()
+	if !isBinary || br.Mode != BinaryResponseModeFile || br.PathParam != "destination" {
+		t.Fatalf("expected the documented binary block, got %+v", br)
+	}
+	if violations := op.ValidateResponseBody("\x89PNG"); violations != nil {
+		t.Fatalf("expected binary bodies to be skipped by validation, got %v", violations)
+	}
+	dest := filepath.Join(t.TempDir(), "widget.png")
+	ctx := context.WithValue(context.Background(), dto.ContextKey(dto.ContextPrefixStackqlRequest+"destination"), dest) //nolint:staticcheck // mirrors request.go
+	resp, err := op.ProcessResponse(binaryHTTPResponse(200, "\x89PNG\x00\xff", ctx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, _ := resp.GetResponse()
+	row := r.GetProcessedBody().(map[string]interface{})
+	if row[BinaryColumnPath] != dest || row[BinaryColumnChecksum] != "ffdb519b788f33bddc8647611c3a24cc5e1a9ef5780c1f6f828a637c309ad8f1" {
+		t.Fatalf("unexpected row %v", row)
+	}
+}
@@ -0,0 +1,143 @@
+package anysdk
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/circuitbreaker"
+)
+
+var (
+	_ CircuitBreakerPolicy      = &standardCircuitBreakerPolicy{}
+	_ jsonpointer.JSONPointable = standardCircuitBreakerPolicy{}
+
+	defaultCircuitBreakerFailureStatusCodes = []int{
+		http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// CircuitBreakerPolicy describes a per-host circuit breaker. Breakers are
+// keyed by provider and host and shared by every concurrent call in the
+// process; each attempt made by the retry loop is an outcome. Transport
+// errors and failure status codes count as failures.
+type CircuitBreakerPolicy interface {
+	GetFailureRateThreshold() float64
+	GetMinimumRequests() int
+	GetWindowSize() int
+	GetCoolDown() time.Duration
+	GetHalfOpenMaxRequests() int
+	GetFailureStatusCodes() []int
+	IsFailureStatus(statusCode int) bool
+	ToBreakerConfig() circuitbreaker.Config
+}
+
+type standardCircuitBreakerPolicy struct {
+	FailureRateThreshold float64 `json:"failure_rate_threshold,omitempty" yaml:"failure_rate_threshold,omitempty"`
+	MinimumRequests      int     `json:"minimum_requests,omitempty" yaml:"minimum_requests,omitempty"`
+	WindowSize           int     `json:"window_size,omitempty" yaml:"window_size,omitempty"`
+	CoolDownMs           int     `json:"cool_down_ms,omitempty" yaml:"cool_down_ms,omitempty"`
+	HalfOpenMaxRequests  int     `json:"half_open_max_requests,omitempty" yaml:"half_open_max_requests,omitempty"`
+	FailureStatusCodes   []int   `json:"failure_status_codes,omitempty" yaml:"failure_status_codes,omitempty"`
+}
+
+func (cb standardCircuitBreakerPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "failure_rate_threshold":
+		return cb.FailureRateThreshold, nil
+	case "minimum_requests":
+		return cb.MinimumRequests, nil
+	case "window_size":
+		return cb.WindowSize, nil
+	case "cool_down_ms":
+		return cb.CoolDownMs, nil
+	case "half_open_max_requests":
+		return cb.HalfOpenMaxRequests, nil
+	case "failure_status_codes":
+		return cb.FailureStatusCodes, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from CircuitBreakerPolicy doc object", token)
+	}
+}
+
+func (cb *standardCircuitBreakerPolicy) GetFailureRateThreshold() float64 {
+	if cb.FailureRateThreshold <= 0 || cb.FailureRateThreshold > 1 {
+		return circuitbreaker.DefaultFailureRateThreshold
+	}
+	return cb.FailureRateThreshold
+}
+
+func (cb *standardCircuitBreakerPolicy) GetMinimumRequests() int {
+	if cb.MinimumRequests <= 0 {
+		return circuitbreaker.DefaultMinimumRequests
+	}
+	return cb.MinimumRequests
+}
+
+func (cb *standardCircuitBreakerPolicy) GetWindowSize() int {
+	if cb.WindowSize <= 0 {
+		return circuitbreaker.DefaultWindowSize
+	}
+	return cb.WindowSize
+}
+
+func (cb *standardCircuitBreakerPolicy) GetCoolDown() time.Duration {
+	if cb.CoolDownMs <= 0 {
+		return circuitbreaker.DefaultCoolDown
+	}
+	return time.Duration(cb.CoolDownMs) * time.Millisecond
+}
+
+func (cb *standardCircuitBreakerPolicy) GetHalfOpenMaxRequests() int {
+	if cb.HalfOpenMaxRequests <= 0 {
+		return circuitbreaker.DefaultHalfOpenMaxRequests
+	}
+	return cb.HalfOpenMaxRequests
+}
+
+func (cb *standardCircuitBreakerPolicy) GetFailureStatusCodes() []int {
+	if len(cb.FailureStatusCodes) == 0 {
+		out := make([]int, len(defaultCircuitBreakerFailureStatusCodes))
+		copy(out, defaultCircuitBreakerFailureStatusCodes)
+		return out
+	}
+	return cb.FailureStatusCodes
+}
+
+func (cb *standardCircuitBreakerPolicy) IsFailureStatus(statusCode int) bool {
+	for _, c := range cb.GetFailureStatusCodes() {
+		if c == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (cb *standardCircuitBreakerPolicy) ToBreakerConfig() circuitbreaker.Config {
+	return circuitbreaker.Config{
+		FailureRateThreshold: cb.GetFailureRateThreshold(),
+		MinimumRequests:      cb.GetMinimumRequests(),
+		WindowSize:           cb.GetWindowSize(),
+		CoolDown:             cb.GetCoolDown(),
+		HalfOpenMaxRequests:  cb.GetHalfOpenMaxRequests(),
+	}
+}
+
+// hostBreaker pairs a shared breaker with the policy that decides which
+// responses count as failures.
+type hostBreaker struct {
+	breaker circuitbreaker.Breaker
+	policy  CircuitBreakerPolicy
+}
+
+func (hb *hostBreaker) isSuccess(resp *http.Response, err error) bool {
+	if err != nil || resp == nil {
+		return false
+	}
+	return !hb.policy.IsFailureStatus(resp.StatusCode)
+}
@@ -0,0 +1,93 @@
+package anysdk
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/circuitbreaker"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCircuitBreakerPolicy_UnmarshalAndDefaults(t *testing.T) {
+	raw := `
+circuitBreaker:
+  failure_rate_threshold: 0.25
+  cool_down_ms: 1500
+`
+	var cfg standardStackQLConfig
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	cb, ok := cfg.GetCircuitBreakerPolicy()
+	if !ok {
+		t.Fatalf("expected circuitBreaker block to be present")
+	}
+	want := circuitbreaker.Config{
+		FailureRateThreshold: 0.25,
+		MinimumRequests:      circuitbreaker.DefaultMinimumRequests,
+		WindowSize:           circuitbreaker.DefaultWindowSize,
+		CoolDown:             1500 * time.Millisecond,
+		HalfOpenMaxRequests:  circuitbreaker.DefaultHalfOpenMaxRequests,
+	}
+	if got := cb.ToBreakerConfig(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if !cb.IsFailureStatus(http.StatusServiceUnavailable) || cb.IsFailureStatus(http.StatusNotFound) {
+		t.Fatalf("unexpected default failure status codes %v", cb.GetFailureStatusCodes())
+	}
+}
+
+func TestCircuitBreaker_OpenCircuitShortCircuitsRetries(t *testing.T) {
+	srv, h := newScriptedServer(t, http.StatusServiceUnavailable)
+	hc := newTestHttpClient()
+	policy := fastPolicy(5, []string{"GET"}, []int{http.StatusServiceUnavailable})
+	cbPolicy := &standardCircuitBreakerPolicy{MinimumRequests: 2, WindowSize: 2, CoolDownMs: 60000}
+	breaker := &hostBreaker{
+		breaker: circuitbreaker.NewBreaker("prov/host", cbPolicy.ToBreakerConfig()),
+		policy:  cbPolicy,
+	}
+
+	_, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, breaker, nil)
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected open circuit error, got %v", err)
+	}
+	if got := atomic.LoadInt64(&h.calls); got != 2 {
+		t.Fatalf("expected the breaker to stop the retry loop after 2 calls, got %d", got)
+	}
+
+	_, err = hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, breaker, nil)
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected subsequent call to fail fast, got %v", err)
+	}
+	if got := atomic.LoadInt64(&h.calls); got != 2 {
+		t.Fatalf("expected no request while open, got %d calls", got)
+	}
+}
+
+func TestCircuitBreaker_NonFailureStatusesKeepCircuitClosed(t *testing.T) {
+	srv, _ := newScriptedServer(t, http.StatusNotFound)
+	hc := newTestHttpClient()
+	cbPolicy := &standardCircuitBreakerPolicy{MinimumRequests: 1, WindowSize: 1}
+	breaker := &hostBreaker{
+		breaker: circuitbreaker.NewBreaker("prov/host", cbPolicy.ToBreakerConfig()),
+		policy:  cbPolicy,
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), fastPolicy(1, nil, nil), nil, breaker, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if state := breaker.breaker.GetState(); state != circuitbreaker.StateClosed {
+		t.Fatalf("expected closed circuit, got %s", state)
+	}
+}
+
+func TestResolveCircuitBreaker_NilWithoutOperationStore(t *testing.T) {
+	req := mustReq(t, http.MethodGet, "https://api.github.com/repos", "")
+	if b := resolveCircuitBreaker(nil, req); b != nil {
+		t.Fatalf("expected nil breaker for nil designation")
+	}
+}
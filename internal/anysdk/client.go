@@ -3,6 +3,7 @@ package anysdk
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,12 +12,16 @@ import (
 	"time"
 
 	"github.com/stackql/any-sdk/pkg/auth_util"
+	"github.com/stackql/any-sdk/pkg/circuitbreaker"
 	"github.com/stackql/any-sdk/pkg/client"
+	"github.com/stackql/any-sdk/pkg/dryrun"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/internaldto"
 	"github.com/stackql/any-sdk/pkg/latetranslator"
 	"github.com/stackql/any-sdk/pkg/netutils"
+	"github.com/stackql/any-sdk/pkg/ratelimit"
 	"github.com/stackql/any-sdk/pkg/requesttranslate"
+	"github.com/stackql/any-sdk/pkg/telemetry"
 )
 
 var (
@@ -28,6 +33,9 @@ var (
 type anySdkHttpClient struct {
 	client         *http.Client
 	lateTranslator latetranslator.LateTranslator
+	auditor        *auditor
+	dryRun         bool
+	providerName   string
 }
 
 func newAnySdkHttpClient(client *http.Client) client.AnySdkClient {
@@ -129,7 +137,26 @@ func (hc *anySdkHttpClient) Do(designation client.AnySdkDesignation, argList cli
 		return nil, translationErr
 	}
 	policy := resolveRetryPolicy(designation)
-	httpResponse, httpResponseErr := hc.doWithRetry(translatedRequest, policy)
+	limiter := resolveRateLimiter(designation, translatedRequest)
+	breaker := resolveCircuitBreaker(designation, translatedRequest)
+	compressionPolicy, _ := resolveCompressionPolicy(designation)
+	ctx, requestSpan := telemetry.StartSpan(translatedRequest.Context(), telemetry.SpanHTTPRequest, designationAttributes(designation)...)
+	if hc.providerName != "" {
+		ctx = netutils.WithProviderName(ctx, hc.providerName)
+	}
+	if hc.dryRun {
+		ctx = dryrun.WithCapture(ctx)
+	}
+	if ctx != translatedRequest.Context() {
+		translatedRequest = translatedRequest.WithContext(ctx)
+	}
+	start := time.Now()
+	httpResponse, httpResponseErr := hc.doWithRetryAndGuards(translatedRequest, policy, limiter, breaker, compressionPolicy)
+	hc.auditor.record(designation, client.ClientProtocolTypeHTTP, translatedRequest, httpResponse, httpResponseErr, start)
+	if httpResponse != nil {
+		requestSpan.SetAttributes(telemetry.Int(telemetry.AttrHTTPStatusCode, httpResponse.StatusCode))
+	}
+	telemetry.EndSpan(requestSpan, httpResponseErr)
 	if httpResponseErr != nil {
 		return nil, httpResponseErr
 	}
@@ -137,6 +164,27 @@ func (hc *anySdkHttpClient) Do(designation client.AnySdkDesignation, argList cli
 	return anySdkHttpResponse, nil
 }
 
+// designationAttributes names the provider and operation behind a
+// designation, for telemetry.
+func designationAttributes(designation client.AnySdkDesignation) []telemetry.Attribute {
+	if designation == nil {
+		return nil
+	}
+	raw, ok := designation.GetDesignation()
+	if !ok {
+		return nil
+	}
+	op, isOp := raw.(OperationStore)
+	if !isOp {
+		return nil
+	}
+	rv := []telemetry.Attribute{telemetry.String(telemetry.AttrOperation, op.GetName())}
+	if prov := op.GetProvider(); prov != nil {
+		rv = append(rv, telemetry.String(telemetry.AttrProvider, prov.GetName()))
+	}
+	return rv
+}
+
 // resolveRetryPolicy walks the designation back to its OperationStore (when
 // present) and consults the inheritance chain. Falls back to defaults when the
 // designation does not carry an OperationStore (e.g. monitor pings, GraphQL).
@@ -154,11 +202,191 @@ func resolveRetryPolicy(designation client.AnySdkDesignation) RetryPolicy {
 	return DefaultRetryPolicy()
 }
 
-// doWithRetry buffers the request body once, then runs up to MaxAttempts tries
+// resolveCompressionPolicy returns the compression policy declared in the
+// designation's inheritance chain, if any.
+func resolveCompressionPolicy(designation client.AnySdkDesignation) (CompressionPolicy, bool) {
+	if designation == nil {
+		return nil, false
+	}
+	raw, ok := designation.GetDesignation()
+	if !ok {
+		return nil, false
+	}
+	op, isOp := raw.(OperationStore)
+	if !isOp {
+		return nil, false
+	}
+	return op.GetCompressionPolicy()
+}
+
+// resolveRateLimiter returns the shared limiter for the designation's
+// provider and the request host, or nil when no rateLimit block is declared
+// anywhere in the inheritance chain.
+func resolveRateLimiter(designation client.AnySdkDesignation, req *http.Request) ratelimit.Limiter {
+	op, host, ok := resolveGuardTarget(designation, req)
+	if !ok {
+		return nil
+	}
+	rl, hasRateLimit := op.GetRateLimitPolicy()
+	if !hasRateLimit {
+		return nil
+	}
+	return ratelimit.GetDefaultRegistry().GetLimiter(
+		guardKey(op, host),
+		rl.ForHost(host).ToLimiterConfig(),
+	)
+}
+
+// resolveCircuitBreaker returns the shared breaker for the designation's
+// provider and the request host, or nil when no circuitBreaker block is
+// declared anywhere in the inheritance chain.
+func resolveCircuitBreaker(designation client.AnySdkDesignation, req *http.Request) *hostBreaker {
+	op, host, ok := resolveGuardTarget(designation, req)
+	if !ok {
+		return nil
+	}
+	cb, hasBreaker := op.GetCircuitBreakerPolicy()
+	if !hasBreaker {
+		return nil
+	}
+	return &hostBreaker{
+		breaker: circuitbreaker.GetDefaultRegistry().GetBreaker(guardKey(op, host), cb.ToBreakerConfig()),
+		policy:  cb,
+	}
+}
+
+func resolveGuardTarget(designation client.AnySdkDesignation, req *http.Request) (OperationStore, string, bool) {
+	if designation == nil || req == nil || req.URL == nil {
+		return nil, "", false
+	}
+	raw, ok := designation.GetDesignation()
+	if !ok {
+		return nil, "", false
+	}
+	op, isOp := raw.(OperationStore)
+	if !isOp {
+		return nil, "", false
+	}
+	return op, strings.ToLower(req.URL.Host), true
+}
+
+// guardKey identifies the shared per provider, per host budget and breaker.
+func guardKey(op OperationStore, host string) string {
+	providerName := ""
+	if prov := op.GetProvider(); prov != nil {
+		providerName = prov.GetName()
+	}
+	return fmt.Sprintf("%s/%s", providerName, host)
+}
+
+// sendGuarded sends a single attempt. The circuit breaker (if any) is
+// consulted first, so an open circuit fails fast without spending a rate
+// limit token; the limiter (if any) is then waited on and afterwards fed the
+// response headers. The attempt's outcome is reported to the breaker.
+func (hc *anySdkHttpClient) sendGuarded(
+	req *http.Request,
+	limiter ratelimit.Limiter,
+	breaker *hostBreaker,
+) (*http.Response, error) {
+	if breaker != nil {
+		done, allowErr := breaker.breaker.Allow()
+		if allowErr != nil {
+			return nil, allowErr
+		}
+		resp, err := hc.sendLimited(req, limiter)
+		done(breaker.isSuccess(resp, err))
+		return resp, err
+	}
+	return hc.sendLimited(req, limiter)
+}
+
+// sendAttempt is sendGuarded within an attempt span, reported to the HTTP
+// attempt metrics. The response body is decoded (when a compression policy
+// is supplied) before it is returned, so retry decisions see plain bytes.
+func (hc *anySdkHttpClient) sendAttempt(
+	req *http.Request,
+	attempt int,
+	limiter ratelimit.Limiter,
+	breaker *hostBreaker,
+	compressionPolicy CompressionPolicy,
+) (*http.Response, error) {
+	host := ""
+	if req.URL != nil {
+		host = req.URL.Host
+	}
+	ctx, span := telemetry.StartSpan(
+		req.Context(),
+		telemetry.SpanHTTPAttempt,
+		telemetry.Int(telemetry.AttrAttempt, attempt),
+		telemetry.String(telemetry.AttrHTTPMethod, req.Method),
+		telemetry.String(telemetry.AttrServerAddress, host),
+	)
+	attemptReq := req
+	if ctx != req.Context() {
+		attemptReq = req.WithContext(ctx)
+	}
+	start := time.Now()
+	resp, err := hc.sendGuarded(attemptReq, limiter, breaker)
+	if err == nil && compressionPolicy != nil {
+		if decodeErr := compressionPolicy.DecodeResponse(resp); decodeErr != nil {
+			_ = resp.Body.Close()
+			resp, err = nil, decodeErr
+		}
+	}
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		span.SetAttributes(telemetry.Int(telemetry.AttrHTTPStatusCode, statusCode))
+	}
+	telemetry.RecordHTTPAttempt(ctx, telemetry.HTTPAttempt{
+		Method:     req.Method,
+		Host:       host,
+		StatusCode: statusCode,
+		Attempt:    attempt,
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+	telemetry.EndSpan(span, err)
+	return resp, err
+}
+
+// sendLimited sends a single attempt, first waiting on the limiter (if any)
+// and afterwards feeding the response headers back to it.
+func (hc *anySdkHttpClient) sendLimited(req *http.Request, limiter ratelimit.Limiter) (*http.Response, error) {
+	if limiter == nil {
+		return hc.client.Do(req)
+	}
+	release, acquireErr := limiter.Acquire(req.Context())
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
+	defer release()
+	resp, err := hc.client.Do(req)
+	if resp != nil {
+		limiter.Observe(resp.Header)
+	}
+	return resp, err
+}
+
+// doWithRetryAndGuards buffers the request body once, then runs up to MaxAttempts tries
 // with backoff between them. Only requests whose method is in the policy's
 // retryable-methods set get retried; everything else makes a single attempt.
-// Network errors and policy-listed status codes are treated as retryable.
-func (hc *anySdkHttpClient) doWithRetry(req *http.Request, policy RetryPolicy) (*http.Response, error) {
+// Methods covered by an idempotency block are retryable and carry a
+// generated idempotency key. Network errors, policy-listed status codes and error bodies matching a
+// body predicate are treated as retryable. When the policy respects
+// Retry-After, a server-supplied delay replaces the computed backoff, and a
+// delay beyond the policy's max delay returns the response instead.
+// Every attempt, including retries, draws from the rate limiter when one is supplied,
+// and is an outcome for the circuit breaker; an open circuit ends the loop at once.
+// A compression policy, when supplied, encodes the body after the idempotency
+// key is in place and decodes each attempt's response.
+func (hc *anySdkHttpClient) doWithRetryAndGuards(
+	req *http.Request,
+	policy RetryPolicy,
+	limiter ratelimit.Limiter,
+	breaker *hostBreaker,
+	compressionPolicy CompressionPolicy,
+) (*http.Response, error) {
 	if policy == nil {
 		policy = DefaultRetryPolicy()
 	}
@@ -167,6 +395,18 @@ func (hc *anySdkHttpClient) doWithRetry(req *http.Request, policy RetryPolicy) (
 	if !methodRetryable {
 		maxAttempts = 1
 	}
+	// The key is injected once, ahead of body buffering, so every attempt
+	// replays the same logical mutation.
+	if idem, hasIdem := policy.GetIdempotencyPolicy(); hasIdem && idem.IsMethodCovered(req.Method) {
+		if _, idemErr := idem.Apply(req); idemErr != nil {
+			return nil, idemErr
+		}
+	}
+	if compressionPolicy != nil {
+		if encodeErr := compressionPolicy.EncodeRequest(req); encodeErr != nil {
+			return nil, encodeErr
+		}
+	}
 
 	var bodyBytes []byte
 	if req.Body != nil && maxAttempts > 1 {
@@ -186,12 +426,21 @@ func (hc *anySdkHttpClient) doWithRetry(req *http.Request, policy RetryPolicy) (
 
 	var lastResp *http.Response
 	var lastErr error
+	var lastHeader http.Header
+	var previousDelay time.Duration
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if attempt > 1 {
 			if bodyBytes != nil {
 				req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 			}
-			delay := policy.BackoffFor(attempt - 1)
+			delay := policy.NextBackoff(attempt-1, previousDelay)
+			if policy.IsRespectRetryAfter() {
+				if serverDelay, ok := policy.RetryAfterFor(lastHeader); ok {
+					delay = serverDelay
+				}
+			}
+			previousDelay = delay
+			lastHeader = nil
 			ctx := req.Context()
 			if delay > 0 {
 				timer := time.NewTimer(delay)
@@ -206,16 +455,24 @@ func (hc *anySdkHttpClient) doWithRetry(req *http.Request, policy RetryPolicy) (
 				}
 			}
 		}
-		resp, err := hc.client.Do(req)
+		resp, err := hc.sendAttempt(req, attempt, limiter, breaker, compressionPolicy)
 		lastResp = resp
 		lastErr = err
 		if err != nil {
-			if attempt < maxAttempts {
+			if attempt < maxAttempts && !errors.Is(err, circuitbreaker.ErrOpen) {
 				continue
 			}
 			return nil, err
 		}
-		if attempt < maxAttempts && policy.IsStatusRetryable(resp.StatusCode) {
+		if attempt < maxAttempts && isResponseRetryable(policy, resp) {
+			if policy.IsRespectRetryAfter() {
+				// Retrying before the server's delay would only be throttled
+				// again, so a delay beyond the policy's max delay ends the loop.
+				if serverDelay, ok := policy.RetryAfterFor(resp.Header); ok && serverDelay > policy.GetMaxDelay() {
+					return resp, nil
+				}
+			}
+			lastHeader = resp.Header
 			// drain & close so the connection can be reused
 			if resp.Body != nil {
 				_, _ = io.Copy(io.Discard, resp.Body)
@@ -228,6 +485,26 @@ func (hc *anySdkHttpClient) doWithRetry(req *http.Request, policy RetryPolicy) (
 	return lastResp, lastErr
 }
 
+// isResponseRetryable checks the status code and, for error responses only,
+// the body predicates. The body is buffered and restored so a non-retried
+// response can still be consumed by the caller.
+func isResponseRetryable(policy RetryPolicy, resp *http.Response) bool {
+	if policy.IsStatusRetryable(resp.StatusCode) {
+		return true
+	}
+	conditions := policy.GetRetryableConditions()
+	if resp.StatusCode < http.StatusBadRequest || resp.Body == nil || len(conditions.GetBodyPredicates()) == 0 {
+		return false
+	}
+	buf, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	if readErr != nil {
+		return false
+	}
+	return conditions.IsBodyRetryable(buf)
+}
+
 type anySdkHTTPClientConfigurator struct {
 	runtimeCtx    dto.RuntimeCtx
 	authUtil      auth_util.AuthUtility
@@ -287,10 +564,65 @@ func (cc *anySdkHTTPClientConfigurator) inferAuthType(authCtx dto.AuthCtx, authT
 	return dto.AuthNullStr
 }
 
+// Auth builds an authenticated client within an auth span.
 func (cc *anySdkHTTPClientConfigurator) Auth(
 	authCtx *dto.AuthCtx,
 	authTypeRequested string,
 	enforceRevokeFirst bool,
+) (client.AnySdkClient, error) {
+	return cc.authWithContext(context.Background(), authCtx, authTypeRequested, enforceRevokeFirst)
+}
+
+// authWithContext is Auth with the auth span started under ctx, so that it
+// shares a parent with the request it authenticates.
+func (cc *anySdkHTTPClientConfigurator) authWithContext(
+	ctx context.Context,
+	authCtx *dto.AuthCtx,
+	authTypeRequested string,
+	enforceRevokeFirst bool,
+) (client.AnySdkClient, error) {
+	_, span := telemetry.StartSpan(
+		ctx,
+		telemetry.SpanAuth,
+		telemetry.String(telemetry.AttrProvider, cc.providerName),
+		telemetry.String(telemetry.AttrAuthType, authTypeRequested),
+	)
+	rv, err := cc.auth(authCtx, authTypeRequested, enforceRevokeFirst)
+	if err == nil {
+		err = cc.configureClient(rv)
+	}
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// configureClient names the provider on the client's requests, for proxy
+// routing, and applies the runtime context's dry run setting and audit
+// log, if any. Dry run calls change nothing, so are not audited.
+func (cc *anySdkHTTPClientConfigurator) configureClient(c client.AnySdkClient) error {
+	hc, isHTTPClient := c.(*anySdkHttpClient)
+	if !isHTTPClient {
+		return nil
+	}
+	hc.providerName = cc.providerName
+	if cc.runtimeCtx.DryRunFlag {
+		hc.dryRun = true
+		return nil
+	}
+	a, err := newAuditor(cc.runtimeCtx)
+	if err != nil {
+		return err
+	}
+	hc.auditor = a
+	return nil
+}
+
+func (cc *anySdkHTTPClientConfigurator) auth(
+	authCtx *dto.AuthCtx,
+	authTypeRequested string,
+	enforceRevokeFirst bool,
 ) (client.AnySdkClient, error) {
 	authCtx = authCtx.Clone()
 	at := cc.inferAuthType(*authCtx, authTypeRequested)
@@ -533,6 +865,21 @@ func CallFromSignature(
 	}
 }
 
+// authForRequest authenticates under the request's context when the
+// configurator supports it, and otherwise through Auth.
+func authForRequest(
+	ctx context.Context,
+	cc client.AnySdkClientConfigurator,
+	authCtx *dto.AuthCtx,
+	authTypeRequested string,
+	enforceRevokeFirst bool,
+) (client.AnySdkClient, error) {
+	if httpConfigurator, isHTTP := cc.(*anySdkHTTPClientConfigurator); isHTTP {
+		return httpConfigurator.authWithContext(ctx, authCtx, authTypeRequested, enforceRevokeFirst)
+	}
+	return cc.Auth(authCtx, authTypeRequested, enforceRevokeFirst)
+}
+
 func httpApiCallFromRequest(
 	cc client.AnySdkClientConfigurator,
 	runtimeCtx dto.RuntimeCtx,
@@ -543,7 +890,7 @@ func httpApiCallFromRequest(
 	method OperationStore,
 	request *http.Request,
 ) (*http.Response, error) {
-	httpClient, httpClientErr := cc.Auth(authCtx, authTypeRequested, enforceRevokeFirst)
+	httpClient, httpClientErr := authForRequest(request.Context(), cc, authCtx, authTypeRequested, enforceRevokeFirst)
 	if httpClientErr != nil {
 		return nil, httpClientErr
 	}
@@ -0,0 +1,136 @@
+package anysdk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/compression"
+)
+
+var (
+	_ CompressionPolicy         = &standardCompressionPolicy{}
+	_ jsonpointer.JSONPointable = standardCompressionPolicy{}
+)
+
+// CompressionPolicy governs content coding on the wire: compressing request
+// bodies, advertising Accept-Encoding explicitly and decoding responses
+// (with a size limit) before they reach response processing.
+type CompressionPolicy interface {
+	GetRequestEncoding() string
+	GetRequestMinBytes() int
+	GetAcceptEncodings() []string
+	GetMaxDecompressedBytes() int64
+	EncodeRequest(req *http.Request) error
+	DecodeResponse(resp *http.Response) error
+}
+
+type standardCompressionPolicy struct {
+	RequestEncoding      string   `json:"request_encoding,omitempty" yaml:"request_encoding,omitempty"`
+	RequestMinBytes      int      `json:"request_min_bytes,omitempty" yaml:"request_min_bytes,omitempty"`
+	AcceptEncodings      []string `json:"accept_encodings,omitempty" yaml:"accept_encodings,omitempty"`
+	MaxDecompressedBytes int64    `json:"max_decompressed_bytes,omitempty" yaml:"max_decompressed_bytes,omitempty"`
+}
+
+func (cp standardCompressionPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "request_encoding":
+		return cp.RequestEncoding, nil
+	case "request_min_bytes":
+		return cp.RequestMinBytes, nil
+	case "accept_encodings":
+		return cp.AcceptEncodings, nil
+	case "max_decompressed_bytes":
+		return cp.MaxDecompressedBytes, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from CompressionPolicy doc object", token)
+	}
+}
+
+func (cp *standardCompressionPolicy) GetRequestEncoding() string {
+	return strings.ToLower(strings.TrimSpace(cp.RequestEncoding))
+}
+
+func (cp *standardCompressionPolicy) GetRequestMinBytes() int {
+	if cp.RequestMinBytes < 0 {
+		return 0
+	}
+	return cp.RequestMinBytes
+}
+
+func (cp *standardCompressionPolicy) GetAcceptEncodings() []string {
+	if len(cp.AcceptEncodings) == 0 {
+		return compression.SupportedEncodings()
+	}
+	return cp.AcceptEncodings
+}
+
+func (cp *standardCompressionPolicy) GetMaxDecompressedBytes() int64 {
+	if cp.MaxDecompressedBytes <= 0 {
+		return compression.DefaultMaxDecodedBytes
+	}
+	return cp.MaxDecompressedBytes
+}
+
+// EncodeRequest advertises the accepted encodings and, when a request
+// encoding is declared and the body is at least the minimum size, compresses
+// the body and sets Content-Encoding. A body that is already encoded is left alone.
+func (cp *standardCompressionPolicy) EncodeRequest(req *http.Request) error {
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Accept-Encoding", strings.Join(cp.GetAcceptEncodings(), ", "))
+	encoding := cp.GetRequestEncoding()
+	if encoding == "" || encoding == compression.EncodingIdentity {
+		return nil
+	}
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	raw, readErr := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if readErr != nil {
+		return readErr
+	}
+	payload := raw
+	if len(raw) >= cp.GetRequestMinBytes() {
+		encoded, encodeErr := compression.Encode(encoding, raw)
+		if encodeErr != nil {
+			return encodeErr
+		}
+		payload = encoded
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(payload)), nil
+	}
+	req.ContentLength = int64(len(payload))
+	return nil
+}
+
+// DecodeResponse replaces an encoded response body with a size limited,
+// decoded one and drops the now inaccurate Content-Encoding and
+// Content-Length headers.
+func (cp *standardCompressionPolicy) DecodeResponse(resp *http.Response) error {
+	if resp == nil || resp.Body == nil {
+		return nil
+	}
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	if len(compression.ParseContentEncoding(contentEncoding)) == 0 {
+		return nil
+	}
+	decoded, err := compression.NewDecodingReader(resp.Body, contentEncoding, cp.GetMaxDecompressedBytes())
+	if err != nil {
+		return err
+	}
+	resp.Body = decoded
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
@@ -0,0 +1,162 @@
+package anysdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/compression"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCompressionPolicy_UnmarshalAndDefaults(t *testing.T) {
+	raw := `
+compression:
+  request_encoding: GZIP
+`
+	var cfg standardStackQLConfig
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	cp, ok := cfg.GetCompressionPolicy()
+	if !ok {
+		t.Fatalf("expected compression block to be present")
+	}
+	if cp.GetRequestEncoding() != compression.EncodingGzip {
+		t.Fatalf("expected normalised request encoding, got %q", cp.GetRequestEncoding())
+	}
+	if cp.GetMaxDecompressedBytes() != compression.DefaultMaxDecodedBytes {
+		t.Fatalf("unexpected default size limit %d", cp.GetMaxDecompressedBytes())
+	}
+	if got := strings.Join(cp.GetAcceptEncodings(), ","); got != "br,zstd,gzip,deflate" {
+		t.Fatalf("unexpected default accept encodings %q", got)
+	}
+}
+
+func TestCompressionPolicy_EncodeRequest(t *testing.T) {
+	payload := strings.Repeat(`{"key":"value"}`, 32)
+	var gotEncoding, gotAccept, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotAccept = r.Header.Get("Accept-Encoding")
+		rdr, err := compression.NewDecodingReader(r.Body, gotEncoding, 0)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(rdr)
+		gotBody = string(b)
+	}))
+	defer srv.Close()
+	cp := &standardCompressionPolicy{RequestEncoding: "zstd", AcceptEncodings: []string{"br", "gzip"}}
+
+	req := mustReq(t, http.MethodPost, srv.URL, payload)
+	if err := cp.EncodeRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.ContentLength >= int64(len(payload)) {
+		t.Fatalf("expected a smaller compressed body, got %d bytes", req.ContentLength)
+	}
+	resp, err := newTestHttpClient().doWithRetryAndGuards(req, fastPolicy(1, nil, nil), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if gotEncoding != "zstd" || gotAccept != "br, gzip" || gotBody != payload {
+		t.Fatalf("unexpected request: encoding %q, accept %q, body %q", gotEncoding, gotAccept, gotBody)
+	}
+
+	small := &standardCompressionPolicy{RequestEncoding: "gzip", RequestMinBytes: 1 << 20}
+	req = mustReq(t, http.MethodPost, srv.URL, payload)
+	if err = small.EncodeRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Header.Get("Content-Encoding") != "" || req.ContentLength != int64(len(payload)) {
+		t.Fatalf("expected a body below request_min_bytes to be sent as is")
+	}
+}
+
+func TestCompressionPolicy_DecodeResponse(t *testing.T) {
+	for _, encoding := range []string{compression.EncodingBrotli, compression.EncodingZstd} {
+		encoded, _ := compression.Encode(encoding, []byte(`{"items":[]}`))
+		resp := &http.Response{
+			Header:        http.Header{"Content-Encoding": {encoding}, "Content-Length": {"99"}},
+			Body:          io.NopCloser(bytes.NewReader(encoded)),
+			ContentLength: int64(len(encoded)),
+		}
+		if err := (&standardCompressionPolicy{}).DecodeResponse(resp); err != nil {
+			t.Fatalf("%s: unexpected error: %v", encoding, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil || string(body) != `{"items":[]}` {
+			t.Fatalf("%s: unexpected body %q, %v", encoding, body, err)
+		}
+		if resp.Header.Get("Content-Encoding") != "" || resp.Header.Get("Content-Length") != "" || resp.ContentLength != -1 {
+			t.Fatalf("%s: expected encoding headers to be dropped", encoding)
+		}
+	}
+
+	bomb, _ := compression.Encode(compression.EncodingGzip, make([]byte, 1<<16))
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+		Body:   io.NopCloser(bytes.NewReader(bomb)),
+	}
+	if err := (&standardCompressionPolicy{MaxDecompressedBytes: 512}).DecodeResponse(resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, compression.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestCompressionPolicy_WithIdempotencyBodyKeyAndBodyRetry(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rdr, err := compression.NewDecodingReader(r.Body, r.Header.Get("Content-Encoding"), 0)
+		if err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		var doc struct {
+			RequestID string `json:"request_id"`
+		}
+		b, _ := io.ReadAll(rdr)
+		if err = json.Unmarshal(b, &doc); err != nil {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		keys = append(keys, doc.RequestID)
+		status, body := http.StatusOK, `{"id":"x"}`
+		if len(keys) == 1 {
+			status, body = http.StatusBadRequest, `{"error":{"code":"Throttled"}}`
+		}
+		encoded, _ := compression.Encode(compression.EncodingGzip, []byte(body))
+		w.Header().Set("Content-Encoding", compression.EncodingGzip)
+		w.WriteHeader(status)
+		_, _ = w.Write(encoded)
+	}))
+	defer srv.Close()
+	policy := fastPolicy(2, nil, nil).(*standardRetryPolicy)
+	policy.Idempotency = &standardIdempotencyPolicy{Location: IdempotencyLocationBody, Name: "request_id"}
+	policy.RetryableConditions.BodyPredicates = []*standardRetryBodyPredicate{
+		{JSONPath: "$.error.code", Values: []string{"Throttled"}},
+	}
+	cp := &standardCompressionPolicy{RequestEncoding: compression.EncodingGzip, AcceptEncodings: []string{compression.EncodingGzip}}
+
+	req := mustReq(t, http.MethodPost, srv.URL, `{"name":"x"}`)
+	resp, err := newTestHttpClient().doWithRetryAndGuards(req, policy, nil, nil, cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"id":"x"}` {
+		t.Fatalf("expected the decoded 200 body, got %d %q", resp.StatusCode, body)
+	}
+	assertStableKey(t, keys, 2)
+}
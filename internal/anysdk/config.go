@@ -2,8 +2,10 @@ package anysdk
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/apierror"
 	"github.com/stackql/any-sdk/pkg/authsurface"
 )
 
@@ -24,6 +26,15 @@ type StackQLConfig interface {
 	GetExternalTables() map[string]SQLExternalTable
 	GetQueryParamPushdown() (QueryParamPushdown, bool)
 	GetRetryPolicy() (RetryPolicy, bool)
+	GetRateLimitPolicy() (RateLimitPolicy, bool)
+	GetCircuitBreakerPolicy() (CircuitBreakerPolicy, bool)
+	GetCompressionPolicy() (CompressionPolicy, bool)
+	GetLROPolicy() (LROPolicy, bool)
+	GetResponseStreamingPolicy() (ResponseStreamingPolicy, bool)
+	GetAcceptHeaderPolicy() (string, bool)
+	GetRequestValidation() (string, bool)
+	GetErrorFormat() (*apierror.Format, bool)
+	GetUpsertPolicy() (UpsertPolicy, bool)
 	GetMinStackQLVersion() string
 	IsSnakeCaseAliasesEnabled() bool
 	//
@@ -43,8 +54,17 @@ type standardStackQLConfig struct {
 	Auth                 *standardAuthDTO                    `json:"auth,omitempty" yaml:"auth,omitempty"`
 	QueryParamPushdown   *standardQueryParamPushdown         `json:"queryParamPushdown,omitempty" yaml:"queryParamPushdown,omitempty"`
 	Retry                *standardRetryPolicy                `json:"retry,omitempty" yaml:"retry,omitempty"`
+	RateLimit            *standardRateLimitPolicy            `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	CircuitBreaker       *standardCircuitBreakerPolicy       `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
+	Compression          *standardCompressionPolicy          `json:"compression,omitempty" yaml:"compression,omitempty"`
+	LRO                  *standardLROPolicy                  `json:"lro,omitempty" yaml:"lro,omitempty"`
+	ResponseStreaming    *standardResponseStreamingPolicy    `json:"responseStreaming,omitempty" yaml:"responseStreaming,omitempty"`
 	MinStackQLVersion    string                              `json:"minStackQLVersion,omitempty" yaml:"minStackQLVersion,omitempty"`
 	SnakeCaseAliases     bool                                `json:"snake_case_aliases,omitempty" yaml:"snake_case_aliases,omitempty"`
+	AcceptHeaderPolicy   string                              `json:"acceptHeaderPolicy,omitempty" yaml:"acceptHeaderPolicy,omitempty"`
+	RequestValidation    string                              `json:"requestValidation,omitempty" yaml:"requestValidation,omitempty"`
+	ErrorFormat          *apierror.Format                    `json:"errorFormat,omitempty" yaml:"errorFormat,omitempty"`
+	Upsert               *standardUpsertPolicy               `json:"upsert,omitempty" yaml:"upsert,omitempty"`
 }
 
 func (qt standardStackQLConfig) JSONLookup(token string) (interface{}, error) {
@@ -61,6 +81,24 @@ func (qt standardStackQLConfig) JSONLookup(token string) (interface{}, error) {
 		return qt.QueryParamPushdown, nil
 	case "retry":
 		return qt.Retry, nil
+	case "rateLimit":
+		return qt.RateLimit, nil
+	case "circuitBreaker":
+		return qt.CircuitBreaker, nil
+	case "compression":
+		return qt.Compression, nil
+	case "lro":
+		return qt.LRO, nil
+	case "responseStreaming":
+		return qt.ResponseStreaming, nil
+	case "acceptHeaderPolicy":
+		return qt.AcceptHeaderPolicy, nil
+	case "requestValidation":
+		return qt.RequestValidation, nil
+	case "errorFormat":
+		return qt.ErrorFormat, nil
+	case "upsert":
+		return qt.Upsert, nil
 	case "minStackQLVersion":
 		return qt.MinStackQLVersion, nil
 	default:
@@ -163,6 +201,76 @@ func (cfg *standardStackQLConfig) GetRetryPolicy() (RetryPolicy, bool) {
 	return cfg.Retry, true
 }
 
+func (cfg *standardStackQLConfig) GetRateLimitPolicy() (RateLimitPolicy, bool) {
+	if cfg.RateLimit == nil {
+		return nil, false
+	}
+	return cfg.RateLimit, true
+}
+
+func (cfg *standardStackQLConfig) GetCircuitBreakerPolicy() (CircuitBreakerPolicy, bool) {
+	if cfg.CircuitBreaker == nil {
+		return nil, false
+	}
+	return cfg.CircuitBreaker, true
+}
+
+func (cfg *standardStackQLConfig) GetCompressionPolicy() (CompressionPolicy, bool) {
+	if cfg.Compression == nil {
+		return nil, false
+	}
+	return cfg.Compression, true
+}
+
+func (cfg *standardStackQLConfig) GetLROPolicy() (LROPolicy, bool) {
+	if cfg.LRO == nil {
+		return nil, false
+	}
+	return cfg.LRO, true
+}
+
+func (cfg *standardStackQLConfig) GetResponseStreamingPolicy() (ResponseStreamingPolicy, bool) {
+	if cfg.ResponseStreaming == nil {
+		return nil, false
+	}
+	return cfg.ResponseStreaming, true
+}
+
+func (cfg *standardStackQLConfig) GetAcceptHeaderPolicy() (string, bool) {
+	if cfg.AcceptHeaderPolicy == "" {
+		return "", false
+	}
+	return strings.ToLower(cfg.AcceptHeaderPolicy), true
+}
+
+func (cfg *standardStackQLConfig) GetRequestValidation() (string, bool) {
+	// YAML 1.1 reads unquoted on and off as booleans.
+	switch v := strings.ToLower(cfg.RequestValidation); v {
+	case "":
+		return "", false
+	case "false":
+		return RequestValidationOff, true
+	case "true":
+		return RequestValidationOn, true
+	default:
+		return v, true
+	}
+}
+
+func (cfg *standardStackQLConfig) GetErrorFormat() (*apierror.Format, bool) {
+	if cfg.ErrorFormat == nil {
+		return nil, false
+	}
+	return cfg.ErrorFormat, true
+}
+
+func (cfg *standardStackQLConfig) GetUpsertPolicy() (UpsertPolicy, bool) {
+	if cfg.Upsert == nil {
+		return nil, false
+	}
+	return cfg.Upsert, true
+}
+
 func (cfg *standardStackQLConfig) GetExternalTables() map[string]SQLExternalTable {
 	rv := make(map[string]SQLExternalTable, len(cfg.ExternalTables))
 	if cfg.ExternalTables != nil {
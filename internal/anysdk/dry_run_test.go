@@ -0,0 +1,53 @@
+package anysdk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+func TestDryRun_WritesAuthenticatedRequestWithoutSending(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+	t.Setenv("DRY_RUN_TEST_TOKEN", "s3cr3t")
+	out := filepath.Join(t.TempDir(), "dryrun.sh")
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	runtimeCtx := dto.RuntimeCtx{DryRunFlag: true, DryRunOutput: out, AuditLogPath: auditPath}
+
+	cc := NewAnySdkClientConfigurator(runtimeCtx, "myprovider", nil)
+	c, err := cc.Auth(&dto.AuthCtx{KeyEnvVar: "DRY_RUN_TEST_TOKEN"}, dto.AuthBearerStr, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := mustReq(t, http.MethodDelete, srv.URL+"/instances/vm1", "")
+	resp, err := c.Do(newAnySdkOpStoreDesignation(mustLoadWidgetsMethod(t, "delete_widget")), NewwHTTPAnySdkArgList(req))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	httpResp, _ := resp.GetHttpResponse()
+	httpResp.Body.Close()
+	if hits != 0 || httpResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected nothing to be sent, got %d hits and status %d", hits, httpResp.StatusCode)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	curl := string(b)
+	if !strings.Contains(curl, "curl -X DELETE '"+srv.URL+"/instances/vm1'") ||
+		!strings.Contains(curl, "Authorization: REDACTED") || strings.Contains(curl, "s3cr3t") {
+		t.Fatalf("unexpected dry run output %q", curl)
+	}
+	if _, err := os.Stat(auditPath); !os.IsNotExist(err) {
+		t.Fatalf("expected dry run calls not to be audited, got %v", err)
+	}
+}
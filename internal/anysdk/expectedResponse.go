@@ -17,6 +17,7 @@ type ExpectedResponse interface {
 	setOverrideSchemaValue(Schema)
 	setAsyncOverrideSchemaValue(Schema)
 	GetTransform() (Transform, bool)
+	GetBinary() (BinaryResponse, bool)
 	GetRawSchema() Schema // base schema before any override
 	//
 	setSchema(Schema)
@@ -31,9 +32,10 @@ type standardExpectedResponse struct {
 	ObjectKey                  string            `json:"objectKey,omitempty" yaml:"objectKey,omitempty"`
 	ProjectionMap              map[string]string `json:"projection_map,omitempty" yaml:"projection_map,omitempty"`
 	Schema                     Schema
-	OverrideSchema             *LocalSchemaRef    `json:"schema_override,omitempty" yaml:"schema_override,omitempty"`
-	AsyncOverrideSchema        *LocalSchemaRef    `json:"async_schema_override,omitempty" yaml:"async_schema_override,omitempty"`
-	Transform                  *standardTransform `json:"transform,omitempty" yaml:"transform,omitempty"`
+	OverrideSchema             *LocalSchemaRef         `json:"schema_override,omitempty" yaml:"schema_override,omitempty"`
+	AsyncOverrideSchema        *LocalSchemaRef         `json:"async_schema_override,omitempty" yaml:"async_schema_override,omitempty"`
+	Transform                  *standardTransform      `json:"transform,omitempty" yaml:"transform,omitempty"`
+	Binary                     *standardBinaryResponse `json:"binary,omitempty" yaml:"binary,omitempty"`
 }
 
 func (er *standardExpectedResponse) GetProjectionMap() map[string]string {
@@ -114,6 +116,13 @@ func (er *standardExpectedResponse) getAsyncOverrideSchema() (*LocalSchemaRef, b
 	return overrideSchema, true
 }
 
+func (er *standardExpectedResponse) GetBinary() (BinaryResponse, bool) {
+	if er.Binary == nil {
+		return nil, false
+	}
+	return er.Binary, true
+}
+
 func (er *standardExpectedResponse) GetRawSchema() Schema {
 	return er.Schema
 }
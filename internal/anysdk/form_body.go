@@ -0,0 +1,85 @@
+package anysdk
+
+import (
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/formencoding"
+	"github.com/stackql/any-sdk/pkg/media"
+)
+
+const (
+	schemaFormatBinary string = "binary"
+)
+
+// marshalFormBody encodes body as application/x-www-form-urlencoded or
+// multipart/form-data, honouring the OpenAPI encoding object of the
+// operation's request body. Multipart bodies return their Content-Type,
+// since the boundary must reach the request header.
+func (op *standardOpenAPIOperationStore) marshalFormBody(
+	body interface{},
+	expectedRequest ExpectedRequest,
+	mediaType string,
+) dto.MarshalledBody {
+	fields := op.getFormFieldEncodings(expectedRequest)
+	if mediaType == media.MediaTypeMultipartForm {
+		b, contentType, err := formencoding.EncodeMultipart(body, fields)
+		return dto.NewMarshalledBodyWithContentType(b, contentType, err)
+	}
+	b, err := formencoding.EncodeURLEncoded(body, fields)
+	return dto.NewMarshalledBody(b, err)
+}
+
+// getFormFieldEncodings merges the encoding object declared for the request
+// media type with the binary format of each property in the request schema.
+func (op *standardOpenAPIOperationStore) getFormFieldEncodings(
+	expectedRequest ExpectedRequest,
+) map[string]formencoding.FieldEncoding {
+	rv := make(map[string]formencoding.FieldEncoding)
+	if schema := expectedRequest.GetSchema(); schema != nil {
+		for k, prop := range schema.getProperties() {
+			if isBinarySchema(prop) {
+				rv[k] = formencoding.FieldEncoding{Binary: true}
+			}
+		}
+	}
+	if op.OperationRef == nil || op.OperationRef.Value == nil {
+		return rv
+	}
+	requestBody := op.OperationRef.Value.RequestBody
+	if requestBody == nil || requestBody.Value == nil {
+		return rv
+	}
+	content := requestBody.Value.Content.Get(expectedRequest.GetBodyMediaType())
+	if content == nil {
+		return rv
+	}
+	for k, enc := range content.Encoding {
+		if enc == nil {
+			continue
+		}
+		fe := rv[k]
+		fe.ContentType = enc.ContentType
+		fe.Style = enc.Style
+		fe.Explode = enc.Explode
+		fe.AllowReserved = enc.AllowReserved
+		rv[k] = fe
+	}
+	return rv
+}
+
+func isBinarySchema(s Schema) bool {
+	ss, ok := s.(*standardSchema)
+	if !ok || ss == nil || ss.Schema == nil {
+		return false
+	}
+	if ss.Format == schemaFormatBinary {
+		return true
+	}
+	return ss.Items != nil && ss.Items.Value != nil && ss.Items.Value.Format == schemaFormatBinary
+}
+
+// isMultipartRequest reports whether req declares a multipart body. Its
+// Content-Type carries a boundary, so it is set when the body is marshalled
+// rather than copied from the declared media type.
+func isMultipartRequest(req ExpectedRequest) bool {
+	return media.StripMediaTypeParameters(req.GetBodyMediaType()) == media.MediaTypeMultipartForm
+}
@@ -0,0 +1,62 @@
+package anysdk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarshalBody_FormURLEncodedHonoursEncoding(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "import_widget")
+	mb := op.MarshalBody(map[string]interface{}{
+		"name":     "widget",
+		"metadata": map[string]interface{}{"team": "infra"},
+	}, op.Request)
+	if err, hasErr := mb.GetError(); hasErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(mb.GetBytes()) != "metadata%5Bteam%5D=infra&name=widget" {
+		t.Fatalf("unexpected body: %s", mb.GetBytes())
+	}
+	if _, hasContentType := mb.GetContentType(); hasContentType {
+		t.Fatalf("form bodies use the declared media type")
+	}
+}
+
+func TestMarshalBody_MultipartCarriesBoundary(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "upload_widget")
+	mb := op.MarshalBody(map[string]interface{}{
+		"name": "widget",
+		"file": map[string]interface{}{"filename": "a.txt", "content": "hello"},
+	}, op.Request)
+	if err, hasErr := mb.GetError(); hasErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contentType, hasContentType := mb.GetContentType()
+	if !hasContentType || !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Fatalf("unexpected content type: %q", contentType)
+	}
+	if !strings.Contains(string(mb.GetBytes()), `name="file"; filename="a.txt"`) {
+		t.Fatalf("expected a file part, got: %s", mb.GetBytes())
+	}
+	if !isMultipartRequest(op.Request) {
+		t.Fatalf("expected the request to be recognised as multipart")
+	}
+}
+
+func TestMarshalBody_MultipartBinaryFieldFromDocument(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "upload_widget")
+	file := filepath.Join(t.TempDir(), "widget.png")
+	if err := os.WriteFile(file, []byte("\x89PNG"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mb := op.MarshalBody(map[string]interface{}{"name": "widget", "file": "@" + file}, op.Request)
+	if err, hasErr := mb.GetError(); hasErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := string(mb.GetBytes())
+	if !strings.Contains(body, `name="file"; filename="widget.png"`) || !strings.Contains(body, "Content-Type: image/png") {
+		t.Fatalf("expected the documented binary field to be sent as a file part, got: %s", body)
+	}
+}
@@ -146,6 +146,9 @@ func (hap *standardHTTPArmouryParameters) SetNextPage(
 		if len(b) > 0 {
 			rv.Body = io.NopCloser(bytes.NewBuffer(b))
 		}
+		if contentType, hasContentType := marshalledBody.GetContentType(); hasContentType {
+			rv.Header.Set("Content-Type", contentType)
+		}
 		rv.ContentLength = int64(len(b))
 		return rv, nil
 	default:
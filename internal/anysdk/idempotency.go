@@ -0,0 +1,178 @@
+package anysdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/google/uuid"
+)
+
+const (
+	IdempotencyLocationHeader = "header"
+	IdempotencyLocationQuery  = "query"
+	IdempotencyLocationBody   = "body"
+
+	defaultIdempotencyLocation = IdempotencyLocationHeader
+	defaultIdempotencyName     = "Idempotency-Key"
+)
+
+var (
+	_ IdempotencyPolicy         = &standardIdempotencyPolicy{}
+	_ jsonpointer.JSONPointable = standardIdempotencyPolicy{}
+
+	defaultIdempotentMethods = []string{
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+)
+
+// IdempotencyPolicy describes how an idempotency key is attached to a
+// mutating request. One key is generated per logical request and reused on
+// every retry attempt, which is what makes retrying the mutation safe.
+//
+// Location is one of "header", "query" or "body". For "body", Name is a
+// dot separated path into a JSON object request body.
+type IdempotencyPolicy interface {
+	GetLocation() string
+	GetName() string
+	GetMethods() []string
+	IsMethodCovered(method string) bool
+	Apply(req *http.Request) (string, error)
+}
+
+type standardIdempotencyPolicy struct {
+	Location string   `json:"location,omitempty" yaml:"location,omitempty"`
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Methods  []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+func (ip standardIdempotencyPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "location":
+		return ip.Location, nil
+	case "name":
+		return ip.Name, nil
+	case "methods":
+		return ip.Methods, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from IdempotencyPolicy doc object", token)
+	}
+}
+
+func (ip *standardIdempotencyPolicy) GetLocation() string {
+	if ip.Location == "" {
+		return defaultIdempotencyLocation
+	}
+	return ip.Location
+}
+
+func (ip *standardIdempotencyPolicy) GetName() string {
+	if ip.Name == "" {
+		return defaultIdempotencyName
+	}
+	return ip.Name
+}
+
+func (ip *standardIdempotencyPolicy) GetMethods() []string {
+	if len(ip.Methods) == 0 {
+		out := make([]string, len(defaultIdempotentMethods))
+		copy(out, defaultIdempotentMethods)
+		return out
+	}
+	return ip.Methods
+}
+
+func (ip *standardIdempotencyPolicy) IsMethodCovered(method string) bool {
+	for _, m := range ip.GetMethods() {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply injects a freshly generated key into req at the declared location and
+// returns it. A key already present at that location (eg: supplied by the
+// caller) is left alone and returned, so callers may pin their own.
+func (ip *standardIdempotencyPolicy) Apply(req *http.Request) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("cannot apply idempotency key to nil request")
+	}
+	name := ip.GetName()
+	key := uuid.NewString()
+	switch ip.GetLocation() {
+	case IdempotencyLocationHeader:
+		if existing := req.Header.Get(name); existing != "" {
+			return existing, nil
+		}
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		req.Header.Set(name, key)
+		return key, nil
+	case IdempotencyLocationQuery:
+		q := req.URL.Query()
+		if existing := q.Get(name); existing != "" {
+			return existing, nil
+		}
+		q.Set(name, key)
+		req.URL.RawQuery = q.Encode()
+		return key, nil
+	case IdempotencyLocationBody:
+		return applyIdempotencyKeyToBody(req, name, key)
+	default:
+		return "", fmt.Errorf("unsupported idempotency key location '%s'", ip.GetLocation())
+	}
+}
+
+func applyIdempotencyKeyToBody(req *http.Request, path string, key string) (string, error) {
+	var doc map[string]interface{}
+	if req.Body != nil && req.Body != http.NoBody {
+		raw, readErr := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if readErr != nil {
+			return "", readErr
+		}
+		if len(bytes.TrimSpace(raw)) > 0 {
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				return "", fmt.Errorf("idempotency key location 'body' requires a JSON object request body: %w", err)
+			}
+		}
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	segments := strings.Split(path, ".")
+	parent := doc
+	for _, seg := range segments[:len(segments)-1] {
+		child, ok := parent[seg].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			parent[seg] = child
+		}
+		parent = child
+	}
+	leaf := segments[len(segments)-1]
+	if existing, ok := parent[leaf].(string); ok && existing != "" {
+		key = existing
+	} else {
+		parent[leaf] = key
+	}
+	rewritten, marshalErr := json.Marshal(doc)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+	req.Body = io.NopCloser(bytes.NewReader(rewritten))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(rewritten)), nil
+	}
+	req.ContentLength = int64(len(rewritten))
+	return key, nil
+}
@@ -0,0 +1,147 @@
+package anysdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// keyRecordingServer fails the first `failures` requests with 503 and records
+// the idempotency key observed on every request, as extracted by `extract`.
+func keyRecordingServer(
+	t *testing.T,
+	failures int,
+	extract func(r *http.Request, body []byte) string,
+) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mutex sync.Mutex
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		keys = append(keys, extract(r, body))
+		n := len(keys)
+		mutex.Unlock()
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func assertStableKey(t *testing.T, keys []string, wantCalls int) {
+	t.Helper()
+	if len(keys) != wantCalls {
+		t.Fatalf("expected %d calls, got %d", wantCalls, len(keys))
+	}
+	for _, k := range keys {
+		if k == "" || k != keys[0] {
+			t.Fatalf("expected the same non-empty key on every attempt, got %v", keys)
+		}
+	}
+}
+
+func TestIdempotency_HeaderKeyMakesPostRetryable(t *testing.T) {
+	srv, keys := keyRecordingServer(t, 2, func(r *http.Request, _ []byte) string {
+		return r.Header.Get("Idempotency-Key")
+	})
+	policy := fastPolicy(3, nil, []int{http.StatusServiceUnavailable}).(*standardRetryPolicy)
+	policy.Idempotency = &standardIdempotencyPolicy{}
+
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodPost, srv.URL, `{"name":"x"}`), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	assertStableKey(t, keys(), 3)
+}
+
+func TestIdempotency_BodyPathKey(t *testing.T) {
+	srv, keys := keyRecordingServer(t, 1, func(_ *http.Request, body []byte) string {
+		var doc struct {
+			Metadata struct {
+				RequestID string `json:"request_id"`
+			} `json:"metadata"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(body, &doc); err != nil || doc.Name != "x" {
+			return ""
+		}
+		return doc.Metadata.RequestID
+	})
+	policy := fastPolicy(2, nil, []int{http.StatusServiceUnavailable}).(*standardRetryPolicy)
+	policy.Idempotency = &standardIdempotencyPolicy{Location: IdempotencyLocationBody, Name: "metadata.request_id"}
+
+	if _, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodPost, srv.URL, `{"name":"x"}`), policy, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertStableKey(t, keys(), 2)
+}
+
+func TestIdempotency_CallerSuppliedKeyIsKept(t *testing.T) {
+	srv, keys := keyRecordingServer(t, 0, func(r *http.Request, _ []byte) string {
+		return r.URL.Query().Get("client_token")
+	})
+	policy := fastPolicy(2, nil, nil).(*standardRetryPolicy)
+	policy.Idempotency = &standardIdempotencyPolicy{Location: IdempotencyLocationQuery, Name: "client_token"}
+
+	if _, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodDelete, srv.URL+"?client_token=pinned", ""), policy, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := keys(); len(got) != 1 || got[0] != "pinned" {
+		t.Fatalf("expected caller supplied key to be kept, got %v", got)
+	}
+}
+
+func TestIdempotency_UncoveredMethodIsNotRetried(t *testing.T) {
+	srv, keys := keyRecordingServer(t, 5, func(r *http.Request, _ []byte) string {
+		return r.Header.Get("Idempotency-Key")
+	})
+	policy := fastPolicy(3, nil, []int{http.StatusServiceUnavailable}).(*standardRetryPolicy)
+	policy.Idempotency = &standardIdempotencyPolicy{Methods: []string{http.MethodPost}}
+
+	if _, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodDelete, srv.URL, ""), policy, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := keys(); len(got) != 1 || got[0] != "" {
+		t.Fatalf("expected a single unkeyed attempt, got %v", got)
+	}
+}
+
+func TestIdempotency_Unmarshal(t *testing.T) {
+	raw := `
+algorithm: exponential
+idempotency:
+  location: body
+  name: clientToken
+  methods: [POST]
+`
+	var policy standardRetryPolicy
+	if err := yaml.Unmarshal([]byte(raw), &policy); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	idem, ok := policy.GetIdempotencyPolicy()
+	if !ok {
+		t.Fatalf("expected idempotency block to be present")
+	}
+	if idem.GetLocation() != IdempotencyLocationBody || idem.GetName() != "clientToken" {
+		t.Fatalf("unexpected idempotency policy %+v", idem)
+	}
+	if !policy.IsMethodRetryable(http.MethodPost) || policy.IsMethodRetryable(http.MethodPut) {
+		t.Fatalf("expected only POST to become retryable via idempotency")
+	}
+}
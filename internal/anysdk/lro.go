@@ -0,0 +1,816 @@
+package anysdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/client"
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+)
+
+const (
+	// LROStyleAzureAsyncOperation polls the Azure-AsyncOperation header URL
+	// until its status is terminal; without that header it falls back to
+	// LROStyleAzureLocation.
+	LROStyleAzureAsyncOperation = "azure_async_operation"
+	// LROStyleAzureLocation polls the Location header URL until it stops
+	// answering 202.
+	LROStyleAzureLocation = "azure_location"
+	// LROStyleGoogleOperation polls a google.longrunning Operation until done.
+	LROStyleGoogleOperation = "google_operation"
+	// LROStyleAWSCloudControl polls CloudControl GetResourceRequestStatus
+	// with the request token of the returned ProgressEvent.
+	LROStyleAWSCloudControl = "aws_cloudcontrol"
+
+	LROLocationHeader  = "header"
+	LROLocationBody    = "body"
+	LROLocationRequest = "request"
+
+	// LROResultStatus takes the result from the final status response.
+	LROResultStatus = "status"
+	// LROResultRequest re-reads the original request URL.
+	LROResultRequest = "request"
+	// LROResultLink follows a URL found in the final status response.
+	LROResultLink = "link"
+	// LROResultInitialLink follows a URL found in the initial response.
+	LROResultInitialLink = "initial_link"
+
+	lroTokenPlaceholder = "{{token}}"
+
+	defaultLROPollIntervalMs    = 2000
+	defaultLROMaxPollIntervalMs = 30000
+	defaultLROBackoffMultiplier = 1.5
+	defaultLROTimeoutMs         = 600000
+)
+
+var (
+	_ LROPolicy                 = &standardLROPolicy{}
+	_ jsonpointer.JSONPointable = standardLROPolicy{}
+	_ LROLocator                = &standardLROLocator{}
+	_ LROResult                 = &standardLROResult{}
+
+	// ErrLROFailed is matched by errors for operations that reach a failure state.
+	ErrLROFailed = errors.New("long-running operation failed")
+	// ErrLROTimeout is matched by errors for operations still pending at the deadline.
+	ErrLROTimeout = errors.New("long-running operation timed out")
+
+	defaultLROPendingStatusCodes = []int{http.StatusAccepted} //nolint:gochecknoglobals // read-only default
+)
+
+// LROLocator finds a value in a response: a header, a JSON path into the
+// body or, for status URLs, the original request URL. An optional template
+// composes a URL from a relative value; it may reference {value}, {scheme},
+// {host} and {version} (the first path segment of the request URL).
+type LROLocator interface {
+	GetLocation() string
+	GetName() string
+	GetTemplate() string
+}
+
+type standardLROLocator struct {
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+func (l *standardLROLocator) GetLocation() string {
+	return strings.ToLower(l.Location)
+}
+
+func (l *standardLROLocator) GetName() string {
+	return l.Name
+}
+
+func (l *standardLROLocator) GetTemplate() string {
+	return l.Template
+}
+
+// LROResult says where the outcome of a completed operation lives.
+type LROResult interface {
+	GetFrom() string
+	GetPath() string
+	GetLink() (LROLocator, bool)
+}
+
+type standardLROResult struct {
+	From string              `json:"from,omitempty" yaml:"from,omitempty"`
+	Path string              `json:"path,omitempty" yaml:"path,omitempty"`
+	Link *standardLROLocator `json:"link,omitempty" yaml:"link,omitempty"`
+}
+
+func (r *standardLROResult) GetFrom() string {
+	if r.From == "" {
+		return LROResultStatus
+	}
+	return strings.ToLower(r.From)
+}
+
+func (r *standardLROResult) GetPath() string {
+	return r.Path
+}
+
+func (r *standardLROResult) GetLink() (LROLocator, bool) {
+	if r.Link == nil {
+		return nil, false
+	}
+	return r.Link, true
+}
+
+// LROPolicy declares how a long-running operation is monitored to
+// completion. A style supplies defaults for every field not set explicitly.
+type LROPolicy interface {
+	GetStyle() string
+	IsWait() bool
+	GetStatusURLs() []LROLocator
+	GetStatusMethod() string
+	GetStatusHeaders() map[string]string
+	GetStatusBody() string
+	GetToken() (LROLocator, bool)
+	GetStatePath() string
+	GetSuccessStates() []string
+	GetFailureStates() []string
+	GetErrorPath() string
+	GetPendingStatusCodes() []int
+	GetResult() LROResult
+	GetPollInterval() time.Duration
+	GetMaxPollInterval() time.Duration
+	GetBackoffMultiplier() float64
+	GetTimeout() time.Duration
+}
+
+type standardLROPolicy struct {
+	Style              string                `json:"style,omitempty" yaml:"style,omitempty"`
+	Wait               bool                  `json:"wait,omitempty" yaml:"wait,omitempty"`
+	StatusURL          []*standardLROLocator `json:"status_url,omitempty" yaml:"status_url,omitempty"`
+	StatusMethod       string                `json:"status_method,omitempty" yaml:"status_method,omitempty"`
+	StatusHeaders      map[string]string     `json:"status_headers,omitempty" yaml:"status_headers,omitempty"`
+	StatusBody         string                `json:"status_body,omitempty" yaml:"status_body,omitempty"`
+	Token              *standardLROLocator   `json:"token,omitempty" yaml:"token,omitempty"`
+	StatePath          string                `json:"state_path,omitempty" yaml:"state_path,omitempty"`
+	SuccessStates      []string              `json:"success_states,omitempty" yaml:"success_states,omitempty"`
+	FailureStates      []string              `json:"failure_states,omitempty" yaml:"failure_states,omitempty"`
+	ErrorPath          string                `json:"error_path,omitempty" yaml:"error_path,omitempty"`
+	PendingStatusCodes []int                 `json:"pending_status_codes,omitempty" yaml:"pending_status_codes,omitempty"`
+	Result             *standardLROResult    `json:"result,omitempty" yaml:"result,omitempty"`
+	PollIntervalMs     int                   `json:"poll_interval_ms,omitempty" yaml:"poll_interval_ms,omitempty"`
+	MaxPollIntervalMs  int                   `json:"max_poll_interval_ms,omitempty" yaml:"max_poll_interval_ms,omitempty"`
+	BackoffMultiplier  float64               `json:"backoff_multiplier,omitempty" yaml:"backoff_multiplier,omitempty"`
+	TimeoutMs          int                   `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+}
+
+//nolint:gochecknoglobals // read-only presets
+var lroStylePresets = map[string]*standardLROPolicy{
+	LROStyleAzureAsyncOperation: {
+		StatusURL:     []*standardLROLocator{{Location: LROLocationHeader, Name: "Azure-AsyncOperation"}},
+		StatePath:     "$.status",
+		SuccessStates: []string{"Succeeded"},
+		FailureStates: []string{"Failed", "Canceled"},
+		ErrorPath:     "$.error",
+		Result: &standardLROResult{
+			From: LROResultInitialLink,
+			Link: &standardLROLocator{Location: LROLocationHeader, Name: "Location"},
+		},
+	},
+	LROStyleAzureLocation: {
+		StatusURL:          []*standardLROLocator{{Location: LROLocationHeader, Name: "Location"}},
+		PendingStatusCodes: []int{http.StatusAccepted},
+		Result:             &standardLROResult{From: LROResultStatus},
+	},
+	LROStyleGoogleOperation: {
+		StatusURL: []*standardLROLocator{
+			{Location: LROLocationBody, Name: "$.selfLink"},
+			{Location: LROLocationBody, Name: "$.name", Template: "{scheme}://{host}/{version}/{value}"},
+		},
+		StatePath:     "$.done",
+		SuccessStates: []string{"true"},
+		ErrorPath:     "$.error",
+		Result:        &standardLROResult{From: LROResultStatus, Path: "$.response"},
+	},
+	LROStyleAWSCloudControl: {
+		StatusURL:    []*standardLROLocator{{Location: LROLocationRequest}},
+		StatusMethod: http.MethodPost,
+		StatusHeaders: map[string]string{
+			"Content-Type": "application/x-amz-json-1.0",
+			"X-Amz-Target": "CloudApiService.GetResourceRequestStatus",
+		},
+		StatusBody:    `{"RequestToken":"` + lroTokenPlaceholder + `"}`,
+		Token:         &standardLROLocator{Location: LROLocationBody, Name: "$.ProgressEvent.RequestToken"},
+		StatePath:     "$.ProgressEvent.OperationStatus",
+		SuccessStates: []string{"SUCCESS"},
+		FailureStates: []string{"FAILED", "CANCEL_COMPLETE"},
+		ErrorPath:     "$.ProgressEvent.StatusMessage",
+		Result:        &standardLROResult{From: LROResultStatus, Path: "$.ProgressEvent"},
+	},
+}
+
+func (lp standardLROPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "style":
+		return lp.Style, nil
+	case "wait":
+		return lp.Wait, nil
+	case "status_url":
+		return lp.StatusURL, nil
+	case "status_method":
+		return lp.StatusMethod, nil
+	case "status_headers":
+		return lp.StatusHeaders, nil
+	case "status_body":
+		return lp.StatusBody, nil
+	case "token":
+		return lp.Token, nil
+	case "state_path":
+		return lp.StatePath, nil
+	case "success_states":
+		return lp.SuccessStates, nil
+	case "failure_states":
+		return lp.FailureStates, nil
+	case "error_path":
+		return lp.ErrorPath, nil
+	case "pending_status_codes":
+		return lp.PendingStatusCodes, nil
+	case "result":
+		return lp.Result, nil
+	case "poll_interval_ms":
+		return lp.PollIntervalMs, nil
+	case "max_poll_interval_ms":
+		return lp.MaxPollIntervalMs, nil
+	case "backoff_multiplier":
+		return lp.BackoffMultiplier, nil
+	case "timeout_ms":
+		return lp.TimeoutMs, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from LROPolicy doc object", token)
+	}
+}
+
+// preset returns the style defaults, or an empty policy for a custom spec.
+func (lp *standardLROPolicy) preset() *standardLROPolicy {
+	if p, ok := lroStylePresets[lp.GetStyle()]; ok {
+		return p
+	}
+	return &standardLROPolicy{}
+}
+
+func (lp *standardLROPolicy) GetStyle() string {
+	return strings.ToLower(lp.Style)
+}
+
+func (lp *standardLROPolicy) IsWait() bool {
+	return lp.Wait
+}
+
+func (lp *standardLROPolicy) GetStatusURLs() []LROLocator {
+	locators := lp.StatusURL
+	if len(locators) == 0 {
+		locators = lp.preset().StatusURL
+	}
+	rv := make([]LROLocator, 0, len(locators))
+	for _, l := range locators {
+		rv = append(rv, l)
+	}
+	return rv
+}
+
+func (lp *standardLROPolicy) GetStatusMethod() string {
+	if lp.StatusMethod != "" {
+		return strings.ToUpper(lp.StatusMethod)
+	}
+	if p := lp.preset(); p.StatusMethod != "" {
+		return p.StatusMethod
+	}
+	return http.MethodGet
+}
+
+func (lp *standardLROPolicy) GetStatusHeaders() map[string]string {
+	if len(lp.StatusHeaders) > 0 {
+		return lp.StatusHeaders
+	}
+	return lp.preset().StatusHeaders
+}
+
+func (lp *standardLROPolicy) GetStatusBody() string {
+	if lp.StatusBody != "" {
+		return lp.StatusBody
+	}
+	return lp.preset().StatusBody
+}
+
+func (lp *standardLROPolicy) GetToken() (LROLocator, bool) {
+	if lp.Token != nil {
+		return lp.Token, true
+	}
+	if p := lp.preset(); p.Token != nil {
+		return p.Token, true
+	}
+	return nil, false
+}
+
+func (lp *standardLROPolicy) GetStatePath() string {
+	if lp.StatePath != "" {
+		return lp.StatePath
+	}
+	return lp.preset().StatePath
+}
+
+func (lp *standardLROPolicy) GetSuccessStates() []string {
+	if len(lp.SuccessStates) > 0 {
+		return lp.SuccessStates
+	}
+	return lp.preset().SuccessStates
+}
+
+func (lp *standardLROPolicy) GetFailureStates() []string {
+	if len(lp.FailureStates) > 0 {
+		return lp.FailureStates
+	}
+	return lp.preset().FailureStates
+}
+
+func (lp *standardLROPolicy) GetErrorPath() string {
+	if lp.ErrorPath != "" {
+		return lp.ErrorPath
+	}
+	return lp.preset().ErrorPath
+}
+
+func (lp *standardLROPolicy) GetPendingStatusCodes() []int {
+	if len(lp.PendingStatusCodes) > 0 {
+		return lp.PendingStatusCodes
+	}
+	if p := lp.preset(); len(p.PendingStatusCodes) > 0 {
+		return p.PendingStatusCodes
+	}
+	return defaultLROPendingStatusCodes
+}
+
+func (lp *standardLROPolicy) GetResult() LROResult {
+	if lp.Result != nil {
+		return lp.Result
+	}
+	if p := lp.preset(); p.Result != nil {
+		return p.Result
+	}
+	return &standardLROResult{}
+}
+
+func (lp *standardLROPolicy) GetPollInterval() time.Duration {
+	if lp.PollIntervalMs <= 0 {
+		return defaultLROPollIntervalMs * time.Millisecond
+	}
+	return time.Duration(lp.PollIntervalMs) * time.Millisecond
+}
+
+func (lp *standardLROPolicy) GetMaxPollInterval() time.Duration {
+	maxInterval := time.Duration(lp.MaxPollIntervalMs) * time.Millisecond
+	if lp.MaxPollIntervalMs <= 0 {
+		maxInterval = defaultLROMaxPollIntervalMs * time.Millisecond
+	}
+	if initial := lp.GetPollInterval(); maxInterval < initial {
+		return initial
+	}
+	return maxInterval
+}
+
+func (lp *standardLROPolicy) GetBackoffMultiplier() float64 {
+	if lp.BackoffMultiplier < 1 {
+		return defaultLROBackoffMultiplier
+	}
+	return lp.BackoffMultiplier
+}
+
+func (lp *standardLROPolicy) GetTimeout() time.Duration {
+	if lp.TimeoutMs <= 0 {
+		return defaultLROTimeoutMs * time.Millisecond
+	}
+	return time.Duration(lp.TimeoutMs) * time.Millisecond
+}
+
+// LROError reports an operation that reached a failure state.
+type LROError struct {
+	State      string
+	Message    string
+	StatusCode int
+}
+
+func (e *LROError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: state '%s'", ErrLROFailed.Error(), e.State)
+	}
+	return fmt.Sprintf("%s: state '%s': %s", ErrLROFailed.Error(), e.State, e.Message)
+}
+
+func (e *LROError) Unwrap() error {
+	return ErrLROFailed
+}
+
+// LRODoer sends one status or result request, with the operation's
+// authentication and transport policies applied.
+type LRODoer func(req *http.Request) (*http.Response, error)
+
+// LROPoller drives a long-running operation to completion.
+type LROPoller interface {
+	// Await polls the operation started by initialReq, whose response is
+	// initial, and returns the response holding the operation's result.
+	// When initial carries no status URL it is not long-running and is
+	// returned unchanged.
+	Await(initialReq *http.Request, initial *http.Response) (*http.Response, error)
+}
+
+type standardLROPoller struct {
+	policy LROPolicy
+	doer   LRODoer
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewLROPoller(policy LROPolicy, doer LRODoer) LROPoller {
+	return &standardLROPoller{
+		policy: policy,
+		doer:   doer,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// NewLROPollDesignation designates the status and result requests of
+// method's long-running operation. Polls keep method's provider, host guards
+// and retry schedule, but are reads: they carry no mutating SQL verb,
+// idempotency key, request translation or request compression.
+func NewLROPollDesignation(method OperationStore) client.AnySdkDesignation {
+	return newAnySdkOpStoreDesignation(&lroPollOperationStore{OperationStore: method})
+}
+
+type lroPollOperationStore struct {
+	OperationStore
+}
+
+func (op *lroPollOperationStore) GetSQLVerb() string {
+	return "select"
+}
+
+func (op *lroPollOperationStore) GetRequestTranslateAlgorithm() string {
+	return ""
+}
+
+func (op *lroPollOperationStore) GetCompressionPolicy() (CompressionPolicy, bool) {
+	return nil, false
+}
+
+func (op *lroPollOperationStore) GetRetryPolicy() RetryPolicy {
+	return &lroPollRetryPolicy{RetryPolicy: op.OperationStore.GetRetryPolicy()}
+}
+
+// lroPollRetryPolicy retries a poll as it would a GET, whatever HTTP method
+// carries it, and never injects an idempotency key.
+type lroPollRetryPolicy struct {
+	RetryPolicy
+}
+
+func (rp *lroPollRetryPolicy) IsMethodRetryable(string) bool {
+	return rp.RetryPolicy.IsMethodRetryable(http.MethodGet)
+}
+
+func (rp *lroPollRetryPolicy) GetIdempotencyPolicy() (IdempotencyPolicy, bool) {
+	return nil, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// lroObservation is a buffered response with its decoded JSON body, if any.
+type lroObservation struct {
+	response *http.Response
+	body     []byte
+	doc      interface{}
+}
+
+func observe(resp *http.Response) (*lroObservation, error) {
+	rv := &lroObservation{response: resp}
+	if resp.Body != nil {
+		b, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rv.body = b
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	if len(rv.body) > 0 {
+		_ = json.Unmarshal(rv.body, &rv.doc)
+	}
+	return rv, nil
+}
+
+func (o *lroObservation) lookup(path string) (interface{}, bool) {
+	if o.doc == nil || path == "" {
+		return nil, false
+	}
+	v, err := jsonpath.Get(path, o.doc)
+	if err != nil || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func (o *lroObservation) locate(l LROLocator, req *http.Request) (string, bool) {
+	switch l.GetLocation() {
+	case LROLocationHeader:
+		v := o.response.Header.Get(l.GetName())
+		return v, v != ""
+	case LROLocationBody:
+		v, ok := o.lookup(l.GetName())
+		if !ok {
+			return "", false
+		}
+		s := fmt.Sprintf("%v", v)
+		return s, s != ""
+	case LROLocationRequest:
+		if req == nil || req.URL == nil {
+			return "", false
+		}
+		return req.URL.String(), true
+	default:
+		return "", false
+	}
+}
+
+// locateURL resolves a located value to an absolute URL against req.
+func (o *lroObservation) locateURL(l LROLocator, req *http.Request) (string, bool) {
+	v, ok := o.locate(l, req)
+	if !ok {
+		return "", false
+	}
+	if req == nil || req.URL == nil {
+		return v, true
+	}
+	if tmpl := l.GetTemplate(); tmpl != "" {
+		version := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+		return strings.NewReplacer(
+			"{value}", strings.TrimPrefix(v, "/"),
+			"{scheme}", req.URL.Scheme,
+			"{host}", req.URL.Host,
+			"{version}", version,
+		).Replace(tmpl), true
+	}
+	ref, err := url.Parse(v)
+	if err != nil {
+		return "", false
+	}
+	return req.URL.ResolveReference(ref).String(), true
+}
+
+func (p *standardLROPoller) Await(initialReq *http.Request, initial *http.Response) (*http.Response, error) {
+	if initial == nil || initial.StatusCode >= http.StatusBadRequest {
+		return initial, nil
+	}
+	first, err := observe(initial)
+	if err != nil {
+		return nil, err
+	}
+	policy := p.policy
+	statusURL, found := findStatusURL(policy, first, initialReq)
+	if !found && policy.GetStyle() == LROStyleAzureAsyncOperation {
+		policy = &standardLROPolicy{
+			Style:             LROStyleAzureLocation,
+			PollIntervalMs:    int(policy.GetPollInterval() / time.Millisecond),
+			MaxPollIntervalMs: int(policy.GetMaxPollInterval() / time.Millisecond),
+			BackoffMultiplier: policy.GetBackoffMultiplier(),
+			TimeoutMs:         int(policy.GetTimeout() / time.Millisecond),
+		}
+		statusURL, found = findStatusURL(policy, first, initialReq)
+	}
+	if !found {
+		return initial, nil
+	}
+	token := ""
+	if tokenLocator, hasToken := policy.GetToken(); hasToken {
+		token, _ = first.locate(tokenLocator, initialReq)
+	}
+	ctx := context.Background()
+	if initialReq != nil {
+		ctx = initialReq.Context()
+	}
+	final := first
+	done, doneErr := evaluateLROState(policy, first, true)
+	if doneErr != nil {
+		return nil, doneErr
+	}
+	deadline := p.now().Add(policy.GetTimeout())
+	interval := policy.GetPollInterval()
+	last := first
+	for !done {
+		wait := interval
+		if retryAfter, ok := parseRetryAfter(last.response.Header.Get("Retry-After"), p.now()); ok && retryAfter > 0 {
+			wait = retryAfter
+		}
+		if remaining := deadline.Sub(p.now()); remaining <= 0 {
+			return nil, fmt.Errorf("%w after %s polling '%s'", ErrLROTimeout, policy.GetTimeout(), statusURL)
+		} else if wait > remaining {
+			wait = remaining
+		}
+		if sleepErr := p.sleep(ctx, wait); sleepErr != nil {
+			return nil, sleepErr
+		}
+		interval = time.Duration(math.Min(
+			float64(interval)*policy.GetBackoffMultiplier(),
+			float64(policy.GetMaxPollInterval()),
+		))
+		statusReq, reqErr := newLROStatusRequest(ctx, policy, statusURL, token)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		resp, doErr := p.doer(statusReq)
+		if doErr != nil {
+			return nil, doErr
+		}
+		last, err = observe(resp)
+		if err != nil {
+			return nil, err
+		}
+		if last.response.StatusCode >= http.StatusBadRequest {
+			return nil, &LROError{
+				State:      last.response.Status,
+				Message:    strings.TrimSpace(string(last.body)),
+				StatusCode: last.response.StatusCode,
+			}
+		}
+		// servers may hand out a fresh monitor URL on each poll
+		for _, l := range policy.GetStatusURLs() {
+			if l.GetLocation() != LROLocationHeader {
+				continue
+			}
+			if next, ok := last.locateURL(l, statusReq); ok {
+				statusURL = next
+				break
+			}
+		}
+		done, err = evaluateLROState(policy, last, false)
+		if err != nil {
+			return nil, err
+		}
+		final = last
+	}
+	return p.result(ctx, policy, initialReq, first, final)
+}
+
+func findStatusURL(policy LROPolicy, obs *lroObservation, req *http.Request) (string, bool) {
+	for _, l := range policy.GetStatusURLs() {
+		if v, ok := obs.locateURL(l, req); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// evaluateLROState reports whether the operation is complete, or an error if
+// it failed. The initial response only completes an operation when it
+// carries an explicit state.
+func evaluateLROState(policy LROPolicy, obs *lroObservation, isInitial bool) (bool, error) {
+	statePath := policy.GetStatePath()
+	if statePath == "" {
+		if isInitial {
+			return false, nil
+		}
+		for _, c := range policy.GetPendingStatusCodes() {
+			if obs.response.StatusCode == c {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	rawState, hasState := obs.lookup(statePath)
+	if !hasState {
+		return false, nil
+	}
+	state := fmt.Sprintf("%v", rawState)
+	for _, s := range policy.GetFailureStates() {
+		if strings.EqualFold(s, state) {
+			return true, &LROError{State: state, Message: lroErrorMessage(policy, obs), StatusCode: obs.response.StatusCode}
+		}
+	}
+	for _, s := range policy.GetSuccessStates() {
+		if strings.EqualFold(s, state) {
+			if msg := lroErrorMessage(policy, obs); msg != "" {
+				return true, &LROError{State: state, Message: msg, StatusCode: obs.response.StatusCode}
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func lroErrorMessage(policy LROPolicy, obs *lroObservation) string {
+	v, ok := obs.lookup(policy.GetErrorPath())
+	if !ok {
+		return ""
+	}
+	if s, isString := v.(string); isString {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func newLROStatusRequest(ctx context.Context, policy LROPolicy, statusURL string, token string) (*http.Request, error) {
+	var body io.Reader
+	if tmpl := policy.GetStatusBody(); tmpl != "" {
+		body = strings.NewReader(strings.ReplaceAll(tmpl, lroTokenPlaceholder, token))
+	}
+	req, err := http.NewRequestWithContext(ctx, policy.GetStatusMethod(), statusURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range policy.GetStatusHeaders() {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// result produces the response holding the outcome of a completed
+// operation. A link that cannot be located falls back to the original URL
+// for PUT and PATCH, which address the resource itself, and otherwise to
+// the final status response.
+func (p *standardLROPoller) result(
+	ctx context.Context,
+	policy LROPolicy,
+	initialReq *http.Request,
+	first *lroObservation,
+	final *lroObservation,
+) (*http.Response, error) {
+	result := policy.GetResult()
+	from := result.GetFrom()
+	var target string
+	switch from {
+	case LROResultRequest:
+		if initialReq != nil && initialReq.URL != nil {
+			target = initialReq.URL.String()
+		}
+	case LROResultLink, LROResultInitialLink:
+		source := final
+		if from == LROResultInitialLink {
+			source = first
+		}
+		if link, hasLink := result.GetLink(); hasLink {
+			target, _ = source.locateURL(link, initialReq)
+		}
+		if target == "" && initialReq != nil &&
+			(initialReq.Method == http.MethodPut || initialReq.Method == http.MethodPatch) {
+			target = initialReq.URL.String()
+		}
+	}
+	if target != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		return p.doer(req)
+	}
+	body := final.body
+	if path := result.GetPath(); path != "" {
+		if v, ok := final.lookup(path); ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			body = b
+		}
+	}
+	header := final.response.Header.Clone()
+	header.Del("Content-Length")
+	if len(body) > 0 && final.doc != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+		StatusCode:    http.StatusOK,
+		Proto:         final.response.Proto,
+		ProtoMajor:    final.response.ProtoMajor,
+		ProtoMinor:    final.response.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       final.response.Request,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package anysdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// newTestPoller returns a poller on a fake clock whose sleeps are recorded
+// rather than taken.
+func newTestPoller(policy LROPolicy) (*standardLROPoller, *[]time.Duration) {
+	var waits []time.Duration
+	clock := time.Unix(1700000000, 0)
+	return &standardLROPoller{
+		policy: policy,
+		doer:   http.DefaultClient.Do,
+		now:    func() time.Time { return clock },
+		sleep: func(_ context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			clock = clock.Add(d)
+			return nil
+		},
+	}, &waits
+}
+
+func startOperation(t *testing.T, method string, url string, body string) (*http.Request, *http.Response) {
+	t.Helper()
+	req := mustReq(t, method, url, body)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return req, resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestLROPolicy_UnmarshalAndPresets(t *testing.T) {
+	raw := `
+lro:
+  style: google_operation
+  wait: true
+  poll_interval_ms: 500
+`
+	var cfg standardStackQLConfig
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	lp, ok := cfg.GetLROPolicy()
+	if !ok || !lp.IsWait() {
+		t.Fatalf("expected a waiting lro block")
+	}
+	if lp.GetStatePath() != "$.done" || lp.GetErrorPath() != "$.error" || lp.GetResult().GetPath() != "$.response" {
+		t.Fatalf("expected google operation preset defaults")
+	}
+	if lp.GetPollInterval() != 500*time.Millisecond || lp.GetMaxPollInterval() != 30*time.Second {
+		t.Fatalf("unexpected intervals %s / %s", lp.GetPollInterval(), lp.GetMaxPollInterval())
+	}
+	if lp.GetStatusMethod() != http.MethodGet || lp.GetTimeout() != 10*time.Minute {
+		t.Fatalf("unexpected status method or timeout")
+	}
+}
+
+func TestLRO_AzureAsyncOperationRereadsResourceForPut(t *testing.T) {
+	var polls int64
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/vm", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.Header().Set("Azure-AsyncOperation", srv.URL+"/ops/1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"properties":{"provisioningState":"Creating"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"properties":{"provisioningState":"Succeeded"}}`)
+	})
+	mux.HandleFunc("/ops/1", func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&polls, 1) < 3 {
+			w.Header().Set("Retry-After", "7")
+			_, _ = io.WriteString(w, `{"status":"InProgress"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status":"Succeeded"}`)
+	})
+	poller, waits := newTestPoller(&standardLROPolicy{Style: LROStyleAzureAsyncOperation})
+
+	req, initial := startOperation(t, http.MethodPut, srv.URL+"/vm", "{}")
+	resp, err := poller.Await(req, initial)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, `"Succeeded"`) {
+		t.Fatalf("expected the resource to be re-read, got %s", body)
+	}
+	if got := *waits; len(got) != 3 || got[0] != 2*time.Second || got[1] != 7*time.Second || got[2] != 7*time.Second {
+		t.Fatalf("expected the poll interval then Retry-After, got %v", got)
+	}
+}
+
+func TestLRO_AzureFallsBackToLocationPolling(t *testing.T) {
+	var polls int64
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/vm/restart", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", "/monitor/1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/monitor/1", func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&polls, 1) < 2 {
+			w.Header().Set("Location", "/monitor/1")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		_, _ = io.WriteString(w, `{"restarted":true}`)
+	})
+	poller, _ := newTestPoller(&standardLROPolicy{Style: LROStyleAzureAsyncOperation})
+
+	req, initial := startOperation(t, http.MethodPost, srv.URL+"/vm/restart", "")
+	resp, err := poller.Await(req, initial)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := readBody(t, resp); body != `{"restarted":true}` || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestLRO_GoogleOperationResultFromResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/v1/projects/p/instances", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"name":"operations/op-1","done":false}`)
+	})
+	mux.HandleFunc("/v1/operations/op-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"name":"operations/op-1","done":true,"response":{"name":"instance-1"}}`)
+	})
+	poller, _ := newTestPoller(&standardLROPolicy{Style: LROStyleGoogleOperation})
+
+	req, initial := startOperation(t, http.MethodPost, srv.URL+"/v1/projects/p/instances", "{}")
+	resp, err := poller.Await(req, initial)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := readBody(t, resp); body != `{"name":"instance-1"}` {
+		t.Fatalf("expected the operation response, got %s", body)
+	}
+}
+
+func TestLRO_AWSCloudControlFailure(t *testing.T) {
+	var gotTarget, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "CloudApiService.GetResourceRequestStatus" {
+			gotTarget = r.Header.Get("X-Amz-Target")
+			b, _ := io.ReadAll(r.Body)
+			gotBody = string(b)
+			_, _ = io.WriteString(w, `{"ProgressEvent":{"OperationStatus":"FAILED","StatusMessage":"bucket exists"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ProgressEvent":{"OperationStatus":"IN_PROGRESS","RequestToken":"tok-1"}}`)
+	}))
+	defer srv.Close()
+	poller, _ := newTestPoller(&standardLROPolicy{Style: LROStyleAWSCloudControl})
+
+	req, initial := startOperation(t, http.MethodPost, srv.URL, `{"TypeName":"AWS::S3::Bucket"}`)
+	_, err := poller.Await(req, initial)
+	var lroErr *LROError
+	if !errors.Is(err, ErrLROFailed) || !errors.As(err, &lroErr) {
+		t.Fatalf("expected an LRO failure, got %v", err)
+	}
+	if lroErr.State != "FAILED" || lroErr.Message != "bucket exists" {
+		t.Fatalf("unexpected failure detail %+v", lroErr)
+	}
+	if gotTarget == "" || gotBody != `{"RequestToken":"tok-1"}` {
+		t.Fatalf("unexpected status request body %q", gotBody)
+	}
+}
+
+func TestLRO_TimeoutAndNonLongRunning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sync" {
+			_, _ = io.WriteString(w, `{"id":"1"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"selfLink":"/op","done":false}`)
+	}))
+	defer srv.Close()
+	poller, waits := newTestPoller(&standardLROPolicy{Style: LROStyleGoogleOperation, TimeoutMs: 5000, PollIntervalMs: 1000, BackoffMultiplier: 2})
+
+	req, initial := startOperation(t, http.MethodPost, srv.URL+"/start", "{}")
+	if _, err := poller.Await(req, initial); !errors.Is(err, ErrLROTimeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if got := *waits; len(got) != 3 || got[0] != time.Second || got[1] != 2*time.Second || got[2] != 2*time.Second {
+		t.Fatalf("expected backoff capped by the deadline, got %v", got)
+	}
+
+	req, initial = startOperation(t, http.MethodGet, srv.URL+"/sync", "")
+	resp, err := poller.Await(req, initial)
+	if err != nil || readBody(t, resp) != `{"id":"1"}` {
+		t.Fatalf("expected a response without a status URL to pass through, got %v", err)
+	}
+}
+
+func TestLROPollDesignation_IsARead(t *testing.T) {
+	var gotKey, gotEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("Idempotency-Key")
+		gotEncoding = r.Header.Get("Content-Encoding")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	op.StackQLConfig = &standardStackQLConfig{
+		Retry:       &standardRetryPolicy{Idempotency: &standardIdempotencyPolicy{}},
+		Compression: &standardCompressionPolicy{RequestEncoding: "gzip"},
+	}
+	var buf bytes.Buffer
+	hc, _ := newAnySdkHttpClient(http.DefaultClient).(*anySdkHttpClient)
+	hc.auditor = newTestAuditor(&buf)
+
+	resp, err := hc.Do(NewLROPollDesignation(op), NewwHTTPAnySdkArgList(mustReq(t, http.MethodPost, srv.URL, `{"operation":"op-1"}`)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, _ := resp.GetHttpResponse(); r != nil {
+		r.Body.Close()
+	}
+	if gotKey != "" || gotEncoding != "" {
+		t.Fatalf("expected no write policies on a poll, got key %q and encoding %q", gotKey, gotEncoding)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected a poll not to be audited as a mutation, got %s", buf.String())
+	}
+	if policy := resolveRetryPolicy(NewLROPollDesignation(op)); !policy.IsMethodRetryable(http.MethodPost) {
+		t.Fatalf("expected a POST poll to be retried as a read")
+	}
+}
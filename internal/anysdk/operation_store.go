@@ -2,6 +2,7 @@ package anysdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/stackql/any-sdk/pkg/apierror"
 	"github.com/stackql/any-sdk/pkg/casing"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/fuzzymatch"
@@ -23,6 +25,7 @@ import (
 	"github.com/stackql/any-sdk/pkg/queryrouter"
 	"github.com/stackql/any-sdk/pkg/response"
 	"github.com/stackql/any-sdk/pkg/stream_transform"
+	"github.com/stackql/any-sdk/pkg/telemetry"
 	"github.com/stackql/any-sdk/pkg/urltranslate"
 	"github.com/stackql/any-sdk/pkg/util"
 	"github.com/stackql/any-sdk/pkg/xmlmap"
@@ -68,6 +71,15 @@ type OperationStore interface {
 	GetRequestNativeCasing() string
 	GetParameterOrError(paramKey string) (Addressable, error)
 	GetRetryPolicy() RetryPolicy
+	GetRateLimitPolicy() (RateLimitPolicy, bool)
+	GetCircuitBreakerPolicy() (CircuitBreakerPolicy, bool)
+	GetCompressionPolicy() (CompressionPolicy, bool)
+	GetLROPolicy() (LROPolicy, bool)
+	GetResponseStreamingPolicy() (ResponseStreamingPolicy, bool)
+	GetAcceptHeaderPolicy() (string, bool)
+	GetRequestValidation() (string, bool)
+	GetErrorFormat() (*apierror.Format, bool)
+	ParseAPIError(httpResponse *http.Response) (*apierror.APIError, bool)
 	GetParameters() map[string]Addressable
 	GetPathItem() *openapi3.PathItem
 	GetAPIMethod() string
@@ -91,6 +103,7 @@ type OperationStore interface {
 	GetSelectSchemaAndObjectPath() (Schema, string, error)
 	GetFinalSelectSchemaAndObjectPath() (Schema, string, error)
 	ProcessResponse(*http.Response) (ProcessedOperationResponse, error) // to be removed
+	ValidateResponseBody(body interface{}) []ResponseViolation
 	parameterize(prov Provider, parentDoc Service, inputParams HttpParameters, requestBody interface{}) (*openapi3filter.RequestValidationInput, error)
 	GetSelectItemsKey() string
 	GetResponseBodySchemaAndMediaType() (Schema, string, error)
@@ -104,6 +117,7 @@ type OperationStore interface {
 	GetPaginationResponseTokenSemantic() (TokenSemantic, bool)
 	GetPaginationResponseTerminatorTokenSemantic() (TokenSemantic, bool)
 	MarshalBody(body interface{}, expectedRequest ExpectedRequest) dto.MarshalledBody
+	MarshalPatchBody(desired interface{}, current interface{}, expectedRequest ExpectedRequest) dto.MarshalledBody
 	GetRequestBodySchema() (Schema, error)
 	GetNonBodyParameters() map[string]Addressable
 	GetRequestBodyAttributesNoRename() (map[string]Addressable, error)
@@ -446,6 +460,125 @@ func (op *standardOpenAPIOperationStore) GetRetryPolicy() RetryPolicy {
 	return DefaultRetryPolicy()
 }
 
+// resolveStackQLConfig returns the first setting found by get on the config
+// of the method, then of its resource, service, provider service and
+// provider: the inheritance walk of GetRetryPolicy.
+func resolveStackQLConfig[T any](op *standardOpenAPIOperationStore, get func(StackQLConfig) (T, bool)) (T, bool) {
+	var levels []StackQLConfig
+	if cfg, ok := op.getStackQLConfig(); ok {
+		levels = append(levels, cfg)
+	}
+	if op.Resource != nil {
+		if cfg, ok := op.Resource.getStackQLConfig(); ok {
+			levels = append(levels, cfg)
+		}
+	}
+	return resolveStackQLConfigAbove(levels, op.OpenAPIService, op.GetProviderService(), op.GetProvider(), get)
+}
+
+// resolveResourceStackQLConfig is resolveStackQLConfig for a setting read
+// at the resource level, such as the upsert policy: the walk starts at the
+// resource.
+func resolveResourceStackQLConfig[T any](rs *standardResource, get func(StackQLConfig) (T, bool)) (T, bool) {
+	var levels []StackQLConfig
+	if cfg, ok := rs.getStackQLConfig(); ok {
+		levels = append(levels, cfg)
+	}
+	return resolveStackQLConfigAbove(levels, rs.OpenAPIService, rs.ProviderService, rs.Provider, get)
+}
+
+// resolveStackQLConfigAbove completes a walk begun with levels, through the
+// service, provider service and provider. The latter two default to the
+// service's.
+func resolveStackQLConfigAbove[T any](
+	levels []StackQLConfig,
+	svc OpenAPIService,
+	ps ProviderService,
+	prov Provider,
+	get func(StackQLConfig) (T, bool),
+) (T, bool) {
+	if svc != nil {
+		if cfg, ok := svc.getStackQLConfig(); ok {
+			levels = append(levels, cfg)
+		}
+		if ps == nil {
+			ps = svc.getProviderService()
+		}
+		if prov == nil {
+			prov = svc.getProvider()
+		}
+	}
+	if ps != nil {
+		if cfg, ok := ps.getStackQLConfig(); ok {
+			levels = append(levels, cfg)
+		}
+	}
+	if prov != nil {
+		if cfg, ok := prov.GetStackQLConfig(); ok {
+			levels = append(levels, cfg)
+		}
+	}
+	for _, cfg := range levels {
+		if rv, ok := get(cfg); ok {
+			return rv, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// GetRateLimitPolicy returns the closest rate limit policy. There is no
+// default; absence means requests are not throttled client side.
+func (op *standardOpenAPIOperationStore) GetRateLimitPolicy() (RateLimitPolicy, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetRateLimitPolicy)
+}
+
+// GetCircuitBreakerPolicy returns the closest circuit breaker policy. Absence
+// means failing hosts are called regardless of their recent errors.
+func (op *standardOpenAPIOperationStore) GetCircuitBreakerPolicy() (CircuitBreakerPolicy, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetCircuitBreakerPolicy)
+}
+
+// GetCompressionPolicy returns the closest compression policy. Absence
+// leaves content coding to the Go transport.
+func (op *standardOpenAPIOperationStore) GetCompressionPolicy() (CompressionPolicy, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetCompressionPolicy)
+}
+
+// GetLROPolicy returns how the method's long-running operations are polled.
+// Absence means responses are processed as returned.
+func (op *standardOpenAPIOperationStore) GetLROPolicy() (LROPolicy, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetLROPolicy)
+}
+
+// GetResponseStreamingPolicy returns the closest responseStreaming config.
+// Absence means JSON responses are decoded whole, without a size limit, and
+// record formats stream with the default batch size.
+func (op *standardOpenAPIOperationStore) GetResponseStreamingPolicy() (ResponseStreamingPolicy, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetResponseStreamingPolicy)
+}
+
+// GetAcceptHeaderPolicy returns the declared Accept header behaviour,
+// response_media_type or omit. Absence means the legacy provider defaults
+// apply.
+func (op *standardOpenAPIOperationStore) GetAcceptHeaderPolicy() (string, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetAcceptHeaderPolicy)
+}
+
+// GetRequestValidation returns whether parameter values and body properties
+// are checked against their schemas before sending. Absence means
+// validation is on.
+func (op *standardOpenAPIOperationStore) GetRequestValidation() (string, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetRequestValidation)
+}
+
+// GetErrorFormat returns where the code, message and request ID sit in
+// custom error bodies. Absence means only the known body formats are
+// recognised.
+func (op *standardOpenAPIOperationStore) GetErrorFormat() (*apierror.Format, bool) {
+	return resolveStackQLConfig(op, StackQLConfig.GetErrorFormat)
+}
+
 // GetQueryParamPushdown returns the queryParamPushdown config with inheritance.
 // It walks up the hierarchy: Method -> Resource -> Service -> ProviderService -> Provider
 func (op *standardOpenAPIOperationStore) GetQueryParamPushdown() (QueryParamPushdown, bool) {
@@ -530,11 +663,21 @@ func (op *standardOpenAPIOperationStore) getServers() (openapi3.Servers, bool) {
 	return nil, false
 }
 
+// GetProviderService returns the operation's provider service, else its
+// service's: a service is given its provider service after its operations
+// are resolved.
 func (op *standardOpenAPIOperationStore) GetProviderService() ProviderService {
+	if op.ProviderService == nil && op.OpenAPIService != nil {
+		return op.OpenAPIService.getProviderService()
+	}
 	return op.ProviderService
 }
 
+// GetProvider returns the operation's provider, else its service's.
 func (op *standardOpenAPIOperationStore) GetProvider() Provider {
+	if op.Provider == nil && op.OpenAPIService != nil {
+		return op.OpenAPIService.getProvider()
+	}
 	return op.Provider
 }
 
@@ -674,13 +817,13 @@ func (op *standardOpenAPIOperationStore) GetPaginationAlgorithm() string {
 			return a
 		}
 	}
-	if op.ProviderService != nil {
-		if a := op.ProviderService.GetPaginationAlgorithm(); a != "" {
+	if ps := op.GetProviderService(); ps != nil {
+		if a := ps.GetPaginationAlgorithm(); a != "" {
 			return a
 		}
 	}
-	if op.Provider != nil {
-		if a := op.Provider.GetPaginationAlgorithm(); a != "" {
+	if prov := op.GetProvider(); prov != nil {
+		if a := prov.GetPaginationAlgorithm(); a != "" {
 			return a
 		}
 	}
@@ -1519,14 +1662,21 @@ func (op *standardOpenAPIOperationStore) marshalBody(body interface{}, expectedR
 		b, err := op.transformRequestBodyMap(body.(map[string]interface{}))
 		return dto.NewMarshalledBody(b, err)
 	}
+	if isPatchMediaType(expectedRequest.GetBodyMediaType()) {
+		return op.marshalPatchBody(body, nil, expectedRequest)
+	}
 	mediaType := expectedRequest.GetBodyMediaType()
 	if expectedRequest.GetSchema() != nil {
 		mediaType = expectedRequest.GetSchema().ExtractMediaTypeSynonym(mediaType)
 	}
-	switch mediaType {
+	switch media.StripMediaTypeParameters(mediaType) {
 	case media.MediaTypeJson:
 		b, err := json.Marshal(body)
 		return dto.NewMarshalledBody(b, err)
+	case media.MediaTypeFormURLEncoded:
+		return op.marshalFormBody(body, expectedRequest, media.MediaTypeFormURLEncoded)
+	case media.MediaTypeMultipartForm:
+		return op.marshalFormBody(body, expectedRequest, media.MediaTypeMultipartForm)
 	case media.MediaTypeXML, media.MediaTypeTextXML:
 		b, err := xmlmap.MarshalXMLUserInput(
 			body,
@@ -1639,6 +1789,7 @@ func (op *standardOpenAPIOperationStore) parameterize(prov Provider, parentDoc S
 		return nil, err
 	}
 	contentTypeHeaderRequired := false
+	var contentTypeOverride string
 	var bodyReader io.Reader
 	if op.Request != nil {
 		// TODO: transform
@@ -1656,6 +1807,7 @@ func (op *standardOpenAPIOperationStore) parameterize(prov Provider, parentDoc S
 		} else if len(b) > 0 {
 			bodyReader = bytes.NewReader(b)
 			contentTypeHeaderRequired = true
+			contentTypeOverride, _ = marshalledBody.GetContentType()
 		}
 	}
 	// TODO: clean up
@@ -1677,7 +1829,9 @@ func (op *standardOpenAPIOperationStore) parameterize(prov Provider, parentDoc S
 		return nil, err
 	}
 	if contentTypeHeaderRequired {
-		if prefilledHeader.Get("Content-Type") != "" {
+		if contentTypeOverride != "" {
+			prefilledHeader.Set("Content-Type", contentTypeOverride)
+		} else if prefilledHeader.Get("Content-Type") != "" {
 			prefilledHeader.Set("Content-Type", op.Request.BodyMediaType)
 		}
 	}
@@ -1774,6 +1928,9 @@ func (op *standardOpenAPIOperationStore) GetSelectSchemaAndObjectPath() (Schema,
 	if op.Response != nil && op.Response.OverrideSchema != nil && op.Response.OverrideSchema.Value != nil {
 		return op.Response.OverrideSchema.Value.getSelectItemsSchema(k, op.Response.OverrideBodyMediaType)
 	}
+	if binaryResponse, isBinary := op.getBinaryResponse(); isBinary {
+		return binaryResponse.getBinaryRowSchema(), "", nil
+	}
 	if op.Response != nil && op.Response.Schema != nil {
 		return op.Response.Schema.getSelectItemsSchema(k, op.getOptimalResponseMediaType())
 	}
@@ -1788,6 +1945,9 @@ func (op *standardOpenAPIOperationStore) GetFinalSelectSchemaAndObjectPath() (Sc
 	if op.Response != nil && op.Response.OverrideSchema != nil && op.Response.OverrideSchema.Value != nil {
 		return op.Response.OverrideSchema.Value.getSelectItemsSchema(k, op.Response.OverrideBodyMediaType)
 	}
+	if binaryResponse, isBinary := op.getBinaryResponse(); isBinary {
+		return binaryResponse.getBinaryRowSchema(), "", nil
+	}
 	if op.Response != nil && op.Response.Schema != nil {
 		return op.Response.Schema.getSelectItemsSchema(k, op.getOptimalResponseMediaType())
 	}
@@ -1936,7 +2096,18 @@ func (op *standardOpenAPIOperationStore) getOverridenResponse(httpResponse *http
 			if err != nil {
 				return nil, fmt.Errorf("failed to transform: %v", err)
 			}
+			spanCtx := context.Background()
+			if httpResponse.Request != nil {
+				spanCtx = httpResponse.Request.Context()
+			}
+			_, transformSpan := telemetry.StartSpan(
+				spanCtx,
+				telemetry.SpanTransform,
+				telemetry.String(telemetry.AttrOperation, op.GetName()),
+				telemetry.String(telemetry.AttrTransformType, responseTransform.GetType()),
+			)
 			tfmErr := tfm.Transform()
+			telemetry.EndSpan(transformSpan, tfmErr)
 			if tfmErr != nil {
 				return nil, fmt.Errorf("failed to transform: %v", tfmErr)
 			}
@@ -2025,12 +2196,21 @@ func (op *standardOpenAPIOperationStore) ProcessResponse(httpResponse *http.Resp
 	if op.Response != nil {
 		overrideMediaType = op.Response.OverrideBodyMediaType
 	}
+	limitResponseBody(op, httpResponse)
+	apiErr, isAPIError := op.ParseAPIError(httpResponse)
 	var rv response.Response
-	if op.isOverridable(httpResponse) {
+	binaryResponse, isBinary := op.getBinaryResponse()
+	isSuccess := httpResponse != nil && httpResponse.StatusCode >= 200 && httpResponse.StatusCode < 300
+	if isBinary && isSuccess && httpResponse.Body != nil {
+		rv, err = op.processBinaryResponse(binaryResponse, httpResponse)
+	} else if op.isOverridable(httpResponse) {
 		rv, err = op.getOverridenResponse(httpResponse, responseSchema)
 	} else {
 		rv, err = responseSchema.processHttpResponse(httpResponse, op.lookupSelectItemsKey(), mediaType, overrideMediaType)
 	}
+	if isAPIError && rv != nil {
+		rv.SetAPIError(apiErr)
+	}
 	var reversal HTTPPreparator
 	inverse, inverseExists := op.GetInverse()
 	if inverseExists {
@@ -693,3 +693,30 @@ func TestJSONRequestBody(t *testing.T) {
 	}
 
 }
+
+// TestResolveStackQLConfig_ClosestLevelWins: a setting on the method
+// overrides its resource, service, provider service and provider, and
+// levels without a config, or without the setting, are skipped.
+func TestResolveStackQLConfig_ClosestLevelWins(t *testing.T) {
+	op := &standardOpenAPIOperationStore{
+		Resource:        &standardResource{StackQLConfig: &standardStackQLConfig{RequestValidation: "off"}},
+		OpenAPIService:  &standardService{},
+		ProviderService: &standardProviderService{StackQLConfig: &standardStackQLConfig{}},
+		Provider: &standardProvider{StackQLConfig: &standardStackQLConfig{
+			AcceptHeaderPolicy: "omit",
+			RequestValidation:  "on",
+		}},
+	}
+	validation, ok := op.GetRequestValidation()
+	assert.Assert(t, ok)
+	assert.Equal(t, validation, "off")
+	accept, ok := op.GetAcceptHeaderPolicy()
+	assert.Assert(t, ok)
+	assert.Equal(t, accept, "omit")
+	_, ok = op.GetLROPolicy()
+	assert.Assert(t, !ok)
+
+	op.StackQLConfig = &standardStackQLConfig{AcceptHeaderPolicy: "response_media_type"}
+	accept, _ = op.GetAcceptHeaderPolicy()
+	assert.Equal(t, accept, "response_media_type")
+}
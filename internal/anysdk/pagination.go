@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/internaldto"
+	"github.com/stackql/any-sdk/pkg/paginator"
 )
 
 var (
@@ -27,6 +29,11 @@ const (
 	// using the public Pagination / TokenSemantic accessors (responseToken keyed at
 	// `@odata.nextLink`).
 	PaginationAlgorithmODataNextLink = "odata_next_link"
+	// PaginationAlgorithmLinkHeader identifies RFC 8288 Link header
+	// pagination: the rel="next" target of the response Link header is the
+	// next request URL. Explicit requestToken / responseToken semantics take
+	// precedence; absent those, this algorithm implies both.
+	PaginationAlgorithmLinkHeader = "link_header"
 )
 
 type Pagination interface {
@@ -49,10 +56,16 @@ func (qt *standardPagination) GetAlgorithm() string {
 }
 
 func (qt *standardPagination) GetRequestToken() TokenSemantic {
+	if qt.RequestToken == nil {
+		return nil
+	}
 	return qt.RequestToken
 }
 
 func (qt *standardPagination) GetResponseToken() TokenSemantic {
+	if qt.ResponseToken == nil {
+		return nil
+	}
 	return qt.ResponseToken
 }
 
@@ -78,6 +91,83 @@ func (qt standardPagination) JSONLookup(token string) (interface{}, error) {
 	}
 }
 
+// NewPaginatorConfig maps an operation's pagination spec onto a
+// paginator.Config. The second return is false when the operation declares
+// no response token. An absent request token means a "pageToken" query
+// parameter, as in the invoker. A response token in a header is a header
+// of RFC 8288 links, whatever the header is named.
+func NewPaginatorConfig(op OperationStore) (paginator.Config, bool) {
+	responseToken, hasResponseToken := op.GetPaginationResponseTokenSemantic()
+	if !hasResponseToken || responseToken == nil {
+		if op.GetPaginationAlgorithm() == PaginationAlgorithmLinkHeader {
+			return paginator.Config{
+				Style:     paginator.StyleLink,
+				ItemsPath: paginatorItemsPath(op.GetSelectItemsKey()),
+			}, true
+		}
+		return paginator.Config{}, false
+	}
+	rv := paginator.Config{
+		Style:                 paginator.StyleToken,
+		ResponseTokenLocation: strings.ToLower(responseToken.GetLocation()),
+		ResponseToken:         paginatorPath(responseToken),
+		RequestTokenLocation:  paginator.LocationQuery,
+		RequestToken:          "pageToken",
+		ItemsPath:             paginatorItemsPath(op.GetSelectItemsKey()),
+	}
+	requestToken, hasRequestToken := op.GetPaginationRequestTokenSemantic()
+	if hasRequestToken && requestToken != nil {
+		rv.RequestTokenLocation = strings.ToLower(requestToken.GetLocation())
+		rv.RequestToken = requestToken.GetKey()
+	}
+	isRequestString := rv.RequestTokenLocation == strings.ToLower(internaldto.RequestStringStr)
+	switch {
+	case op.GetPaginationAlgorithm() == PaginationAlgorithmPageNumber:
+		rv.Style = paginator.StylePageNumber
+		rv.PageParam = rv.RequestToken
+		rv.CurrentPagePath = rv.ResponseToken
+		if terminator, ok := op.GetPaginationResponseTerminatorTokenSemantic(); ok && terminator != nil {
+			rv.TotalPagesPath = paginatorPath(terminator)
+		}
+	case op.GetPaginationAlgorithm() == PaginationAlgorithmODataNextLink:
+		rv.Style = paginator.StyleNextURL
+	case op.GetPaginationAlgorithm() == PaginationAlgorithmLinkHeader,
+		rv.ResponseTokenLocation == paginator.LocationHeader:
+		rv.Style = paginator.StyleLink
+	case isRequestString:
+		rv.Style = paginator.StyleNextURL
+	}
+	return rv, true
+}
+
+// paginatorPath renders a body token key as a JSON path. Keys are dotted
+// paths (e.g. "result_info.page"), except annotation keys such as
+// "@odata.nextLink", which are bracket quoted so the dot is not a separator.
+func paginatorPath(ts TokenSemantic) string {
+	if !strings.EqualFold(ts.GetLocation(), paginator.LocationBody) {
+		return ts.GetKey()
+	}
+	return toJSONPath(ts.GetKey())
+}
+
+func paginatorItemsPath(selectItemsKey string) string {
+	if selectItemsKey == "" || selectItemsKey == "/*" {
+		return ""
+	}
+	return toJSONPath(selectItemsKey)
+}
+
+func toJSONPath(key string) string {
+	switch {
+	case strings.HasPrefix(key, "$"):
+		return key
+	case strings.HasPrefix(key, "@"):
+		return fmt.Sprintf(`$["%s"]`, key)
+	default:
+		return "$." + key
+	}
+}
+
 // GetTestingPagination returns a zero-value Pagination for testing.
 // Mirrors the GetTestingQueryParamPushdown helper convention.
 func GetTestingPagination() standardPagination {
@@ -0,0 +1,68 @@
+package anysdk
+
+import (
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/paginator"
+)
+
+func TestNewPaginatorConfig(t *testing.T) {
+	op := &standardOpenAPIOperationStore{
+		StackQLConfig: &standardStackQLConfig{
+			Pagination: &standardPagination{
+				Algorithm:          PaginationAlgorithmPageNumber,
+				RequestToken:       &standardTokenSemantic{Key: "page", Location: "query"},
+				ResponseToken:      &standardTokenSemantic{Key: "result_info.page", Location: "body"},
+				ResponseTerminator: &standardTokenSemantic{Key: "result_info.total_pages", Location: "body"},
+			},
+		},
+	}
+	cfg, ok := NewPaginatorConfig(op)
+	if !ok {
+		t.Fatalf("expected a paginator config")
+	}
+	if cfg.Style != paginator.StylePageNumber || cfg.PageParam != "page" ||
+		cfg.CurrentPagePath != "$.result_info.page" || cfg.TotalPagesPath != "$.result_info.total_pages" {
+		t.Fatalf("unexpected page number config %+v", cfg)
+	}
+
+	op.StackQLConfig.Pagination = &standardPagination{
+		Algorithm:     PaginationAlgorithmODataNextLink,
+		ResponseToken: &standardTokenSemantic{Key: "@odata.nextLink", Location: "body"},
+	}
+	if cfg, _ = NewPaginatorConfig(op); cfg.Style != paginator.StyleNextURL || cfg.ResponseToken != `$["@odata.nextLink"]` {
+		t.Fatalf("unexpected next link config %+v", cfg)
+	}
+
+	op.StackQLConfig.Pagination = &standardPagination{
+		ResponseToken: &standardTokenSemantic{Key: "Link", Location: "header"},
+		RequestToken:  &standardTokenSemantic{Location: "request"},
+	}
+	if cfg, _ = NewPaginatorConfig(op); cfg.Style != paginator.StyleLink {
+		t.Fatalf("expected a Link header sent as the request URL to be followed as links, got %+v", cfg)
+	}
+
+	op.StackQLConfig.Pagination = &standardPagination{
+		ResponseToken: &standardTokenSemantic{Key: "next", Location: "body"},
+		RequestToken:  &standardTokenSemantic{Location: "request"},
+	}
+	if cfg, _ = NewPaginatorConfig(op); cfg.Style != paginator.StyleNextURL {
+		t.Fatalf("expected a request string token to follow URLs, got %+v", cfg)
+	}
+
+	op.StackQLConfig.Pagination = &standardPagination{
+		ResponseToken: &standardTokenSemantic{Key: "Link", Location: "header"},
+	}
+	if cfg, _ = NewPaginatorConfig(op); cfg.Style != paginator.StyleLink {
+		t.Fatalf("expected link style, got %+v", cfg)
+	}
+
+	op.StackQLConfig.Pagination = &standardPagination{
+		ResponseToken: &standardTokenSemantic{Key: "X-Next", Location: "header"},
+		RequestToken:  &standardTokenSemantic{Key: "cursor", Location: "query"},
+	}
+	cfg, _ = NewPaginatorConfig(op)
+	if cfg.Style != paginator.StyleLink || cfg.ResponseTokenLocation != paginator.LocationHeader || cfg.ResponseToken != "X-Next" {
+		t.Fatalf("expected links read from the X-Next header, got %+v", cfg)
+	}
+}
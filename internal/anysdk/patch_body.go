@@ -0,0 +1,94 @@
+package anysdk
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/jsonpatch"
+	"github.com/stackql/any-sdk/pkg/media"
+)
+
+func isPatchMediaType(mediaType string) bool {
+	switch media.StripMediaTypeParameters(mediaType) {
+	case media.MediaTypeMergePatchJson, media.MediaTypeJsonPatchJson:
+		return true
+	default:
+		return false
+	}
+}
+
+// MarshalPatchBody marshals the desired column values of an update. When the
+// request media type is application/merge-patch+json or
+// application/json-patch+json, the body is the RFC 7396 or RFC 6902 document
+// that turns current into desired; a nil current sends every desired value.
+// Other media types marshal desired as MarshalBody does and ignore current.
+// Request preparation calls MarshalBody, so it never diffs against a
+// fetched current state.
+func (op *standardOpenAPIOperationStore) MarshalPatchBody(
+	desired interface{},
+	current interface{},
+	expectedRequest ExpectedRequest,
+) dto.MarshalledBody {
+	if !isPatchMediaType(expectedRequest.GetBodyMediaType()) {
+		return op.marshalBody(desired, expectedRequest)
+	}
+	return op.marshalPatchBody(desired, current, expectedRequest)
+}
+
+func (op *standardOpenAPIOperationStore) marshalPatchBody(
+	desired interface{},
+	current interface{},
+	expectedRequest ExpectedRequest,
+) dto.MarshalledBody {
+	desiredMap, err := toPatchObject(desired)
+	if err != nil {
+		return dto.NewMarshalledBody(nil, fmt.Errorf("desired state: %w", err))
+	}
+	currentMap, err := toPatchObject(current)
+	if err != nil {
+		return dto.NewMarshalledBody(nil, fmt.Errorf("current state: %w", err))
+	}
+	var doc interface{}
+	switch media.StripMediaTypeParameters(expectedRequest.GetBodyMediaType()) {
+	case media.MediaTypeJsonPatchJson:
+		patch := jsonpatch.CreateJSONPatch(currentMap, desiredMap)
+		if patch == nil {
+			patch = []jsonpatch.Operation{}
+		}
+		doc = patch
+	default:
+		doc = jsonpatch.CreateMergePatch(currentMap, desiredMap)
+	}
+	b, err := json.Marshal(doc)
+	return dto.NewMarshalledBody(b, err)
+}
+
+// toPatchObject accepts a decoded JSON object, raw JSON bytes or a JSON
+// string, so that a fetched current state can be passed as received.
+func toPatchObject(v interface{}) (map[string]interface{}, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return t, nil
+	case []byte:
+		return unmarshalPatchObject(t)
+	case string:
+		return unmarshalPatchObject([]byte(t))
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalPatchObject(b)
+	}
+}
+
+func unmarshalPatchObject(b []byte) (map[string]interface{}, error) {
+	var rv map[string]interface{}
+	if err := json.Unmarshal(b, &rv); err != nil {
+		return nil, fmt.Errorf("patch state must be a JSON object: %w", err)
+	}
+	return rv, nil
+}
@@ -0,0 +1,59 @@
+package anysdk
+
+import (
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/media"
+)
+
+func TestMarshalBody_PatchMediaTypes(t *testing.T) {
+	desired := map[string]interface{}{"name": "vm-1", "labels": map[string]interface{}{"env": "prod"}}
+	current := []byte(`{"name":"vm-1","labels":{"env":"dev","team":"a"}}`)
+	cases := []struct {
+		mediaType, noCurrent, withCurrent string
+	}{
+		{
+			media.MediaTypeMergePatchJson,
+			`{"labels":{"env":"prod"},"name":"vm-1"}`,
+			`{"labels":{"env":"prod"}}`,
+		},
+		{
+			media.MediaTypeJsonPatchJson + "; charset=utf-8",
+			`[{"op":"add","path":"/labels","value":{"env":"prod"}},{"op":"add","path":"/name","value":"vm-1"}]`,
+			`[{"op":"replace","path":"/labels/env","value":"prod"}]`,
+		},
+	}
+	for _, c := range cases {
+		op := &standardOpenAPIOperationStore{Request: &standardExpectedRequest{BodyMediaType: c.mediaType}}
+		mb := op.MarshalBody(desired, op.Request)
+		if err, hasErr := mb.GetError(); hasErr {
+			t.Fatalf("%s: unexpected error: %v", c.mediaType, err)
+		}
+		if string(mb.GetBytes()) != c.noCurrent {
+			t.Fatalf("%s: got %s, want %s", c.mediaType, mb.GetBytes(), c.noCurrent)
+		}
+		mb = op.MarshalPatchBody(desired, current, op.Request)
+		if err, hasErr := mb.GetError(); hasErr {
+			t.Fatalf("%s: unexpected error: %v", c.mediaType, err)
+		}
+		if string(mb.GetBytes()) != c.withCurrent {
+			t.Fatalf("%s: got %s, want %s", c.mediaType, mb.GetBytes(), c.withCurrent)
+		}
+	}
+}
+
+func TestMarshalPatchBody_NonPatchMediaTypeIgnoresCurrent(t *testing.T) {
+	op := &standardOpenAPIOperationStore{Request: &standardExpectedRequest{BodyMediaType: media.MediaTypeJson}}
+	mb := op.MarshalPatchBody(map[string]interface{}{"a": "b"}, map[string]interface{}{"a": "b"}, op.Request)
+	if string(mb.GetBytes()) != `{"a":"b"}` {
+		t.Fatalf("unexpected body %s", mb.GetBytes())
+	}
+}
+
+func TestMarshalPatchBody_RejectsNonObjectState(t *testing.T) {
+	op := &standardOpenAPIOperationStore{Request: &standardExpectedRequest{BodyMediaType: media.MediaTypeMergePatchJson}}
+	mb := op.MarshalPatchBody(map[string]interface{}{"a": "b"}, "[1,2]", op.Request)
+	if _, hasErr := mb.GetError(); !hasErr {
+		t.Fatalf("expected an error for a non-object current state")
+	}
+}
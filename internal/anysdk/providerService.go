@@ -47,6 +47,7 @@ type ProviderService interface {
 	setService(svc Service) bool
 	getServiceWithRegistry(registry RegistryAPI) (Service, error)
 	getServiceDocRef(rr ResourceRegister, rsc Resource) ServiceRef
+	getStackQLConfig() (StackQLConfig, bool)
 	setProvider(provider Provider)
 }
 
@@ -210,6 +211,10 @@ func (sv *standardProviderService) GetRetryPolicy() (RetryPolicy, bool) {
 	return nil, false
 }
 
+func (sv *standardProviderService) getStackQLConfig() (StackQLConfig, bool) {
+	return sv.StackQLConfig, sv.StackQLConfig != nil
+}
+
 func (sv *standardProviderService) ConditionIsValid(lhs string, rhs interface{}) bool {
 	elem := sv.ToMap()[lhs]
 	return reflect.TypeOf(elem) == reflect.TypeOf(rhs)
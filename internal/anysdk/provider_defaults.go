@@ -0,0 +1,32 @@
+package anysdk
+
+const (
+	// AcceptHeaderPolicyResponseMediaType sends the operation's response
+	// media type as the Accept header. This is the default.
+	AcceptHeaderPolicyResponseMediaType = "response_media_type"
+	// AcceptHeaderPolicyOmit sends no Accept header, for APIs that reject
+	// or mishandle one.
+	AcceptHeaderPolicyOmit = "omit"
+)
+
+// ResolveAcceptHeaderPolicy returns the effective acceptHeaderPolicy for an
+// operation: declared anywhere in its inheritance chain, else
+// AcceptHeaderPolicyResponseMediaType.
+func ResolveAcceptHeaderPolicy(op OperationStore) string {
+	if op != nil {
+		if p, ok := op.GetAcceptHeaderPolicy(); ok {
+			return p
+		}
+	}
+	return AcceptHeaderPolicyResponseMediaType
+}
+
+// ResolvePaginationAlgorithm returns the effective pagination algorithm for
+// an operation: declared anywhere in its inheritance chain. Empty means
+// token pagination.
+func ResolvePaginationAlgorithm(op OperationStore) string {
+	if op == nil {
+		return ""
+	}
+	return op.GetPaginationAlgorithm()
+}
@@ -0,0 +1,51 @@
+package anysdk
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/fileutil"
+
+	"gotest.tools/assert"
+)
+
+func mustLoadProvider(t *testing.T, doc string) Provider {
+	t.Helper()
+	prov, err := LoadProviderDocFromBytes([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error loading provider doc: %v", err)
+	}
+	return prov
+}
+
+// TestProviderBehaviour_DeclaredByDocuments checks that the providers whose
+// names used to be special cased get their behaviour from their documents.
+func TestProviderBehaviour_DeclaredByDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		path       string
+		accept     string
+		pagination string
+	}{
+		{path: "test/registry/src/aws/v0.1.0/provider.yaml", accept: AcceptHeaderPolicyOmit},
+		{path: "test/registry/src/okta/v0.1.0/provider.yaml", accept: AcceptHeaderPolicyResponseMediaType, pagination: PaginationAlgorithmLinkHeader},
+		{path: "test/registry/unsigned-src/github/v1/provider.yaml", accept: AcceptHeaderPolicyResponseMediaType, pagination: PaginationAlgorithmLinkHeader},
+	} {
+		fullPath, err := fileutil.GetFilePathFromRepositoryRoot(tc.path)
+		assert.NilError(t, err)
+		doc, err := os.ReadFile(fullPath)
+		assert.NilError(t, err)
+		op := &standardOpenAPIOperationStore{Provider: mustLoadProvider(t, string(doc))}
+		assert.Equal(t, ResolveAcceptHeaderPolicy(op), tc.accept, tc.path)
+		assert.Equal(t, ResolvePaginationAlgorithm(op), tc.pagination, tc.path)
+	}
+}
+
+// TestProviderBehaviour_NotKeyedOnName checks that an undeclared document
+// gets the defaults, even under a name that used to be special cased.
+func TestProviderBehaviour_NotKeyedOnName(t *testing.T) {
+	for _, name := range []string{"aws", "github", "okta"} {
+		op := &standardOpenAPIOperationStore{Provider: mustLoadProvider(t, "id: "+name+"\nname: "+name+"\nversion: v1\n")}
+		assert.Equal(t, ResolveAcceptHeaderPolicy(op), AcceptHeaderPolicyResponseMediaType)
+		assert.Equal(t, ResolvePaginationAlgorithm(op), "")
+	}
+}
@@ -0,0 +1,35 @@
+package anysdk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+func TestProxyRules_MatchOnConfiguredProvider(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+	runtimeCtx := dto.RuntimeCtx{
+		HTTPProxyRules: `{"rules": [{"providers": ["otherprovider"], "proxy": "direct"}, {"providers": ["myprovider"], "proxy": "` + proxy.URL + `"}]}`,
+	}
+	cc := NewAnySdkClientConfigurator(runtimeCtx, "myprovider", nil)
+	c, err := cc.Auth(&dto.AuthCtx{}, dto.AuthNullStr, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := mustReq(t, http.MethodGet, "http://api.example.test/v1/things", "")
+	resp, err := c.Do(nil, NewwHTTPAnySdkArgList(req))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	httpResp, _ := resp.GetHttpResponse()
+	httpResp.Body.Close()
+	if proxied != "http://api.example.test/v1/things" {
+		t.Fatalf("expected the provider's request to reach its proxy, got %q", proxied)
+	}
+}
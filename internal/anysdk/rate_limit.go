@@ -0,0 +1,175 @@
+package anysdk
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/ratelimit"
+)
+
+var (
+	_ RateLimitPolicy           = &standardRateLimitPolicy{}
+	_ RateLimitAdaptation       = &standardRateLimitAdaptation{}
+	_ jsonpointer.JSONPointable = standardRateLimitPolicy{}
+	_ jsonpointer.JSONPointable = standardRateLimitAdaptation{}
+)
+
+// RateLimitPolicy describes a client-side request budget. Budgets are
+// enforced per provider and host, and shared by every concurrent call in
+// the process. Per-host overrides replace, rather than merge with, the
+// top level settings.
+type RateLimitPolicy interface {
+	GetRequestsPerSecond() float64
+	GetBurst() int
+	GetMaxConcurrency() int
+	GetAdaptation() (RateLimitAdaptation, bool)
+	GetHostOverrides() map[string]RateLimitPolicy
+	ForHost(host string) RateLimitPolicy
+	ToLimiterConfig() ratelimit.Config
+}
+
+// RateLimitAdaptation configures pausing when the server reports an
+// exhausted budget through response headers.
+type RateLimitAdaptation interface {
+	GetRemainingHeader() string
+	GetResetHeader() string
+	GetResetFormat() string
+}
+
+type standardRateLimitAdaptation struct {
+	RemainingHeader string `json:"remaining_header,omitempty" yaml:"remaining_header,omitempty"`
+	ResetHeader     string `json:"reset_header,omitempty" yaml:"reset_header,omitempty"`
+	ResetFormat     string `json:"reset_format,omitempty" yaml:"reset_format,omitempty"`
+}
+
+func (ra standardRateLimitAdaptation) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "remaining_header":
+		return ra.RemainingHeader, nil
+	case "reset_header":
+		return ra.ResetHeader, nil
+	case "reset_format":
+		return ra.ResetFormat, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from RateLimitAdaptation doc object", token)
+	}
+}
+
+func (ra *standardRateLimitAdaptation) GetRemainingHeader() string {
+	if ra.RemainingHeader == "" {
+		return ratelimit.DefaultRemainingHeader
+	}
+	return ra.RemainingHeader
+}
+
+func (ra *standardRateLimitAdaptation) GetResetHeader() string {
+	if ra.ResetHeader == "" {
+		return ratelimit.DefaultResetHeader
+	}
+	return ra.ResetHeader
+}
+
+func (ra *standardRateLimitAdaptation) GetResetFormat() string {
+	switch ra.ResetFormat {
+	case ratelimit.ResetFormatEpochSeconds, ratelimit.ResetFormatDeltaSeconds:
+		return ra.ResetFormat
+	default:
+		return ratelimit.ResetFormatAuto
+	}
+}
+
+type standardRateLimitPolicy struct {
+	RequestsPerSecond float64                             `json:"requests_per_second,omitempty" yaml:"requests_per_second,omitempty"`
+	Burst             int                                 `json:"burst,omitempty" yaml:"burst,omitempty"`
+	MaxConcurrency    int                                 `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`
+	Adaptive          *standardRateLimitAdaptation        `json:"adaptive,omitempty" yaml:"adaptive,omitempty"`
+	Hosts             map[string]*standardRateLimitPolicy `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+}
+
+func (rl standardRateLimitPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "requests_per_second":
+		return rl.RequestsPerSecond, nil
+	case "burst":
+		return rl.Burst, nil
+	case "max_concurrency":
+		return rl.MaxConcurrency, nil
+	case "adaptive":
+		return rl.Adaptive, nil
+	case "hosts":
+		return rl.Hosts, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from RateLimitPolicy doc object", token)
+	}
+}
+
+func (rl *standardRateLimitPolicy) GetRequestsPerSecond() float64 {
+	if rl.RequestsPerSecond < 0 {
+		return 0
+	}
+	return rl.RequestsPerSecond
+}
+
+func (rl *standardRateLimitPolicy) GetBurst() int {
+	if rl.Burst < 0 {
+		return 0
+	}
+	return rl.Burst
+}
+
+func (rl *standardRateLimitPolicy) GetMaxConcurrency() int {
+	if rl.MaxConcurrency < 0 {
+		return 0
+	}
+	return rl.MaxConcurrency
+}
+
+func (rl *standardRateLimitPolicy) GetAdaptation() (RateLimitAdaptation, bool) {
+	if rl.Adaptive == nil {
+		return nil, false
+	}
+	return rl.Adaptive, true
+}
+
+func (rl *standardRateLimitPolicy) GetHostOverrides() map[string]RateLimitPolicy {
+	rv := make(map[string]RateLimitPolicy, len(rl.Hosts))
+	for k, v := range rl.Hosts {
+		if v != nil {
+			rv[k] = v
+		}
+	}
+	return rv
+}
+
+// ForHost returns the override declared for host, matched case-insensitively
+// and with any port stripped, or the policy itself when there is none.
+func (rl *standardRateLimitPolicy) ForHost(host string) RateLimitPolicy {
+	hostname := host
+	if h, _, splitErr := net.SplitHostPort(host); splitErr == nil {
+		hostname = h
+	}
+	hostname = strings.ToLower(hostname)
+	for k, v := range rl.Hosts {
+		if v != nil && strings.ToLower(k) == hostname {
+			return v
+		}
+	}
+	return rl
+}
+
+func (rl *standardRateLimitPolicy) ToLimiterConfig() ratelimit.Config {
+	rv := ratelimit.Config{
+		RequestsPerSecond: rl.GetRequestsPerSecond(),
+		Burst:             rl.GetBurst(),
+		MaxConcurrency:    rl.GetMaxConcurrency(),
+	}
+	if adaptation, ok := rl.GetAdaptation(); ok {
+		rv.IsAdaptive = true
+		rv.RemainingHeader = adaptation.GetRemainingHeader()
+		rv.ResetHeader = adaptation.GetResetHeader()
+		rv.ResetFormat = adaptation.GetResetFormat()
+	}
+	return rv
+}
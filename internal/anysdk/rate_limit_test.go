@@ -0,0 +1,111 @@
+package anysdk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/ratelimit"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRateLimitPolicy_UnmarshalAndHostOverride(t *testing.T) {
+	raw := `
+rateLimit:
+  requests_per_second: 10
+  burst: 20
+  max_concurrency: 4
+  adaptive:
+    reset_format: epoch_seconds
+  hosts:
+    api.github.com:
+      requests_per_second: 1
+`
+	var cfg standardStackQLConfig
+	if err := yaml.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	rl, ok := cfg.GetRateLimitPolicy()
+	if !ok {
+		t.Fatalf("expected rateLimit block to be present")
+	}
+	top := rl.ToLimiterConfig()
+	want := ratelimit.Config{
+		RequestsPerSecond: 10,
+		Burst:             20,
+		MaxConcurrency:    4,
+		IsAdaptive:        true,
+		RemainingHeader:   ratelimit.DefaultRemainingHeader,
+		ResetHeader:       ratelimit.DefaultResetHeader,
+		ResetFormat:       ratelimit.ResetFormatEpochSeconds,
+	}
+	if top != want {
+		t.Fatalf("expected %+v, got %+v", want, top)
+	}
+	if got := rl.ForHost("API.GitHub.com:443").GetRequestsPerSecond(); got != 1 {
+		t.Fatalf("expected host override rate 1, got %v", got)
+	}
+	if got := rl.ForHost("example.com").GetRequestsPerSecond(); got != 10 {
+		t.Fatalf("expected top level rate 10 for unlisted host, got %v", got)
+	}
+	if _, adaptive := rl.ForHost("api.github.com").GetAdaptation(); adaptive {
+		t.Fatalf("expected host override to replace, not merge, the adaptive block")
+	}
+}
+
+func TestRateLimit_RetriesDrawFromTheBucket(t *testing.T) {
+	srv, h := newScriptedServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+	hc := newTestHttpClient()
+	policy := fastPolicy(3, []string{"GET"}, []int{http.StatusServiceUnavailable})
+	limiter := ratelimit.NewLimiter(ratelimit.Config{RequestsPerSecond: 20, Burst: 1})
+
+	start := time.Now()
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, limiter, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got := atomic.LoadInt64(&h.calls); got != 3 {
+		t.Fatalf("expected 3 calls, got %d", got)
+	}
+	// burst 1 at 20 rps: the second and third attempts each wait ~50ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected rate limiting to pace attempts, finished in %s", elapsed)
+	}
+}
+
+func TestRateLimit_AdaptivePauseFromResponseHeaders(t *testing.T) {
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(1))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	hc := newTestHttpClient()
+	limiter := ratelimit.NewLimiter(ratelimit.Config{IsAdaptive: true, ResetFormat: ratelimit.ResetFormatDeltaSeconds})
+
+	if _, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), fastPolicy(1, nil, nil), limiter, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now()
+	if _, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), fastPolicy(1, nil, nil), limiter, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("expected second call to wait for the advertised reset, waited %s", elapsed)
+	}
+}
+
+func TestResolveRateLimiter_NilWithoutOperationStore(t *testing.T) {
+	req := mustReq(t, http.MethodGet, "https://api.github.com/repos", "")
+	if l := resolveRateLimiter(nil, req); l != nil {
+		t.Fatalf("expected nil limiter for nil designation")
+	}
+}
@@ -0,0 +1,102 @@
+package anysdk
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+	"github.com/stackql/any-sdk/pkg/media"
+	"github.com/stackql/any-sdk/pkg/recordstream"
+)
+
+//nolint:gochecknoglobals // read-only table
+var recordFormats = map[string]string{
+	media.MediaTypeNDJson: recordstream.FormatNDJSON,
+	media.MediaTypeCSV:    recordstream.FormatCSV,
+	media.MediaTypeTSV:    recordstream.FormatTSV,
+	media.MediaTypeYAML:   recordstream.FormatYAML,
+}
+
+// NewRecordDecoder returns a streaming decoder for a line delimited or
+// tabular response body, with CSV and TSV columns typed by the row schema.
+// The bool is false when mediaType is not such a format.
+func NewRecordDecoder(body io.Reader, mediaType string, rowSchema Schema) (recordstream.Decoder, bool, error) {
+	recordMediaType, isRecord := media.GetRecordMediaType(mediaType)
+	if !isRecord {
+		return nil, false, nil
+	}
+	d, err := recordstream.NewDecoder(
+		recordFormats[recordMediaType],
+		body,
+		recordstream.Options{ColumnTypes: getRecordColumnTypes(rowSchema)},
+	)
+	return d, true, err
+}
+
+// unmarshalRecordBody decodes a line delimited or tabular body record by
+// record. The records form an array, except that a single YAML document is
+// returned as is, so that paths such as $.items apply to Kubernetes lists.
+func (s *standardSchema) unmarshalRecordBody(body io.Reader, path string, mediaType string) (interface{}, interface{}, error) {
+	d, _, err := NewRecordDecoder(body, mediaType, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := recordstream.ReadAll(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	var target interface{} = records
+	if recordMediaType, _ := media.GetRecordMediaType(mediaType); recordMediaType == media.MediaTypeYAML && len(records) == 1 {
+		target = records[0]
+	}
+	if isWholeRecordPath(path) {
+		return target, target, nil
+	}
+	processedResponse, err := jsonpath.Get(path, target)
+	if err != nil {
+		return nil, target, err
+	}
+	return processedResponse, target, nil
+}
+
+// isWholeRecordPath reports whether an objectKey selects the records
+// themselves rather than values within them.
+func isWholeRecordPath(path string) bool {
+	return path == "" || path == "$" || !strings.HasPrefix(path, "$")
+}
+
+// getRecordColumnTypes reads column types from the properties of the row
+// schema, or of its items when the schema describes the whole array.
+func getRecordColumnTypes(rowSchema Schema) map[string]string {
+	ss, ok := rowSchema.(*standardSchema)
+	if !ok || ss == nil || ss.Schema == nil {
+		return nil
+	}
+	properties := ss.Properties
+	if ss.Type == "array" && ss.Items != nil && ss.Items.Value != nil {
+		properties = ss.Items.Value.Properties
+	}
+	rv := make(map[string]string)
+	for k, prop := range properties {
+		if prop != nil && prop.Value != nil && prop.Value.Type != "" {
+			rv[k] = prop.Value.Type
+		}
+	}
+	return rv
+}
+
+// NewOperationRecordDecoder returns a streaming decoder for the body of a
+// response to op, typed by the operation's response schema. The media type
+// comes from the response, else from the operation.
+func NewOperationRecordDecoder(op OperationStore, r *http.Response) (recordstream.Decoder, bool, error) {
+	schema, mediaType, err := op.GetResponseBodySchemaAndMediaType()
+	if err != nil {
+		schema = nil
+	}
+	mediaType, err = media.GetResponseMediaType(r, mediaType)
+	if err != nil {
+		return nil, false, err
+	}
+	return NewRecordDecoder(r.Body, mediaType, schema)
+}
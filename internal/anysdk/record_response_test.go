@@ -0,0 +1,55 @@
+package anysdk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestUnmarshalRecordBody_TypedCSV(t *testing.T) {
+	row := openapi3.NewObjectSchema().
+		WithProperty("id", openapi3.NewIntegerSchema()).
+		WithProperty("bytes", openapi3.NewFloat64Schema()).
+		WithProperty("name", openapi3.NewStringSchema())
+	s := newSchema(openapi3.NewArraySchema().WithItems(row), nil, "", "").(*standardSchema)
+	processed, _, err := s.unmarshalReaderResponseAtPath(
+		strings.NewReader("id,name,bytes\n7,job-1,1.5e3\n"), "$", "text/csv; header=present", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := json.Marshal(processed)
+	if string(b) != `[{"bytes":1500,"id":7,"name":"job-1"}]` {
+		t.Fatalf("unexpected rows %s", b)
+	}
+}
+
+func TestUnmarshalRecordBody_NDJSONIsNotTreatedAsJSON(t *testing.T) {
+	s := newSchema(openapi3.NewArraySchema(), nil, "", "").(*standardSchema)
+	processed, _, err := s.unmarshalReaderResponseAtPath(
+		strings.NewReader("{\"a\":1}\n{\"a\":2}\n"), "$[*].a", "application/x-ndjson", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := json.Marshal(processed)
+	if string(b) != `[1,2]` {
+		t.Fatalf("unexpected rows %s", b)
+	}
+}
+
+func TestUnmarshalRecordBody_KubernetesYAMLList(t *testing.T) {
+	body := "apiVersion: v1\nkind: PodList\nitems:\n- metadata:\n    name: a\n- metadata:\n    name: b\n"
+	s := newSchema(openapi3.NewObjectSchema(), nil, "", "").(*standardSchema)
+	processed, raw, err := s.unmarshalReaderResponseAtPath(strings.NewReader(body), "$.items", "application/yaml", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := json.Marshal(processed)
+	if string(b) != `[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}}]` {
+		t.Fatalf("unexpected rows %s", b)
+	}
+	if rawMap, ok := raw.(map[string]interface{}); !ok || rawMap["kind"] != "PodList" {
+		t.Fatalf("expected the single document as the raw body, got %T", raw)
+	}
+}
@@ -37,6 +37,8 @@ type HTTPPreparator interface {
 	BuildHTTPRequestCtx(HTTPPreparatorConfig) (HTTPArmoury, error)
 	MergeParams(map[int]map[string]any) (HTTPPreparator, error)
 	WithPushdownIntent(intent PushdownIntent) HTTPPreparator
+	GetProvider() Provider
+	GetOperationStore() OperationStore
 }
 
 type standardHTTPPreparator struct {
@@ -119,6 +121,14 @@ func (pr *standardHTTPPreparator) WithPushdownIntent(intent PushdownIntent) HTTP
 	return rv
 }
 
+func (pr *standardHTTPPreparator) GetProvider() Provider {
+	return pr.prov
+}
+
+func (pr *standardHTTPPreparator) GetOperationStore() OperationStore {
+	return pr.m
+}
+
 func (pr *standardHTTPPreparator) MergeParams(maps map[int]map[string]any) (HTTPPreparator, error) {
 	rv := pr.clone()
 	if len(maps) > 1 {
@@ -215,19 +225,19 @@ func (pr *standardHTTPPreparator) buildHTTPRequestCtxInner(cfg HTTPPreparatorCon
 			}
 			pm.SetBodyBytes(b)
 			req, reqExists := pr.m.GetRequest() //nolint:govet // intentional shadowing
-			if reqExists {
+			if reqExists && !isMultipartRequest(req) {
 				pm.SetHeaderKV("Content-Type", []string{req.GetBodyMediaType()})
 			}
 		} else if len(method.getDefaultRequestBodyBytes()) > 0 {
 			pm.SetBodyBytes(method.getDefaultRequestBodyBytes())
 			req, reqExists := method.GetRequest() //nolint:govet // intentional shadowing
-			if reqExists {
+			if reqExists && !isMultipartRequest(req) {
 				pm.SetHeaderKV("Content-Type", []string{req.GetBodyMediaType()})
 			}
 		}
 		resp, respExists := method.GetResponse()
 		if respExists {
-			if resp.GetBodyMediaType() != "" && pr.prov.GetName() != "aws" {
+			if resp.GetBodyMediaType() != "" && ResolveAcceptHeaderPolicy(method) != AcceptHeaderPolicyOmit {
 				pm.SetHeaderKV("Accept", []string{resp.GetBodyMediaType()})
 			}
 		}
@@ -303,6 +313,9 @@ func getRequest(
 	if err != nil {
 		return nil, err
 	}
+	if err = validateRequest(method, httpParams); err != nil {
+		return nil, err
+	}
 	validationParams, err := method.parameterize(prov, svc, httpParams, httpParams.GetRequestBody())
 	if err != nil {
 		return nil, err
@@ -313,6 +326,7 @@ func getRequest(
 		return nil, err
 	}
 	ctx := awsContextHousekeeping(request.Context(), method, contextParams, params)
+	ctx = withAuditParameters(ctx, params)
 	request = request.WithContext(ctx)
 	return request, nil
 }
@@ -388,19 +402,19 @@ func (pr *standardHTTPPreparator) buildHTTPRequestCtxFromAnnotation(cfg HTTPPrep
 			}
 			pm.SetBodyBytes(b)
 			req, reqExists := pr.m.GetRequest() //nolint:govet // intentional
-			if reqExists {
+			if reqExists && !isMultipartRequest(req) {
 				pm.SetHeaderKV("Content-Type", []string{req.GetBodyMediaType()})
 			}
 		} else if len(httpMethod.getDefaultRequestBodyBytes()) > 0 {
 			pm.SetBodyBytes(httpMethod.getDefaultRequestBodyBytes())
 			req, reqExists := pr.m.GetRequest() //nolint:govet // intentional shadowing
-			if reqExists {
+			if reqExists && !isMultipartRequest(req) {
 				pm.SetHeaderKV("Content-Type", []string{req.GetBodyMediaType()})
 			}
 		}
 		resp, respExists := pr.m.GetResponse() //nolint:govet // intentional
 		if respExists {
-			if resp.GetBodyMediaType() != "" && pr.prov.GetName() != "aws" {
+			if resp.GetBodyMediaType() != "" && ResolveAcceptHeaderPolicy(pr.m) != AcceptHeaderPolicyOmit {
 				pm.SetHeaderKV("Accept", []string{resp.GetBodyMediaType()})
 			}
 		}
@@ -0,0 +1,141 @@
+package anysdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// RequestValidationOn checks parameters and body properties against
+	// their schemas before a request is sent. It is the default.
+	RequestValidationOn = "on"
+	// RequestValidationOff sends values unchecked, leaving validation to
+	// the server.
+	RequestValidationOff = "off"
+)
+
+// isRequestValidationEnabled reports whether requests for op are
+// validated: unless requestValidation is "off" anywhere in the operation's
+// inheritance chain.
+func isRequestValidationEnabled(op OperationStore) bool {
+	if op != nil {
+		if v, ok := op.GetRequestValidation(); ok {
+			return v != RequestValidationOff
+		}
+	}
+	return true
+}
+
+// validateRequest checks the parameter values and JSON body properties of
+// a request against the operation's parameter and request body schemas,
+// returning one error naming every offending parameter and the constraint
+// it breaks. Missing required values are left to parameterize.
+func validateRequest(method OperationStore, httpParams HttpParameters) error {
+	op, isStandard := method.(*standardOpenAPIOperationStore)
+	if !isStandard || !isRequestValidationEnabled(method) {
+		return nil
+	}
+	var messages []string
+	if op.OperationRef != nil && op.OperationRef.Value != nil {
+		for _, p := range op.OperationRef.Value.Parameters {
+			if p == nil || p.Value == nil || p.Value.Schema == nil || p.Value.Schema.Value == nil {
+				continue
+			}
+			binding, present := httpParams.GetParameter(p.Value.Name, p.Value.In)
+			if !present {
+				continue
+			}
+			schema := p.Value.Schema.Value
+			v := &schemaValidator{constraints: true}
+			v.validate(schema, coerceParameterValue(schema, binding.GetVal()), "")
+			for _, violation := range v.violations {
+				messages = append(messages, describeRequestViolation(
+					fmt.Sprintf("%s parameter '%s'", p.Value.In, p.Value.Name), violation))
+			}
+		}
+	}
+	if body := httpParams.GetRequestBody(); len(body) > 0 {
+		messages = append(messages, op.validateRequestBody(body)...)
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("request validation failed for method '%s': %s", op.GetName(), strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+func (op *standardOpenAPIOperationStore) validateRequestBody(body map[string]interface{}) []string {
+	req, reqExists := op.GetRequest()
+	if !reqExists || !isJSONMediaType(req.GetBodyMediaType()) {
+		return nil
+	}
+	requestSchema, err := op.getRequestBodySchema()
+	if err != nil {
+		return nil
+	}
+	ss, isStandard := requestSchema.(*standardSchema)
+	if !isStandard || ss == nil || ss.Schema == nil {
+		return nil
+	}
+	v := &schemaValidator{constraints: true, skipRequired: true}
+	v.validate(ss.Schema, map[string]interface{}(body), "")
+	messages := make([]string, 0, len(v.violations))
+	for _, violation := range v.violations {
+		messages = append(messages, describeRequestViolation("request body", violation))
+	}
+	return messages
+}
+
+func describeRequestViolation(subject string, violation ResponseViolation) string {
+	if violation.Pointer == "" {
+		return fmt.Sprintf("%s: %s", subject, violation.Message)
+	}
+	return fmt.Sprintf("%s at #%s: %s", subject, violation.Pointer, violation.Message)
+}
+
+// coerceParameterValue converts the string form of a parameter value to
+// the JSON type its schema declares, so that "10" is checked as the
+// integer 10. Values that do not convert are returned unchanged and fail
+// the type check.
+func coerceParameterValue(schema *openapi3.Schema, val interface{}) interface{} {
+	if schema == nil {
+		return val
+	}
+	switch vt := val.(type) {
+	case string:
+		switch schema.Type {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(vt, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(vt); err == nil {
+				return b
+			}
+		case "object":
+			var m map[string]interface{}
+			if json.Unmarshal([]byte(vt), &m) == nil {
+				return m
+			}
+		case "array":
+			var arr []interface{}
+			if json.Unmarshal([]byte(vt), &arr) == nil {
+				return coerceParameterValue(schema, arr)
+			}
+			return coerceParameterValue(schema, []interface{}{vt})
+		}
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return vt
+		}
+		rv := make([]interface{}, len(vt))
+		for i, item := range vt {
+			rv[i] = coerceParameterValue(schema.Items.Value, item)
+		}
+		return rv
+	}
+	return val
+}
@@ -0,0 +1,107 @@
+package anysdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func requestValidationParameters(
+	op *standardOpenAPIOperationStore,
+	values map[string]interface{},
+	body map[string]interface{},
+) HttpParameters {
+	hp := NewHttpParameters(op)
+	for _, p := range op.OperationRef.Value.Parameters {
+		if v, ok := values[p.Value.Name]; ok {
+			hp.StoreParameter(NewParameter(p.Value, nil), v)
+		}
+	}
+	for k, v := range body {
+		hp.SetRequestBodyParam(k, v)
+	}
+	return hp
+}
+
+func TestValidateRequest_Conforming(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	hp := requestValidationParameters(op,
+		map[string]interface{}{"project": "my-project", "maxResults": "100", "view": "FULL", "zones": `["us-east1", "us-west1"]`},
+		map[string]interface{}{"replicas": float64(3), "ports": []interface{}{float64(80), float64(443)}},
+	)
+	if err := validateRequest(op, hp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateRequest_NamesParameterAndConstraint(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	hp := requestValidationParameters(op,
+		map[string]interface{}{
+			"project":    "My_Project",
+			"maxResults": "5000",
+			"view":       "EVERYTHING",
+			"zones":      "a",
+			"If-Match":   `"0123456789"`,
+		},
+		map[string]interface{}{
+			"name":     "a-very-long-name",
+			"replicas": float64(0),
+			"id":       "nope",
+			"ports":    []interface{}{float64(80), float64(80), float64(70000)},
+		},
+	)
+	err := validateRequest(op, hp)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{
+		"request validation failed for method 'widgets/insert': ",
+		`path parameter 'project': value "My_Project" does not match pattern "^[a-z][a-z0-9-]{4,28}[a-z0-9]$"`,
+		"query parameter 'maxResults': value 5000 is greater than maximum 500",
+		`query parameter 'view': value "EVERYTHING" is not one of ["BASIC","FULL"]`,
+		`query parameter 'zones' at #/0: value "a" is shorter than minLength 3`,
+		`header parameter 'If-Match': value "\"0123456789\"" is longer than maxLength 8`,
+		`request body at #/name: value "a-very-long-name" is longer than maxLength 10`,
+		"request body at #/replicas: value 0 must be greater than 0",
+		`request body at #/id: value "nope" is not a valid uuid`,
+		"request body at #/ports: items 0 and 1 are equal",
+		"request body at #/ports/2: value 70000 is greater than maximum 65535",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error %q", want, err.Error())
+		}
+	}
+}
+
+func TestValidateRequest_TypeMismatch(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	hp := requestValidationParameters(op, map[string]interface{}{"maxResults": "lots"}, nil)
+	err := validateRequest(op, hp)
+	if err == nil || !strings.Contains(err.Error(), "query parameter 'maxResults': expected integer, got string") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestValidateRequest_DisabledPerProvider(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "insert_widget")
+	op.Provider = &standardProvider{StackQLConfig: &standardStackQLConfig{RequestValidation: "off"}}
+	hp := requestValidationParameters(op, map[string]interface{}{"maxResults": "5000"}, nil)
+	if err := validateRequest(op, hp); err != nil {
+		t.Fatalf("expected validation to be disabled, got %v", err)
+	}
+	op.StackQLConfig = &standardStackQLConfig{RequestValidation: "on"}
+	if err := validateRequest(op, hp); err == nil {
+		t.Fatalf("expected the method level setting to win over the provider")
+	}
+}
+
+func TestValidateRequest_DisabledByDocument(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "import_widget")
+	if isRequestValidationEnabled(op) {
+		t.Fatalf("expected the documented requestValidation: off to disable validation")
+	}
+	hp := requestValidationParameters(op, map[string]interface{}{"project": "My_Project"}, nil)
+	if err := validateRequest(op, hp); err != nil {
+		t.Fatalf("expected validation to be disabled, got %v", err)
+	}
+}
@@ -31,6 +31,7 @@ type Resource interface {
 	GetPaginationResponseTerminatorTokenSemantic() (TokenSemantic, bool)
 	GetQueryParamPushdown() (QueryParamPushdown, bool)
 	GetRetryPolicy() (RetryPolicy, bool)
+	GetUpsertPlan(parameters map[string]interface{}) (UpsertPlan, error)
 	FindMethod(key string) (StandardOperationStore, error)
 	GetFirstMethodFromSQLVerb(sqlVerb string) (StandardOperationStore, string, bool)
 	GetFirstNamespaceMethodMatchFromSQLVerb(sqlVerb string, parameters map[string]interface{}) (StandardOperationStore, map[string]interface{}, bool)
@@ -41,6 +42,7 @@ type Resource interface {
 	ToMap(extended bool) map[string]interface{}
 	// unexported mutators
 	getSQLVerbs() map[string][]OpenAPIOperationStoreRef
+	getStackQLConfig() (StackQLConfig, bool)
 	setProvider(p Provider)
 	setService(s OpenAPIService)
 	SetProvider(p Provider)
@@ -156,6 +158,10 @@ func (r *standardResource) GetSelectorAlgorithm() string {
 	return r.SelectorAlgorithm
 }
 
+func (r *standardResource) getStackQLConfig() (StackQLConfig, bool) {
+	return r.StackQLConfig, r.StackQLConfig != nil
+}
+
 func (r *standardResource) GetMethods() Methods {
 	return r.Methods
 }
@@ -239,6 +245,12 @@ func (r *standardResource) GetRetryPolicy() (RetryPolicy, bool) {
 	return nil, false
 }
 
+// GetUpsertPlan resolves how to create or update the resource addressed
+// by parameters; see UpsertPlan.
+func (r *standardResource) GetUpsertPlan(parameters map[string]interface{}) (UpsertPlan, error) {
+	return r.getUpsertPlan(parameters)
+}
+
 func (rsc standardResource) JSONLookup(token string) (interface{}, error) {
 	ss := strings.Split(token, "/")
 	tokenRoot := ""
@@ -384,6 +396,8 @@ func (rs *standardResource) getDefaultMethodKeysForSQLVerb(sqlVerb string) []str
 		return []string{"delete"}
 	case "select":
 		return []string{"select", "list", "aggregatedList", "get"}
+	case "upsert":
+		return []string{"upsert", "createOrUpdate"}
 	default:
 		return []string{}
 	}
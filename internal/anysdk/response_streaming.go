@@ -0,0 +1,188 @@
+package anysdk
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/jsonstream"
+	"github.com/stackql/any-sdk/pkg/media"
+	"github.com/stackql/any-sdk/pkg/recordstream"
+)
+
+var (
+	_ ResponseStreamingPolicy   = &standardResponseStreamingPolicy{}
+	_ jsonpointer.JSONPointable = standardResponseStreamingPolicy{}
+	_ ResponseRowStreamer       = &jsonstream.Streamer{}
+	_ ResponseRowStreamer       = &recordRowStreamer{}
+)
+
+const (
+	DefaultResponseStreamingBatchRows = 500
+)
+
+// ResponseStreamingPolicy governs how large JSON list responses are read:
+// rows at the objectKey are decoded one at a time and emitted in batches,
+// and response bodies may be capped in size.
+type ResponseStreamingPolicy interface {
+	IsEnabled() bool
+	GetBatchRows() int
+	GetMaxResponseBytes() int64
+}
+
+type standardResponseStreamingPolicy struct {
+	Enabled          *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	BatchRows        int   `json:"batch_rows,omitempty" yaml:"batch_rows,omitempty"`
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty" yaml:"max_response_bytes,omitempty"`
+}
+
+func (sp standardResponseStreamingPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "enabled":
+		return sp.Enabled, nil
+	case "batch_rows":
+		return sp.BatchRows, nil
+	case "max_response_bytes":
+		return sp.MaxResponseBytes, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from ResponseStreamingPolicy doc object", token)
+	}
+}
+
+// IsEnabled defaults to true: declaring the block opts in to streaming.
+// Setting enabled to false keeps only the size limit.
+func (sp *standardResponseStreamingPolicy) IsEnabled() bool {
+	return sp.Enabled == nil || *sp.Enabled
+}
+
+func (sp *standardResponseStreamingPolicy) GetBatchRows() int {
+	if sp.BatchRows <= 0 {
+		return DefaultResponseStreamingBatchRows
+	}
+	return sp.BatchRows
+}
+
+func (sp *standardResponseStreamingPolicy) GetMaxResponseBytes() int64 {
+	if sp.MaxResponseBytes < 0 {
+		return 0
+	}
+	return sp.MaxResponseBytes
+}
+
+// limitResponseBody caps the response body at the operation's
+// max_response_bytes, if any.
+func limitResponseBody(op OperationStore, resp *http.Response) {
+	policy, hasPolicy := op.GetResponseStreamingPolicy()
+	if !hasPolicy || resp == nil || resp.Body == nil || policy.GetMaxResponseBytes() <= 0 {
+		return
+	}
+	resp.Body = &limitedReadCloser{
+		Reader: jsonstream.LimitReader(resp.Body, policy.GetMaxResponseBytes()),
+		closer: resp.Body,
+	}
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.closer.Close()
+}
+
+// ResponseRowStreamer yields the rows of a response one at a time. Next
+// returns io.EOF after the last row. Found is false when the response holds
+// no array of rows, in which case Remainder is the whole document; else
+// Remainder is the document without its rows.
+type ResponseRowStreamer interface {
+	Next() (interface{}, error)
+	Found() bool
+	Remainder() interface{}
+	GetCount() int
+}
+
+// recordRowStreamer yields the records of a line delimited or tabular
+// body, which are the rows themselves.
+type recordRowStreamer struct {
+	decoder recordstream.Decoder
+	count   int
+}
+
+func (rs *recordRowStreamer) Next() (interface{}, error) {
+	rec, err := rs.decoder.Next()
+	if err == nil {
+		rs.count++
+	}
+	return rec, err
+}
+
+func (rs *recordRowStreamer) Found() bool {
+	return true
+}
+
+func (rs *recordRowStreamer) Remainder() interface{} {
+	return []interface{}{}
+}
+
+func (rs *recordRowStreamer) GetCount() int {
+	return rs.count
+}
+
+// NewResponseRowStreamer returns a streamer over the rows of a successful
+// response to op. NDJSON, CSV and TSV bodies are streamed record by record
+// unless a responseStreaming policy is disabled. JSON bodies are streamed
+// from the operation's objectKey when a responseStreaming policy is
+// enabled. The bool is false when the response must be processed whole
+// instead: a non-JSON, YAML or error response, a response transform,
+// binary mode or inverse, or an objectKey that is not a plain chain of keys
+// for JSON, or that selects within the records for the record formats.
+func NewResponseRowStreamer(op OperationStore, resp *http.Response) (ResponseRowStreamer, bool) {
+	policy, hasPolicy := op.GetResponseStreamingPolicy()
+	if (hasPolicy && !policy.IsEnabled()) || resp == nil || resp.Body == nil {
+		return nil, false
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false
+	}
+	standardOp, isStandard := op.(*standardOpenAPIOperationStore)
+	if !isStandard || standardOp.isOverridable(resp) {
+		return nil, false
+	}
+	if _, isBinary := standardOp.getBinaryResponse(); isBinary {
+		return nil, false
+	}
+	if _, hasInverse := op.GetInverse(); hasInverse {
+		return nil, false
+	}
+	schema, mediaType, err := op.GetResponseBodySchemaAndMediaType()
+	if err != nil {
+		return nil, false
+	}
+	if standardOp.Response != nil && standardOp.Response.OverrideBodyMediaType != "" {
+		mediaType = standardOp.Response.OverrideBodyMediaType
+	} else if mediaType, err = media.GetResponseMediaType(resp, mediaType); err != nil {
+		return nil, false
+	}
+	if recordMediaType, isRecord := media.GetRecordMediaType(mediaType); isRecord {
+		if recordMediaType == media.MediaTypeYAML || !isWholeRecordPath(standardOp.lookupSelectItemsKey()) {
+			return nil, false
+		}
+		limitResponseBody(op, resp)
+		decoder, _, decoderErr := NewRecordDecoder(resp.Body, mediaType, schema)
+		if decoderErr != nil {
+			return nil, false
+		}
+		return &recordRowStreamer{decoder: decoder}, true
+	}
+	if !hasPolicy || media.NormaliseMediaType(mediaType) != media.MediaTypeJson {
+		return nil, false
+	}
+	limitResponseBody(op, resp)
+	streamer, err := jsonstream.NewStreamer(resp.Body, standardOp.lookupSelectItemsKey())
+	if err != nil {
+		return nil, false
+	}
+	return streamer, true
+}
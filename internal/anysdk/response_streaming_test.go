@@ -0,0 +1,139 @@
+package anysdk
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/jsonstream"
+)
+
+func withResponseStreaming(op *standardOpenAPIOperationStore, policy *standardResponseStreamingPolicy) *standardOpenAPIOperationStore {
+	op.StackQLConfig = &standardStackQLConfig{ResponseStreaming: policy}
+	return op
+}
+
+func jsonResponse(status int, contentType string, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestNewResponseRowStreamer_Eligibility(t *testing.T) {
+	disabled := false
+	cases := []struct {
+		name   string
+		method string
+		policy *standardResponseStreamingPolicy
+		resp   *http.Response
+		expect bool
+	}{
+		{"declared", "list_widgets", &standardResponseStreamingPolicy{}, jsonResponse(200, "application/json; charset=utf-8", `{}`), true},
+		{"no policy", "list_widgets", nil, jsonResponse(200, "application/json", `{}`), false},
+		{"disabled", "list_widgets", &standardResponseStreamingPolicy{Enabled: &disabled}, jsonResponse(200, "application/json", `{}`), false},
+		{"error status", "list_widgets", &standardResponseStreamingPolicy{}, jsonResponse(404, "application/json", `{}`), false},
+		{"ndjson within records", "list_widgets", &standardResponseStreamingPolicy{}, jsonResponse(200, "application/x-ndjson", `{}`), false},
+		{"ndjson", "export_widgets", nil, jsonResponse(200, "application/x-ndjson", `{}`), true},
+		{"csv declared", "export_widgets", &standardResponseStreamingPolicy{}, jsonResponse(200, "text/csv", "id\n1\n"), true},
+		{"csv disabled", "export_widgets", &standardResponseStreamingPolicy{Enabled: &disabled}, jsonResponse(200, "text/csv", "id\n1\n"), false},
+		{"yaml", "export_widgets", nil, jsonResponse(200, "application/yaml", `items: []`), false},
+		{"xml", "list_widgets", &standardResponseStreamingPolicy{}, jsonResponse(200, "application/xml", `<a/>`), false},
+		{"binary", "download_widget", &standardResponseStreamingPolicy{}, jsonResponse(200, "application/octet-stream", "x"), false},
+	}
+	for _, c := range cases {
+		_, ok := NewResponseRowStreamer(withResponseStreaming(mustLoadWidgetsMethod(t, c.method), c.policy), c.resp)
+		if ok != c.expect {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.expect, ok)
+		}
+	}
+}
+
+func TestNewResponseRowStreamer_AppliesSizeLimit(t *testing.T) {
+	op := withResponseStreaming(mustLoadWidgetsMethod(t, "list_widgets"), &standardResponseStreamingPolicy{MaxResponseBytes: 16})
+	streamer, ok := NewResponseRowStreamer(op, jsonResponse(200, "application/json", `{"items":[{"id":1},{"id":2},{"id":3}]}`))
+	if !ok {
+		t.Fatalf("expected a streamer")
+	}
+	var err error
+	for err == nil {
+		_, err = streamer.Next()
+	}
+	if !errors.Is(err, jsonstream.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestNewResponseRowStreamer_StreamsRecords(t *testing.T) {
+	streamer, ok := NewResponseRowStreamer(mustLoadWidgetsMethod(t, "export_widgets"), jsonResponse(200, "text/csv", "id\n1\n2\n"))
+	if !ok {
+		t.Fatalf("expected a streamer")
+	}
+	var rows []interface{}
+	for {
+		row, err := streamer.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 2 || streamer.GetCount() != 2 || !streamer.Found() {
+		t.Fatalf("expected two rows, got %v", rows)
+	}
+	if id := rows[1].(map[string]interface{})["id"]; id != int64(2) {
+		t.Fatalf("expected typed id 2, got %#v", id)
+	}
+}
+
+func TestProcessResponse_AppliesSizeLimit(t *testing.T) {
+	op := withResponseStreaming(mustLoadWidgetsMethod(t, "list_widgets"), &standardResponseStreamingPolicy{MaxResponseBytes: 8})
+	_, err := op.ProcessResponse(jsonResponse(200, "application/json", `{"items":[{"id":1}]}`))
+	if !errors.Is(err, jsonstream.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestNewResponseRowStreamer_DeclaredByDocument(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	policy, hasPolicy := op.GetResponseStreamingPolicy()
+	if !hasPolicy || policy.GetBatchRows() != 100 {
+		t.Fatalf("expected the documented policy, got %v", policy)
+	}
+	body := `{"items":[{"id":"0b8f3d9e-4c4b-4e5a-9f0e-1a2b3c4d5e6f","status":"RUNNING"},{"id":"x","status":"STOPPED"}],"nextPageToken":"p2"}`
+	streamer, ok := NewResponseRowStreamer(op, jsonResponse(200, "application/json", body))
+	if !ok {
+		t.Fatalf("expected a streamer")
+	}
+	var rows []interface{}
+	for {
+		row, err := streamer.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	violations := ValidateStreamedRows(op, rows, 0)
+	if len(rows) != 2 || len(violations) != 1 || violations[0].Pointer != "/items/1/id" {
+		t.Fatalf("expected two rows checked against the documented item schema, got %v and %v", rows, violations)
+	}
+	if violations = ValidateStreamedRemainder(op, streamer.Remainder()); len(violations) != 0 {
+		t.Fatalf("unexpected violations in the remainder %v", violations)
+	}
+}
+
+func TestStackQLConfig_JSONLookupResponseStreaming(t *testing.T) {
+	policy := &standardResponseStreamingPolicy{BatchRows: 10}
+	got, err := standardStackQLConfig{ResponseStreaming: policy}.JSONLookup("responseStreaming")
+	if err != nil || got != policy {
+		t.Fatalf("expected the responseStreaming policy, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,481 @@
+package anysdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stackql/any-sdk/pkg/jsonstream"
+)
+
+const (
+	// ResponseValidationOff skips response validation. It is the default.
+	ResponseValidationOff = "off"
+	// ResponseValidationWarn reports violations and carries on.
+	ResponseValidationWarn = "warn"
+	// ResponseValidationError fails the call on any violation.
+	ResponseValidationError = "error"
+)
+
+var (
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`) //nolint:gochecknoglobals // compiled once
+)
+
+// ResponseViolation is one way a response body departs from the
+// operation's response schema.
+type ResponseViolation struct {
+	// Pointer is the RFC 6901 JSON pointer to the offending value; empty
+	// for the whole body.
+	Pointer string
+	// Keyword is the schema keyword violated, such as type, required, enum
+	// or format.
+	Keyword string
+	Message string
+}
+
+func (v ResponseViolation) String() string {
+	return fmt.Sprintf("#%s: %s", v.Pointer, v.Message)
+}
+
+// ValidateResponseBody checks a decoded response body against the
+// operation's response schema. Only JSON bodies are checked; binary and
+// other bodies yield no violations.
+func (op *standardOpenAPIOperationStore) ValidateResponseBody(body interface{}) []ResponseViolation {
+	schema, ok := getResponseValidationSchema(op)
+	if !ok {
+		return nil
+	}
+	v := &schemaValidator{}
+	v.validate(schema, body, "")
+	return v.violations
+}
+
+// ValidateStreamedRows checks rows streamed from a response to op, see
+// NewResponseRowStreamer, against the items schema of the array at the
+// operation's objectKey. Rows are numbered from offset, as in the whole
+// body, so that pointers match those of ValidateResponseBody.
+func ValidateStreamedRows(op OperationStore, rows []interface{}, offset int) []ResponseViolation {
+	arraySchema, pointer, _, ok := getStreamedArraySchema(op)
+	if !ok || arraySchema.Items == nil || arraySchema.Items.Value == nil {
+		return nil
+	}
+	v := &schemaValidator{}
+	for i, row := range rows {
+		v.validate(arraySchema.Items.Value, row, fmt.Sprintf("%s/%d", pointer, offset+i))
+	}
+	return v.violations
+}
+
+// ValidateStreamedRemainder checks what is left of a streamed response to
+// op once its rows are read against the response schema. The streamed
+// array counts as present and is not checked again.
+func ValidateStreamedRemainder(op OperationStore, remainder interface{}) []ResponseViolation {
+	_, pointer, keys, ok := getStreamedArraySchema(op)
+	if !ok || len(keys) == 0 {
+		return nil
+	}
+	ss, _ := getResponseValidationSchema(op)
+	v := &schemaValidator{skipPointer: pointer}
+	v.validate(ss, restoreStreamedArray(remainder, keys), "")
+	return v.violations
+}
+
+// getResponseValidationSchema returns the schema ValidateResponseBody
+// checks a response to op against, if any.
+func getResponseValidationSchema(op OperationStore) (*openapi3.Schema, bool) {
+	standardOp, isStandard := op.(*standardOpenAPIOperationStore)
+	if !isStandard {
+		return nil, false
+	}
+	if _, isBinary := standardOp.getBinaryResponse(); isBinary {
+		return nil, false
+	}
+	responseSchema, mediaType, err := standardOp.getResponseBodySchemaAndMediaType()
+	if err != nil || !isJSONMediaType(mediaType) {
+		return nil, false
+	}
+	ss, isStandardSchema := responseSchema.(*standardSchema)
+	if !isStandardSchema || ss == nil || ss.Schema == nil {
+		return nil, false
+	}
+	return ss.Schema, true
+}
+
+// getStreamedArraySchema follows the operation's objectKey through the
+// response schema to the schema of the streamed array, returning it with
+// its JSON pointer and the keys leading to it.
+func getStreamedArraySchema(op OperationStore) (*openapi3.Schema, string, []string, bool) {
+	schema, ok := getResponseValidationSchema(op)
+	if !ok {
+		return nil, "", nil, false
+	}
+	keys, isPlain := jsonstream.ParsePath(op.(*standardOpenAPIOperationStore).lookupSelectItemsKey())
+	if !isPlain {
+		return nil, "", nil, false
+	}
+	pointer := ""
+	for _, key := range keys {
+		prop, hasProp := schema.Properties[key]
+		if !hasProp || prop == nil || prop.Value == nil {
+			return nil, "", nil, false
+		}
+		schema = prop.Value
+		pointer += "/" + escapeJSONPointerToken(key)
+	}
+	return schema, pointer, keys, true
+}
+
+// restoreStreamedArray copies the objects along keys in remainder and puts
+// a placeholder back where the streamed array was.
+func restoreStreamedArray(remainder interface{}, keys []string) interface{} {
+	obj, isObj := remainder.(map[string]interface{})
+	if !isObj {
+		return remainder
+	}
+	rv := make(map[string]interface{}, len(obj)+1)
+	for k, val := range obj {
+		rv[k] = val
+	}
+	if len(keys) == 1 {
+		rv[keys[0]] = nil
+		return rv
+	}
+	rv[keys[0]] = restoreStreamedArray(obj[keys[0]], keys[1:])
+	return rv
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "" || strings.Contains(strings.ToLower(mediaType), "json")
+}
+
+// schemaValidator collects the ways a decoded JSON value departs from a
+// schema. Response validation checks shape only; request validation also
+// checks value constraints, and leaves required properties to the
+// required parameter checks.
+type schemaValidator struct {
+	violations   []ResponseViolation
+	constraints  bool
+	skipRequired bool
+	// skipPointer names a value not to check, such as a streamed array.
+	skipPointer string
+}
+
+func (v *schemaValidator) add(pointer, keyword, format string, args ...interface{}) {
+	v.violations = append(v.violations, ResponseViolation{
+		Pointer: pointer,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *schemaValidator) validate(schema *openapi3.Schema, value interface{}, pointer string) {
+	if schema == nil || (v.skipPointer != "" && pointer == v.skipPointer) {
+		return
+	}
+	for _, sub := range schema.AllOf {
+		if sub != nil {
+			v.validate(sub.Value, value, pointer)
+		}
+	}
+	v.validateAlternatives("anyOf", schema.AnyOf, value, pointer)
+	v.validateAlternatives("oneOf", schema.OneOf, value, pointer)
+	if value == nil {
+		if schema.Type != "" && !schema.Nullable {
+			v.add(pointer, "type", "expected %s, got null", schema.Type)
+		}
+		return
+	}
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		v.add(pointer, "enum", "value %s is not one of %s", describeValue(value), describeValue(schema.Enum))
+	}
+	switch t := value.(type) {
+	case map[string]interface{}:
+		if !v.checkType(schema, "object", pointer) {
+			return
+		}
+		v.validateObject(schema, t, pointer)
+	case []interface{}:
+		if !v.checkType(schema, "array", pointer) {
+			return
+		}
+		v.validateArrayConstraints(schema, t, pointer)
+		for i, item := range t {
+			if schema.Items != nil {
+				v.validate(schema.Items.Value, item, fmt.Sprintf("%s/%d", pointer, i))
+			}
+		}
+	case []map[string]interface{}:
+		if !v.checkType(schema, "array", pointer) {
+			return
+		}
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = item
+		}
+		v.validateArrayConstraints(schema, items, pointer)
+		for i, item := range t {
+			if schema.Items != nil {
+				v.validate(schema.Items.Value, item, fmt.Sprintf("%s/%d", pointer, i))
+			}
+		}
+	case string:
+		if v.checkType(schema, "string", pointer) {
+			v.validateStringFormat(schema.Format, t, pointer)
+			v.validateStringConstraints(schema, t, pointer)
+		}
+	case bool:
+		v.checkType(schema, "boolean", pointer)
+	default:
+		f, isNumber := toFloat(value)
+		if !isNumber {
+			return
+		}
+		if schema.Type == "integer" && f != math.Trunc(f) {
+			v.add(pointer, "type", "expected integer, got %s", describeValue(value))
+			return
+		}
+		if schema.Type != "integer" && !v.checkType(schema, "number", pointer) {
+			return
+		}
+		v.validateNumberFormat(schema.Format, f, pointer)
+		v.validateNumberConstraints(schema, f, pointer)
+	}
+}
+
+// checkType reports whether a value of JSON type actual satisfies the
+// schema's type, adding a violation when it does not.
+func (v *schemaValidator) checkType(schema *openapi3.Schema, actual string, pointer string) bool {
+	if schema.Type == "" || schema.Type == actual || (schema.Type == "number" && actual == "integer") {
+		return true
+	}
+	v.add(pointer, "type", "expected %s, got %s", schema.Type, actual)
+	return false
+}
+
+func (v *schemaValidator) validateObject(schema *openapi3.Schema, obj map[string]interface{}, pointer string) {
+	if !v.skipRequired {
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				v.add(pointer, "required", "missing required property '%s'", name)
+			}
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		childPointer := pointer + "/" + escapeJSONPointerToken(k)
+		if prop, ok := schema.Properties[k]; ok {
+			if prop != nil {
+				v.validate(prop.Value, obj[k], childPointer)
+			}
+			continue
+		}
+		if schema.AdditionalProperties != nil {
+			v.validate(schema.AdditionalProperties.Value, obj[k], childPointer)
+		}
+	}
+}
+
+// validateAlternatives requires value to satisfy at least one of alts. A
+// oneOf matching several alternatives is tolerated, since response
+// schemas commonly use oneOf for overlapping shapes.
+func (v *schemaValidator) validateAlternatives(keyword string, alts openapi3.SchemaRefs, value interface{}, pointer string) {
+	if len(alts) == 0 {
+		return
+	}
+	for _, alt := range alts {
+		if alt == nil || alt.Value == nil {
+			return
+		}
+		probe := &schemaValidator{constraints: v.constraints, skipRequired: v.skipRequired, skipPointer: v.skipPointer}
+		probe.validate(alt.Value, value, pointer)
+		if len(probe.violations) == 0 {
+			return
+		}
+	}
+	v.add(pointer, keyword, "value matches none of the %d %s schemas", len(alts), keyword)
+}
+
+func (v *schemaValidator) validateStringFormat(format string, s string, pointer string) {
+	var ok bool
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		ok = err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		ok = err == nil
+	case "uuid":
+		ok = uuidRegexp.MatchString(s)
+	case "email":
+		_, err := mail.ParseAddress(s)
+		ok = err == nil
+	case "ipv4":
+		ip := net.ParseIP(s)
+		ok = ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(s)
+		ok = ip != nil && ip.To4() == nil
+	case "uri":
+		u, err := url.Parse(s)
+		ok = err == nil && u.Scheme != ""
+	case "byte":
+		_, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			_, err = base64.URLEncoding.DecodeString(s)
+		}
+		ok = err == nil
+	case "int32", "int64":
+		// Some providers send 64 bit integers as strings, which is valid.
+		_, err := json.Number(s).Int64()
+		ok = err == nil
+	default:
+		return
+	}
+	if !ok {
+		v.add(pointer, "format", "value %s is not a valid %s", describeValue(s), format)
+	}
+}
+
+func (v *schemaValidator) validateNumberFormat(format string, f float64, pointer string) {
+	switch format {
+	case "int32":
+		if f != math.Trunc(f) || f < math.MinInt32 || f > math.MaxInt32 {
+			v.add(pointer, "format", "value %v is not a valid int32", f)
+		}
+	case "int64":
+		if f != math.Trunc(f) {
+			v.add(pointer, "format", "value %v is not a valid int64", f)
+		}
+	}
+}
+
+func (v *schemaValidator) validateStringConstraints(schema *openapi3.Schema, s string, pointer string) {
+	if !v.constraints {
+		return
+	}
+	length := uint64(utf8.RuneCountInString(s))
+	if length < schema.MinLength {
+		v.add(pointer, "minLength", "value %s is shorter than minLength %d", describeValue(s), schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.add(pointer, "maxLength", "value %s is longer than maxLength %d", describeValue(s), *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		// Patterns Go cannot compile, such as those using lookaround, are
+		// left to the server.
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+			v.add(pointer, "pattern", "value %s does not match pattern %s", describeValue(s), describeValue(schema.Pattern))
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumberConstraints(schema *openapi3.Schema, f float64, pointer string) {
+	if !v.constraints {
+		return
+	}
+	if schema.Min != nil {
+		if schema.ExclusiveMin && f <= *schema.Min {
+			v.add(pointer, "minimum", "value %v must be greater than %v", f, *schema.Min)
+		} else if f < *schema.Min {
+			v.add(pointer, "minimum", "value %v is less than minimum %v", f, *schema.Min)
+		}
+	}
+	if schema.Max != nil {
+		if schema.ExclusiveMax && f >= *schema.Max {
+			v.add(pointer, "maximum", "value %v must be less than %v", f, *schema.Max)
+		} else if f > *schema.Max {
+			v.add(pointer, "maximum", "value %v is greater than maximum %v", f, *schema.Max)
+		}
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		if q := f / *schema.MultipleOf; q != math.Trunc(q) {
+			v.add(pointer, "multipleOf", "value %v is not a multiple of %v", f, *schema.MultipleOf)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArrayConstraints(schema *openapi3.Schema, items []interface{}, pointer string) {
+	if !v.constraints {
+		return
+	}
+	count := uint64(len(items))
+	if count < schema.MinItems {
+		v.add(pointer, "minItems", "array has %d items, fewer than minItems %d", count, schema.MinItems)
+	}
+	if schema.MaxItems != nil && count > *schema.MaxItems {
+		v.add(pointer, "maxItems", "array has %d items, more than maxItems %d", count, *schema.MaxItems)
+	}
+	if schema.UniqueItems {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					v.add(pointer, "uniqueItems", "items %d and %d are equal", j, i)
+					return
+				}
+			}
+		}
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() { //nolint:exhaustive // only numeric kinds matter
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	f, isNumber := toFloat(value)
+	for _, candidate := range enum {
+		if isNumber {
+			if cf, candidateIsNumber := toFloat(candidate); candidateIsNumber && cf == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeValue(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+func escapeJSONPointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
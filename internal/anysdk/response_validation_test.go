@@ -0,0 +1,105 @@
+package anysdk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	return v
+}
+
+func TestValidateResponseBody_Conforming(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	body := decodeJSON(t, `{"items": [{"id": "0b8f3d9e-4c4b-4e5a-9f0e-1a2b3c4d5e6f", "status": "RUNNING",
+		"created": "2026-10-19T09:30:00Z", "size": 10, "labels": {"env": "prod"}, "extra": 1}], "nextPageToken": null}`)
+	if violations := op.ValidateResponseBody(body); len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+}
+
+func TestValidateResponseBody_ReportsPointers(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	body := decodeJSON(t, `{"items": [
+		{"id": "0b8f3d9e-4c4b-4e5a-9f0e-1a2b3c4d5e6f", "status": "RUNNING"},
+		{"id": "not-a-uuid", "status": "DELETED", "created": "yesterday", "size": 1.5,
+		 "labels": {"env": 3}, "a/b": "yes"},
+		{"status": 7}
+	]}`)
+	got := map[string]string{}
+	for _, v := range op.ValidateResponseBody(body) {
+		got[v.String()] = v.Keyword
+	}
+	want := map[string]string{
+		`#/items/1/id: value "not-a-uuid" is not a valid uuid`:                  "format",
+		`#/items/1/status: value "DELETED" is not one of ["RUNNING","STOPPED"]`: "enum",
+		`#/items/1/created: value "yesterday" is not a valid date-time`:         "format",
+		`#/items/1/size: expected integer, got 1.5`:                             "type",
+		`#/items/1/labels/env: expected string, got number`:                     "type",
+		`#/items/1/a~1b: expected boolean, got string`:                          "type",
+		`#/items/2: missing required property 'id'`:                             "required",
+		`#/items/2/status: value 7 is not one of ["RUNNING","STOPPED"]`:         "enum",
+		`#/items/2/status: expected string, got number`:                         "type",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d violations, got %d: %v", len(want), len(got), got)
+	}
+	for msg, keyword := range want {
+		if got[msg] != keyword {
+			t.Errorf("missing violation %q (%s) in %v", msg, keyword, got)
+		}
+	}
+}
+
+func TestValidateResponseBody_ProcessedResponse(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"items": "oops"}`)),
+	}
+	processed, err := op.ProcessResponse(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, _ := processed.GetResponse()
+	violations := op.ValidateResponseBody(r.GetBody())
+	if len(violations) != 1 || violations[0].Pointer != "/items" || violations[0].Keyword != "type" {
+		t.Fatalf("unexpected violations %v", violations)
+	}
+}
+
+func TestValidateStreamed_RowsAndRemainder(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	rows := []interface{}{
+		decodeJSON(t, `{"id": "0b8f3d9e-4c4b-4e5a-9f0e-1a2b3c4d5e6f", "status": "RUNNING"}`),
+		decodeJSON(t, `{"id": "0b8f3d9e-4c4b-4e5a-9f0e-1a2b3c4d5e6f", "status": "DELETED"}`),
+	}
+	violations := ValidateStreamedRows(op, rows, 500)
+	if len(violations) != 1 || violations[0].Pointer != "/items/501/status" || violations[0].Keyword != "enum" {
+		t.Fatalf("expected an enum violation numbered from the offset, got %v", violations)
+	}
+	if violations = ValidateStreamedRemainder(op, decodeJSON(t, `{"nextPageToken": null}`)); len(violations) != 0 {
+		t.Fatalf("expected the streamed array to count as present, got %v", violations)
+	}
+	violations = ValidateStreamedRemainder(op, decodeJSON(t, `{"nextPageToken": 7}`))
+	if len(violations) != 1 || violations[0].Pointer != "/nextPageToken" {
+		t.Fatalf("expected a violation in the remainder, got %v", violations)
+	}
+}
+
+func TestValidateResponseBody_SkipsNonJSON(t *testing.T) {
+	op := mustLoadWidgetsMethod(t, "list_widgets")
+	op.Response.BodyMediaType = "application/xml"
+	if violations := op.ValidateResponseBody(decodeJSON(t, `{"items": "oops"}`)); violations != nil {
+		t.Fatalf("expected xml bodies to be skipped, got %v", violations)
+	}
+}
@@ -1,17 +1,29 @@
 package anysdk
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/antchfx/xmlquery"
 	"github.com/go-openapi/jsonpointer"
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+	"github.com/stackql/any-sdk/pkg/ratelimit"
 )
 
 const (
-	RetryAlgorithmExponential = "exponential"
+	RetryAlgorithmExponential        = "exponential"
+	RetryAlgorithmLinear             = "linear"
+	RetryAlgorithmDecorrelatedJitter = "decorrelated_jitter"
+
+	// decorrelatedJitterGrowth is the upper bound multiplier applied to the
+	// previous delay, per the AWS architecture blog's formulation.
+	decorrelatedJitterGrowth = 3.0
 
 	defaultRetryAlgorithm      = RetryAlgorithmExponential
 	defaultRetryMaxAttempts    = 3
@@ -24,8 +36,10 @@ const (
 var (
 	_ RetryPolicy               = &standardRetryPolicy{}
 	_ RetryConditions           = &standardRetryConditions{}
+	_ RetryBodyPredicate        = &standardRetryBodyPredicate{}
 	_ jsonpointer.JSONPointable = standardRetryPolicy{}
 	_ jsonpointer.JSONPointable = standardRetryConditions{}
+	_ jsonpointer.JSONPointable = standardRetryBodyPredicate{}
 
 	defaultRetryableStatusCodes = []int{
 		http.StatusRequestTimeout,
@@ -43,8 +57,8 @@ var (
 
 // RetryPolicy describes how a remote call should be retried on transient
 // failure. Algorithm is a string so we can introduce alternative strategies
-// without breaking existing specs; the only currently supported value is
-// "exponential".
+// without breaking existing specs; supported values are "exponential",
+// "linear" and "decorrelated_jitter".
 type RetryPolicy interface {
 	GetAlgorithm() string
 	GetMaxAttempts() int
@@ -56,26 +70,138 @@ type RetryPolicy interface {
 	GetRetryableMethods() []string
 	IsStatusRetryable(statusCode int) bool
 	IsMethodRetryable(method string) bool
+	IsRespectRetryAfter() bool
+	GetIdempotencyPolicy() (IdempotencyPolicy, bool)
 	BackoffFor(attempt int) time.Duration
+	NextBackoff(attempt int, previous time.Duration) time.Duration
+	RetryAfterFor(header http.Header) (time.Duration, bool)
 }
 
 // RetryConditions enumerates the per-response signals that mark a result as
-// retryable. Currently only HTTP status codes are checked, but the type is
-// modelled as an extensible bag so future signals (header probes, body shape,
-// error class) can be added without rewriting specs.
+// retryable: HTTP status codes, and predicates over the response body for
+// vendors that signal throttling in an error payload rather than a status.
 type RetryConditions interface {
 	GetStatusCodes() []int
+	GetBodyPredicates() []RetryBodyPredicate
 	IsStatusRetryable(statusCode int) bool
+	IsBodyRetryable(body []byte) bool
+}
+
+// RetryBodyPredicate matches an error response body. Exactly one of
+// JSONPath and XPath is expected; the predicate matches when the path
+// resolves to any of Values, or to anything at all when Values is empty.
+type RetryBodyPredicate interface {
+	GetJSONPath() string
+	GetXPath() string
+	GetValues() []string
+	Matches(body []byte) bool
+}
+
+type standardRetryBodyPredicate struct {
+	JSONPath string   `json:"json_path,omitempty" yaml:"json_path,omitempty"`
+	XPath    string   `json:"xpath,omitempty" yaml:"xpath,omitempty"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+func (bp standardRetryBodyPredicate) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "json_path":
+		return bp.JSONPath, nil
+	case "xpath":
+		return bp.XPath, nil
+	case "values":
+		return bp.Values, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from RetryBodyPredicate doc object", token)
+	}
+}
+
+func (bp *standardRetryBodyPredicate) GetJSONPath() string {
+	return bp.JSONPath
+}
+
+func (bp *standardRetryBodyPredicate) GetXPath() string {
+	return bp.XPath
+}
+
+func (bp *standardRetryBodyPredicate) GetValues() []string {
+	return bp.Values
+}
+
+func (bp *standardRetryBodyPredicate) Matches(body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+	var found []string
+	switch {
+	case bp.JSONPath != "":
+		found = bp.findJSON(body)
+	case bp.XPath != "":
+		found = bp.findXML(body)
+	}
+	for _, f := range found {
+		if len(bp.Values) == 0 && f != "" {
+			return true
+		}
+		for _, v := range bp.Values {
+			if f == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (bp *standardRetryBodyPredicate) findJSON(body []byte) []string {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	raw, err := jsonpath.Get(bp.JSONPath, doc)
+	if err != nil {
+		return nil
+	}
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		rv := make([]string, 0, len(v))
+		for _, item := range v {
+			rv = append(rv, fmt.Sprintf("%v", item))
+		}
+		return rv
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
+
+func (bp *standardRetryBodyPredicate) findXML(body []byte) []string {
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	nodes, err := xmlquery.QueryAll(doc, bp.XPath)
+	if err != nil {
+		return nil
+	}
+	rv := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		rv = append(rv, strings.TrimSpace(n.InnerText()))
+	}
+	return rv
 }
 
 type standardRetryConditions struct {
-	StatusCodes []int `json:"status_codes,omitempty" yaml:"status_codes,omitempty"`
+	StatusCodes    []int                         `json:"status_codes,omitempty" yaml:"status_codes,omitempty"`
+	BodyPredicates []*standardRetryBodyPredicate `json:"body_predicates,omitempty" yaml:"body_predicates,omitempty"`
 }
 
 func (rc standardRetryConditions) JSONLookup(token string) (interface{}, error) {
 	switch token {
 	case "status_codes":
 		return rc.StatusCodes, nil
+	case "body_predicates":
+		return rc.BodyPredicates, nil
 	default:
 		return nil, fmt.Errorf("could not resolve token '%s' from RetryConditions doc object", token)
 	}
@@ -90,6 +216,16 @@ func (rc *standardRetryConditions) GetStatusCodes() []int {
 	return rc.StatusCodes
 }
 
+func (rc *standardRetryConditions) GetBodyPredicates() []RetryBodyPredicate {
+	rv := make([]RetryBodyPredicate, 0, len(rc.BodyPredicates))
+	for _, bp := range rc.BodyPredicates {
+		if bp != nil {
+			rv = append(rv, bp)
+		}
+	}
+	return rv
+}
+
 func (rc *standardRetryConditions) IsStatusRetryable(statusCode int) bool {
 	for _, c := range rc.GetStatusCodes() {
 		if c == statusCode {
@@ -99,15 +235,26 @@ func (rc *standardRetryConditions) IsStatusRetryable(statusCode int) bool {
 	return false
 }
 
+func (rc *standardRetryConditions) IsBodyRetryable(body []byte) bool {
+	for _, bp := range rc.GetBodyPredicates() {
+		if bp.Matches(body) {
+			return true
+		}
+	}
+	return false
+}
+
 type standardRetryPolicy struct {
-	Algorithm           string                   `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
-	MaxAttempts         int                      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
-	InitialDelayMs      int                      `json:"initial_delay_ms,omitempty" yaml:"initial_delay_ms,omitempty"`
-	MaxDelayMs          int                      `json:"max_delay_ms,omitempty" yaml:"max_delay_ms,omitempty"`
-	Multiplier          float64                  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
-	JitterFraction      float64                  `json:"jitter_fraction,omitempty" yaml:"jitter_fraction,omitempty"`
-	RetryableConditions *standardRetryConditions `json:"retryable_conditions,omitempty" yaml:"retryable_conditions,omitempty"`
-	RetryableMethods    []string                 `json:"retryable_methods,omitempty" yaml:"retryable_methods,omitempty"`
+	Algorithm           string                     `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	MaxAttempts         int                        `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	InitialDelayMs      int                        `json:"initial_delay_ms,omitempty" yaml:"initial_delay_ms,omitempty"`
+	MaxDelayMs          int                        `json:"max_delay_ms,omitempty" yaml:"max_delay_ms,omitempty"`
+	Multiplier          float64                    `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	JitterFraction      float64                    `json:"jitter_fraction,omitempty" yaml:"jitter_fraction,omitempty"`
+	RetryableConditions *standardRetryConditions   `json:"retryable_conditions,omitempty" yaml:"retryable_conditions,omitempty"`
+	RetryableMethods    []string                   `json:"retryable_methods,omitempty" yaml:"retryable_methods,omitempty"`
+	RespectRetryAfter   bool                       `json:"respect_retry_after,omitempty" yaml:"respect_retry_after,omitempty"`
+	Idempotency         *standardIdempotencyPolicy `json:"idempotency,omitempty" yaml:"idempotency,omitempty"`
 }
 
 func (rp standardRetryPolicy) JSONLookup(token string) (interface{}, error) {
@@ -128,6 +275,10 @@ func (rp standardRetryPolicy) JSONLookup(token string) (interface{}, error) {
 		return rp.RetryableConditions, nil
 	case "retryable_methods":
 		return rp.RetryableMethods, nil
+	case "respect_retry_after":
+		return rp.RespectRetryAfter, nil
+	case "idempotency":
+		return rp.Idempotency, nil
 	default:
 		return nil, fmt.Errorf("could not resolve token '%s' from RetryPolicy doc object", token)
 	}
@@ -201,6 +352,8 @@ func (rp *standardRetryPolicy) IsStatusRetryable(statusCode int) bool {
 	return rp.GetRetryableConditions().IsStatusRetryable(statusCode)
 }
 
+// IsMethodRetryable reports whether method is listed in retryable_methods or
+// covered by the idempotency block, whose key makes retrying it safe.
 func (rp *standardRetryPolicy) IsMethodRetryable(method string) bool {
 	for _, m := range rp.GetRetryableMethods() {
 		if m == "*" {
@@ -210,12 +363,34 @@ func (rp *standardRetryPolicy) IsMethodRetryable(method string) bool {
 			return true
 		}
 	}
+	if idem, ok := rp.GetIdempotencyPolicy(); ok {
+		return idem.IsMethodCovered(method)
+	}
 	return false
 }
 
+func (rp *standardRetryPolicy) IsRespectRetryAfter() bool {
+	return rp.RespectRetryAfter
+}
+
+func (rp *standardRetryPolicy) GetIdempotencyPolicy() (IdempotencyPolicy, bool) {
+	if rp.Idempotency == nil {
+		return nil, false
+	}
+	return rp.Idempotency, true
+}
+
 // BackoffFor returns the delay to wait before the given attempt number
 // (1-indexed). attempt==1 means "wait before the second try", etc.
+// For decorrelated jitter, which depends on the previous delay, the
+// initial delay stands in for the previous one.
 func (rp *standardRetryPolicy) BackoffFor(attempt int) time.Duration {
+	return rp.NextBackoff(attempt, 0)
+}
+
+// NextBackoff is BackoffFor with knowledge of the previously applied delay,
+// which only the decorrelated jitter algorithm consumes.
+func (rp *standardRetryPolicy) NextBackoff(attempt int, previous time.Duration) time.Duration {
 	if attempt < 1 {
 		attempt = 1
 	}
@@ -224,6 +399,24 @@ func (rp *standardRetryPolicy) BackoffFor(attempt int) time.Duration {
 	maxDelay := rp.GetMaxDelay()
 	var d time.Duration
 	switch algo {
+	case RetryAlgorithmDecorrelatedJitter:
+		// sleep = min(cap, uniform(base, prev * 3)); jitter is intrinsic.
+		if previous < initial {
+			previous = initial
+		}
+		upper := math.Min(float64(previous)*decorrelatedJitterGrowth, float64(maxDelay))
+		lower := float64(initial)
+		if upper <= lower {
+			return time.Duration(math.Min(lower, float64(maxDelay)))
+		}
+		return time.Duration(lower + rand.Float64()*(upper-lower)) //nolint:gosec // not security-sensitive
+	case RetryAlgorithmLinear:
+		nanos := float64(initial) * float64(attempt)
+		if nanos > float64(maxDelay) {
+			d = maxDelay
+		} else {
+			d = time.Duration(nanos)
+		}
 	case RetryAlgorithmExponential:
 		multiplier := rp.GetMultiplier()
 		factor := math.Pow(multiplier, float64(attempt-1))
@@ -253,6 +446,49 @@ func (rp *standardRetryPolicy) BackoffFor(attempt int) time.Duration {
 	return d
 }
 
+// RetryAfterFor extracts a server-requested delay from Retry-After
+// (delta-seconds or HTTP-date) or, failing that, from X-RateLimit-Reset when
+// X-RateLimit-Remaining reports the budget exhausted. Providers such as
+// GitHub send the reset on every response, so it says nothing about a
+// response with budget left. The delay is not capped; the retry loop gives
+// up rather than retry before a delay beyond the policy's max delay.
+func (rp *standardRetryPolicy) RetryAfterFor(header http.Header) (time.Duration, bool) {
+	if header == nil {
+		return 0, false
+	}
+	now := time.Now()
+	d, ok := parseRetryAfter(header.Get("Retry-After"), now)
+	if !ok {
+		if strings.TrimSpace(header.Get(ratelimit.DefaultRemainingHeader)) != "0" {
+			return 0, false
+		}
+		resetAt, resetOk := ratelimit.ParseReset(header.Get(ratelimit.DefaultResetHeader), ratelimit.ResetFormatAuto, now)
+		if !resetOk {
+			return 0, false
+		}
+		d = resetAt.Sub(now)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+func parseRetryAfter(raw string, now time.Time) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if at, err := http.ParseTime(raw); err == nil {
+		return at.Sub(now), true
+	}
+	resetAt, ok := ratelimit.ParseReset(raw, ratelimit.ResetFormatDeltaSeconds, now)
+	if !ok {
+		return 0, false
+	}
+	return resetAt.Sub(now), true
+}
+
 // DefaultRetryPolicy returns the policy applied when no x-stackQL-config
 // retry block is present anywhere in the inheritance chain.
 func DefaultRetryPolicy() RetryPolicy {
@@ -0,0 +1,199 @@
+package anysdk
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// throttlingServer replies with the given status, headers and body for the
+// first `throttled` requests, then 200.
+func throttlingServer(
+	t *testing.T,
+	throttled int64,
+	status int,
+	header map[string]string,
+	body string,
+) (*httptest.Server, *int64) {
+	t.Helper()
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1) > throttled {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetry_RespectsRetryAfterSeconds(t *testing.T) {
+	srv, calls := throttlingServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, "")
+	policy := fastPolicy(2, []string{"GET"}, []int{http.StatusTooManyRequests}).(*standardRetryPolicy)
+	policy.MaxDelayMs = 2000
+	policy.RespectRetryAfter = true
+
+	start := time.Now()
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt64(calls) != 2 {
+		t.Fatalf("expected 200 after 2 calls, got %d after %d", resp.StatusCode, atomic.LoadInt64(calls))
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("expected the Retry-After delay to be honoured, finished in %s", elapsed)
+	}
+}
+
+func TestRetry_IgnoresRetryAfterUnlessRespected(t *testing.T) {
+	srv, _ := throttlingServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "5"}, "")
+	policy := fastPolicy(2, []string{"GET"}, []int{http.StatusTooManyRequests})
+
+	start := time.Now()
+	if _, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected computed backoff only, waited %s", elapsed)
+	}
+}
+
+func TestRetry_RetryAfterBeyondMaxDelayEndsRetries(t *testing.T) {
+	srv, calls := throttlingServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}, "slow down")
+	policy := fastPolicy(3, nil, []int{http.StatusTooManyRequests}).(*standardRetryPolicy)
+	policy.RespectRetryAfter = true
+
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "slow down" || atomic.LoadInt64(calls) != 1 {
+		t.Fatalf("expected the 429 after 1 call, got %d %q after %d", resp.StatusCode, body, atomic.LoadInt64(calls))
+	}
+}
+
+func TestRetryAfterFor_ParsesHeaders(t *testing.T) {
+	policy := &standardRetryPolicy{MaxDelayMs: 60000, RespectRetryAfter: true}
+
+	d, ok := policy.RetryAfterFor(http.Header{"Retry-After": []string{"7"}})
+	if !ok || d != 7*time.Second {
+		t.Fatalf("expected 7s from delta-seconds, got %s (ok=%v)", d, ok)
+	}
+
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	d, ok = policy.RetryAfterFor(http.Header{"Retry-After": []string{date}})
+	if !ok || d < 28*time.Second || d > 30*time.Second {
+		t.Fatalf("expected ~30s from HTTP-date, got %s (ok=%v)", d, ok)
+	}
+
+	d, ok = policy.RetryAfterFor(http.Header{"X-Ratelimit-Reset": []string{"3"}, "X-Ratelimit-Remaining": []string{"0"}})
+	if !ok || d != 3*time.Second {
+		t.Fatalf("expected 3s from X-RateLimit-Reset, got %s (ok=%v)", d, ok)
+	}
+
+	if _, ok = policy.RetryAfterFor(http.Header{"X-Ratelimit-Reset": []string{"3"}, "X-Ratelimit-Remaining": []string{"4999"}}); ok {
+		t.Fatalf("expected X-RateLimit-Reset to be ignored while budget remains")
+	}
+
+	d, ok = policy.RetryAfterFor(http.Header{"Retry-After": []string{"3600"}})
+	if !ok || d != time.Hour {
+		t.Fatalf("expected Retry-After not to be capped, got %s", d)
+	}
+
+	if _, ok = policy.RetryAfterFor(http.Header{"Retry-After": []string{"soon"}}); ok {
+		t.Fatalf("expected malformed Retry-After to be ignored")
+	}
+}
+
+func TestRetry_AWSThrottlingXMLBody(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<Response><Errors><Error><Code>Throttling</Code><Message>Rate exceeded</Message></Error></Errors></Response>`
+	srv, calls := throttlingServer(t, 2, http.StatusBadRequest, nil, body)
+	policy := fastPolicy(3, []string{"POST"}, nil).(*standardRetryPolicy)
+	policy.RetryableConditions.BodyPredicates = []*standardRetryBodyPredicate{
+		{XPath: "//Error/Code", Values: []string{"Throttling", "RequestLimitExceeded"}},
+	}
+
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodPost, srv.URL, "Action=DescribeInstances"), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt64(calls) != 3 {
+		t.Fatalf("expected 200 after 3 calls, got %d after %d", resp.StatusCode, atomic.LoadInt64(calls))
+	}
+}
+
+func TestRetry_GoogleRateLimitJSONBody(t *testing.T) {
+	body := `{"error":{"code":403,"errors":[{"reason":"RATE_LIMIT_EXCEEDED"}]}}`
+	srv, calls := throttlingServer(t, 1, http.StatusForbidden, nil, body)
+	policy := fastPolicy(2, []string{"GET"}, nil).(*standardRetryPolicy)
+	policy.RetryableConditions.BodyPredicates = []*standardRetryBodyPredicate{
+		{JSONPath: "$.error.errors[*].reason", Values: []string{"rateLimitExceeded", "RATE_LIMIT_EXCEEDED"}},
+	}
+
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt64(calls) != 2 {
+		t.Fatalf("expected 200 after 2 calls, got %d after %d", resp.StatusCode, atomic.LoadInt64(calls))
+	}
+}
+
+func TestRetry_NonMatchingBodyIsReturnedIntact(t *testing.T) {
+	body := `{"error":{"code":403,"errors":[{"reason":"forbidden"}]}}`
+	srv, calls := throttlingServer(t, 5, http.StatusForbidden, nil, body)
+	policy := fastPolicy(3, []string{"GET"}, nil).(*standardRetryPolicy)
+	policy.RetryableConditions.BodyPredicates = []*standardRetryBodyPredicate{
+		{JSONPath: "$.error.errors[*].reason", Values: []string{"RATE_LIMIT_EXCEEDED"}},
+	}
+
+	resp, err := newTestHttpClient().doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt64(calls); got != 1 {
+		t.Fatalf("expected a single call, got %d", got)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	if string(got) != body {
+		t.Fatalf("expected body to be preserved, got %q", string(got))
+	}
+}
+
+func TestBackoff_LinearAndDecorrelatedJitter(t *testing.T) {
+	linear := &standardRetryPolicy{Algorithm: RetryAlgorithmLinear, InitialDelayMs: 100, MaxDelayMs: 250}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 250 * time.Millisecond} {
+		if got := linear.BackoffFor(attempt); got != want {
+			t.Fatalf("linear attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+
+	decorrelated := &standardRetryPolicy{Algorithm: RetryAlgorithmDecorrelatedJitter, InitialDelayMs: 100, MaxDelayMs: 1000}
+	previous := time.Duration(0)
+	for attempt := 1; attempt <= 20; attempt++ {
+		d := decorrelated.NextBackoff(attempt, previous)
+		upper := 3 * previous
+		if upper < 300*time.Millisecond {
+			upper = 300 * time.Millisecond
+		}
+		if upper > time.Second {
+			upper = time.Second
+		}
+		if d < 100*time.Millisecond || d > upper {
+			t.Fatalf("decorrelated attempt %d: %s outside [100ms, %s]", attempt, d, upper)
+		}
+		previous = d
+	}
+}
@@ -18,7 +18,7 @@ import (
 // This deliberately avoids depending on the broader stackql/flask mock corpus
 // (which lives in the stackql repo) so the test stays self-contained here.
 //
-// Tests drive doWithRetry directly to dodge the need to stub the very wide
+// Tests drive doWithRetryAndGuards directly to dodge the need to stub the very wide
 // OperationStore interface; the resolveRetryPolicy path is covered separately.
 
 // scriptedHandler returns the next status code from `script` for each request.
@@ -91,7 +91,7 @@ func TestRetry_ZeroRepeats_OnlyOneAttempt(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := fastPolicy(1, []string{"*"}, []int{http.StatusServiceUnavailable})
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodGet, srv.URL, ""), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -116,7 +116,7 @@ func TestRetry_Default_RecoversWithinBudget(t *testing.T) {
 	// Override the delay to keep the test fast without changing semantics.
 	policy := &standardRetryPolicy{InitialDelayMs: 1, MaxDelayMs: 2}
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodGet, srv.URL, ""), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -133,7 +133,7 @@ func TestRetry_Default_ExhaustsAttemptsAndReturnsLastResponse(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := &standardRetryPolicy{InitialDelayMs: 1, MaxDelayMs: 2}
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodGet, srv.URL, ""), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -150,7 +150,7 @@ func TestRetry_Default_DoesNotRetryNonRetryableStatus(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := &standardRetryPolicy{InitialDelayMs: 1, MaxDelayMs: 2}
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodGet, srv.URL, ""), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -167,7 +167,7 @@ func TestRetry_Default_DoesNotRetryNonRetryableMethod(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := &standardRetryPolicy{InitialDelayMs: 1, MaxDelayMs: 2}
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodPost, srv.URL, "{}"), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodPost, srv.URL, "{}"), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -192,7 +192,7 @@ func TestRetry_Configured_FiveAttempts_SucceedsOnFifth(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := fastPolicy(5, []string{http.MethodGet}, []int{http.StatusServiceUnavailable})
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodGet, srv.URL, ""), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, srv.URL, ""), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -209,7 +209,7 @@ func TestRetry_Configured_WildcardMethodAndCustomStatus(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := fastPolicy(3, []string{"*"}, []int{http.StatusConflict})
 
-	resp, err := hc.doWithRetry(mustReq(t, http.MethodPost, srv.URL, `{"k":"v"}`), policy)
+	resp, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodPost, srv.URL, `{"k":"v"}`), policy, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -240,7 +240,7 @@ func TestRetry_RetriesOnTransportError(t *testing.T) {
 	hc := newTestHttpClient()
 	policy := fastPolicy(3, []string{http.MethodGet}, nil)
 
-	_, err := hc.doWithRetry(mustReq(t, http.MethodGet, url, ""), policy)
+	_, err := hc.doWithRetryAndGuards(mustReq(t, http.MethodGet, url, ""), policy, nil, nil, nil)
 	if err == nil {
 		t.Fatalf("expected dial error after exhausting retries")
 	}
@@ -266,7 +266,7 @@ func TestRetry_ContextCancellationStopsBackoff(t *testing.T) {
 		cancel()
 	}()
 
-	_, _ = hc.doWithRetry(req, policy)
+	_, _ = hc.doWithRetryAndGuards(req, policy, nil, nil, nil)
 	// First attempt always runs; backoff before attempt 2 should be cut
 	// short by ctx cancellation, so we should never see attempt 3.
 	if got := atomic.LoadInt64(&h.calls); got > 2 {
@@ -1431,6 +1431,10 @@ func (s *standardSchema) unmarshalResponse(r *http.Response, overrideMediaType s
 }
 
 func (s *standardSchema) unmarshalResponseFromReader(body io.Reader, mediaType string) (interface{}, error) {
+	if _, isRecord := media.GetRecordMediaType(mediaType); isRecord {
+		target, _, err := s.unmarshalRecordBody(body, "", mediaType)
+		return target, err
+	}
 	var target interface{}
 	var err error
 	switch mediaType {
@@ -1497,6 +1501,9 @@ func (s *standardSchema) unmarshalResponseAtPath(r *http.Response, path string,
 // }
 
 func (s *standardSchema) unmarshalReaderResponseAtPath(r io.Reader, path string, mediaType string, fallbackMediaType string) (interface{}, interface{}, error) {
+	if _, isRecord := media.GetRecordMediaType(mediaType); isRecord {
+		return s.unmarshalRecordBody(r, path, mediaType)
+	}
 	conformedMediaType := s.extractMediaTypeSynonym(mediaType)
 	switch conformedMediaType {
 	case media.MediaTypeXML:
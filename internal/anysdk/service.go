@@ -44,6 +44,7 @@ type OpenAPIService interface {
 	iDiscoveryDoc()
 	isObjectSchemaImplicitlyUnioned() bool
 	getExtension(key string) (interface{}, bool)
+	getStackQLConfig() (StackQLConfig, bool)
 	setStackQLConfig(config StackQLConfig)
 	setResourceMap(rsc map[string]*standardResource)
 	setProvider(provider Provider)
@@ -196,6 +197,10 @@ func (sv *standardService) setProviderService(providerService ProviderService) {
 	}
 }
 
+func (sv *standardService) getStackQLConfig() (StackQLConfig, bool) {
+	return sv.StackQLConfig, sv.StackQLConfig != nil
+}
+
 func (sv *standardService) setStackQLConfig(config StackQLConfig) {
 	sv.StackQLConfig = config
 }
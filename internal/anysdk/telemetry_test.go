@@ -0,0 +1,79 @@
+package anysdk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/telemetry"
+)
+
+func TestTelemetry_SpanPerHTTPAttempt(t *testing.T) {
+	im := telemetry.NewInMemory()
+	telemetry.SetInstrumentation(im)
+	defer telemetry.SetInstrumentation(nil)
+
+	srv, _ := newScriptedServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	hc := newTestHttpClient()
+	policy := fastPolicy(3, []string{"GET"}, []int{http.StatusServiceUnavailable})
+	ctx, parent := telemetry.StartSpan(context.Background(), telemetry.SpanHTTPRequest)
+	req := mustReq(t, http.MethodGet, srv.URL, "").WithContext(ctx)
+	resp, err := hc.doWithRetryAndGuards(req, policy, nil, nil, nil)
+	parent.End()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %v %v", resp, err)
+	}
+
+	spans := im.GetSpansNamed(telemetry.SpanHTTPAttempt)
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 attempt spans, got %d", len(spans))
+	}
+	for i, s := range spans {
+		if s.Parent != telemetry.SpanHTTPRequest || !s.Ended || s.Attributes[telemetry.AttrAttempt] != i+1 {
+			t.Fatalf("unexpected attempt span %+v", s)
+		}
+	}
+	if spans[0].Attributes[telemetry.AttrHTTPStatusCode] != http.StatusServiceUnavailable {
+		t.Fatalf("expected the first attempt to carry 503, got %+v", spans[0].Attributes)
+	}
+	attempts := im.GetHTTPAttempts()
+	if len(attempts) != 2 || attempts[0].StatusCode != 503 || attempts[1].StatusCode != 200 || attempts[1].Method != http.MethodGet {
+		t.Fatalf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestTelemetry_AuthSpan(t *testing.T) {
+	im := telemetry.NewInMemory()
+	telemetry.SetInstrumentation(im)
+	defer telemetry.SetInstrumentation(nil)
+
+	cc := NewAnySdkClientConfigurator(dto.RuntimeCtx{}, "myprovider", nil)
+	if _, err := cc.Auth(&dto.AuthCtx{}, dto.AuthNullStr, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spans := im.GetSpansNamed(telemetry.SpanAuth)
+	if len(spans) != 1 || spans[0].Attributes[telemetry.AttrProvider] != "myprovider" || spans[0].Err != nil {
+		t.Fatalf("unexpected auth spans %+v", spans)
+	}
+}
+
+// TestTelemetry_AuthSpanJoinsRequestContext: authenticating for a request
+// starts the auth span under the request's context, not a fresh one.
+func TestTelemetry_AuthSpanJoinsRequestContext(t *testing.T) {
+	im := telemetry.NewInMemory()
+	telemetry.SetInstrumentation(im)
+	defer telemetry.SetInstrumentation(nil)
+
+	ctx, parent := telemetry.StartSpan(context.Background(), telemetry.SpanPage)
+	cc := NewAnySdkClientConfigurator(dto.RuntimeCtx{}, "myprovider", nil)
+	_, err := authForRequest(ctx, cc, &dto.AuthCtx{}, dto.AuthNullStr, false)
+	parent.End()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spans := im.GetSpansNamed(telemetry.SpanAuth)
+	if len(spans) != 1 || spans[0].Parent != telemetry.SpanPage {
+		t.Fatalf("expected the auth span under the request's span, got %+v", spans)
+	}
+}
@@ -0,0 +1,365 @@
+package anysdk
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+)
+
+const (
+	// UpsertConflictFail reports a 409 from the create, or native upsert,
+	// call as an error.
+	UpsertConflictFail = "fail"
+	// UpsertConflictUpdate retries a conflicting create as an update, as
+	// when the resource was created between the get and the create.
+	UpsertConflictUpdate = "update"
+	// UpsertConflictIgnore leaves a conflicting resource as it is.
+	UpsertConflictIgnore = "ignore"
+
+	UpsertActionCreated   = "created"
+	UpsertActionUpdated   = "updated"
+	UpsertActionUnchanged = "unchanged"
+	// UpsertActionUpserted is the outcome of a native upsert, which does
+	// not say whether the resource existed.
+	UpsertActionUpserted = "upserted"
+)
+
+var (
+	_ UpsertPolicy              = &standardUpsertPolicy{}
+	_ jsonpointer.JSONPointable = standardUpsertPolicy{}
+	_ UpsertPlan                = &standardUpsertPlan{}
+
+	//nolint:gochecknoglobals // read-only names for error messages
+	upsertSteps = map[string]string{
+		UpsertActionCreated:  "create",
+		UpsertActionUpdated:  "update",
+		UpsertActionUpserted: "upsert",
+	}
+)
+
+// UpsertPolicy governs "create if absent, update if present" on a
+// resource: how a conflicting create is handled, and, optionally, which
+// methods the get-then-create/update fallback uses in place of those
+// inferred from the resource's SQL verbs.
+type UpsertPolicy interface {
+	GetOnConflict() string
+	GetGetMethod() string
+	GetCreateMethod() string
+	GetUpdateMethod() string
+}
+
+type standardUpsertPolicy struct {
+	OnConflict   string `json:"on_conflict,omitempty" yaml:"on_conflict,omitempty"`
+	GetMethod    string `json:"get_method,omitempty" yaml:"get_method,omitempty"`
+	CreateMethod string `json:"create_method,omitempty" yaml:"create_method,omitempty"`
+	UpdateMethod string `json:"update_method,omitempty" yaml:"update_method,omitempty"`
+}
+
+func (up standardUpsertPolicy) JSONLookup(token string) (interface{}, error) {
+	switch token {
+	case "on_conflict":
+		return up.OnConflict, nil
+	case "get_method":
+		return up.GetMethod, nil
+	case "create_method":
+		return up.CreateMethod, nil
+	case "update_method":
+		return up.UpdateMethod, nil
+	default:
+		return nil, fmt.Errorf("could not resolve token '%s' from UpsertPolicy doc object", token)
+	}
+}
+
+func (up *standardUpsertPolicy) GetOnConflict() string {
+	switch strings.ToLower(up.OnConflict) {
+	case UpsertConflictUpdate:
+		return UpsertConflictUpdate
+	case UpsertConflictIgnore:
+		return UpsertConflictIgnore
+	default:
+		return UpsertConflictFail
+	}
+}
+
+func (up *standardUpsertPolicy) GetGetMethod() string {
+	return up.GetMethod
+}
+
+func (up *standardUpsertPolicy) GetCreateMethod() string {
+	return up.CreateMethod
+}
+
+func (up *standardUpsertPolicy) GetUpdateMethod() string {
+	return up.UpdateMethod
+}
+
+// UpsertDoer sends one call of method with the supplied parameters, with
+// the method's authentication and transport policies applied.
+type UpsertDoer func(method StandardOperationStore, params map[string]interface{}) (*http.Response, error)
+
+// UpsertResult is the outcome of an upsert. Response is the open response
+// of the call that made the change; it is nil when nothing changed.
+type UpsertResult struct {
+	Action   string
+	Method   StandardOperationStore
+	Response *http.Response
+}
+
+// UpsertPlan is how a resource does "create if absent, update if present".
+// A native plan sends a single PUT-style upsert; otherwise the plan gets
+// the resource by its identifier parameters and creates or updates it
+// according to whether it was found.
+type UpsertPlan interface {
+	IsNative() bool
+	GetUpsertMethod() (StandardOperationStore, bool)
+	GetGetMethod() (StandardOperationStore, bool)
+	GetCreateMethod() (StandardOperationStore, bool)
+	GetUpdateMethod() (StandardOperationStore, bool)
+	// GetIdentifierParameters names the parameters that address a single
+	// resource: the required parameters of the get method.
+	GetIdentifierParameters() []string
+	GetOnConflict() string
+	Execute(params map[string]interface{}, doer UpsertDoer) (UpsertResult, error)
+}
+
+type standardUpsertPlan struct {
+	resourceName string
+	upsert       StandardOperationStore
+	get          StandardOperationStore
+	create       StandardOperationStore
+	update       StandardOperationStore
+	identifiers  []string
+	onConflict   string
+}
+
+func (p *standardUpsertPlan) IsNative() bool {
+	return p.upsert != nil
+}
+
+func (p *standardUpsertPlan) GetUpsertMethod() (StandardOperationStore, bool) {
+	return p.upsert, p.upsert != nil
+}
+
+func (p *standardUpsertPlan) GetGetMethod() (StandardOperationStore, bool) {
+	return p.get, p.get != nil
+}
+
+func (p *standardUpsertPlan) GetCreateMethod() (StandardOperationStore, bool) {
+	return p.create, p.create != nil
+}
+
+func (p *standardUpsertPlan) GetUpdateMethod() (StandardOperationStore, bool) {
+	return p.update, p.update != nil
+}
+
+func (p *standardUpsertPlan) GetIdentifierParameters() []string {
+	return p.identifiers
+}
+
+func (p *standardUpsertPlan) GetOnConflict() string {
+	return p.onConflict
+}
+
+func (p *standardUpsertPlan) Execute(params map[string]interface{}, doer UpsertDoer) (UpsertResult, error) {
+	if p.upsert != nil {
+		return p.write(p.upsert, UpsertActionUpserted, params, doer)
+	}
+	getParams := make(map[string]interface{})
+	for k, v := range params {
+		if _, isParam := p.get.GetOperationParameter(k); isParam {
+			getParams[k] = v
+		}
+	}
+	resp, err := doer(p.get, getParams)
+	if err != nil {
+		return UpsertResult{}, p.wrapError("get", p.get, err)
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		closeResponse(resp)
+		return p.write(p.create, UpsertActionCreated, params, doer)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return UpsertResult{}, p.wrapError("get", p.get, p.responseError(p.get, resp))
+	}
+	closeResponse(resp)
+	switch {
+	case p.update == nil:
+		return UpsertResult{}, fmt.Errorf(
+			"upsert on resource '%s': resource exists and there is no update method", p.resourceName)
+	default:
+		return p.write(p.update, UpsertActionUpdated, params, doer)
+	}
+}
+
+// write sends a create, update or native upsert, applying the conflict
+// policy to a 409 from anything but an update.
+func (p *standardUpsertPlan) write(
+	method StandardOperationStore,
+	action string,
+	params map[string]interface{},
+	doer UpsertDoer,
+) (UpsertResult, error) {
+	step := upsertSteps[action]
+	resp, err := doer(method, params)
+	if err != nil {
+		return UpsertResult{}, p.wrapError(step, method, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return UpsertResult{Action: action, Method: method, Response: resp}, nil
+	}
+	if resp.StatusCode == http.StatusConflict && action != UpsertActionUpdated {
+		switch {
+		case p.onConflict == UpsertConflictIgnore:
+			closeResponse(resp)
+			return UpsertResult{Action: UpsertActionUnchanged, Method: method}, nil
+		case p.onConflict == UpsertConflictUpdate && p.update != nil:
+			closeResponse(resp)
+			return p.write(p.update, UpsertActionUpdated, params, doer)
+		}
+	}
+	return UpsertResult{}, p.wrapError(step, method, p.responseError(method, resp))
+}
+
+func (p *standardUpsertPlan) responseError(method StandardOperationStore, resp *http.Response) error {
+	defer closeResponse(resp)
+	if apiErr, isAPIError := method.ParseAPIError(resp); isAPIError {
+		return apiErr
+	}
+	return fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+func (p *standardUpsertPlan) wrapError(step string, method StandardOperationStore, err error) error {
+	return fmt.Errorf("upsert on resource '%s': %s with method '%s': %w", p.resourceName, step, method.GetName(), err)
+}
+
+func closeResponse(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		//nolint:errcheck // drained and discarded
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// getUpsertPlan resolves the methods for an upsert with the supplied
+// parameters. A method for the upsert SQL verb, declared in sqlVerbs or
+// keyed upsert or createOrUpdate, makes a native plan. Otherwise the get
+// method is the select method, among those whose required parameters are
+// all supplied, with the most required parameters; its required parameters
+// identify the resource, so they must go beyond those of the create method.
+//
+//nolint:gocognit // one branch per method role
+func (rs *standardResource) getUpsertPlan(parameters map[string]interface{}) (UpsertPlan, error) {
+	policy := rs.getUpsertPolicy()
+	plan := &standardUpsertPlan{
+		resourceName: rs.GetName(),
+		onConflict:   policy.GetOnConflict(),
+	}
+	if ms, err := rs.getMethodsForSQLVerb("upsert"); err == nil {
+		if m, isMatch := firstSuppliedMethod(ms, parameters); isMatch {
+			plan.upsert = m
+			plan.update, _ = rs.resolveUpsertMethod(policy.GetUpdateMethod(), parameters, "update", "replace")
+			return plan, nil
+		}
+	}
+	var found bool
+	plan.create, found = rs.resolveUpsertMethod(policy.GetCreateMethod(), parameters, "insert")
+	if !found {
+		return nil, fmt.Errorf("cannot upsert resource '%s': no create method matches the supplied parameters", rs.GetName())
+	}
+	plan.update, _ = rs.resolveUpsertMethod(policy.GetUpdateMethod(), parameters, "update", "replace")
+	if key := policy.GetGetMethod(); key != "" {
+		m, err := rs.FindMethod(key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot upsert resource '%s': %w", rs.GetName(), err)
+		}
+		plan.get = m
+	} else {
+		ms, _ := rs.getMethodsForSQLVerb("select")
+		for _, m := range ms {
+			if !isEverySupplied(m.GetRequiredNonBodyParameters(), parameters) {
+				continue
+			}
+			if plan.get == nil || len(m.GetRequiredNonBodyParameters()) > len(plan.get.GetRequiredNonBodyParameters()) {
+				plan.get = m
+			}
+		}
+		if plan.get == nil || isSubset(plan.get.GetRequiredNonBodyParameters(), plan.create.GetRequiredNonBodyParameters()) {
+			return nil, fmt.Errorf(
+				"cannot upsert resource '%s': cannot infer identifier parameters from the supplied parameters; declare upsert.get_method",
+				rs.GetName(),
+			)
+		}
+	}
+	for k := range plan.get.GetRequiredNonBodyParameters() {
+		plan.identifiers = append(plan.identifiers, k)
+	}
+	sort.Strings(plan.identifiers)
+	return plan, nil
+}
+
+// resolveUpsertMethod returns the method keyed by override, or else the
+// first method of the SQL verbs whose required parameters are supplied,
+// falling back to methods keyed by the verbs themselves, and patch, for
+// resources that do not declare them.
+func (rs *standardResource) resolveUpsertMethod(
+	override string,
+	parameters map[string]interface{},
+	sqlVerbs ...string,
+) (StandardOperationStore, bool) {
+	if override != "" {
+		m, err := rs.FindMethod(override)
+		return m, err == nil
+	}
+	for _, verb := range sqlVerbs {
+		if ms, err := rs.getMethodsForSQLVerb(verb); err == nil {
+			if m, isMatch := firstSuppliedMethod(ms, parameters); isMatch {
+				return m, true
+			}
+		}
+	}
+	var ms MethodSet
+	for _, k := range append(sqlVerbs, "patch") {
+		if m, err := rs.FindMethod(k); err == nil {
+			ms = append(ms, m)
+		}
+	}
+	return firstSuppliedMethod(ms, parameters)
+}
+
+func (rs *standardResource) getUpsertPolicy() UpsertPolicy {
+	if up, ok := resolveResourceStackQLConfig(rs, StackQLConfig.GetUpsertPolicy); ok {
+		return up
+	}
+	return &standardUpsertPolicy{}
+}
+
+func firstSuppliedMethod(ms MethodSet, parameters map[string]interface{}) (StandardOperationStore, bool) {
+	for _, m := range ms {
+		if isEverySupplied(m.GetRequiredNonBodyParameters(), parameters) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func isEverySupplied(required map[string]Addressable, parameters map[string]interface{}) bool {
+	for k := range required {
+		if _, ok := parameters[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubset(a, b map[string]Addressable) bool {
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
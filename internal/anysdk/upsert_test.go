@@ -0,0 +1,259 @@
+package anysdk
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/apierror"
+)
+
+func upsertTestMethod(key string, required ...string) standardOpenAPIOperationStore {
+	params := make(map[string]map[string]interface{})
+	for _, name := range required {
+		params[name] = map[string]interface{}{"in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}}
+	}
+	return standardOpenAPIOperationStore{MethodKey: key, Parameters: params}
+}
+
+func upsertTestResource(extra ...standardOpenAPIOperationStore) *standardResource {
+	rs := &standardResource{
+		Name: "instances",
+		Methods: Methods{
+			"insert": upsertTestMethod("insert", "project", "zone"),
+			"list":   upsertTestMethod("list", "project", "zone"),
+			"get":    upsertTestMethod("get", "project", "zone", "instance"),
+			"update": upsertTestMethod("update", "project", "zone", "instance"),
+		},
+		SQLVerbs: make(map[string][]OpenAPIOperationStoreRef),
+	}
+	for _, m := range extra {
+		rs.Methods[m.MethodKey] = m
+	}
+	return rs
+}
+
+var upsertTestParams = map[string]interface{}{ //nolint:gochecknoglobals // test fixture
+	"project":  "my-project",
+	"zone":     "us-east1-b",
+	"instance": "vm-1",
+	"name":     "vm-1",
+}
+
+type upsertCall struct {
+	method string
+	params map[string]interface{}
+}
+
+// scriptedDoer answers each method with a fixed status and records calls.
+func scriptedDoer(statuses map[string]int, calls *[]upsertCall) UpsertDoer {
+	return func(method StandardOperationStore, params map[string]interface{}) (*http.Response, error) {
+		*calls = append(*calls, upsertCall{method: method.GetName(), params: params})
+		status := statuses[method.GetName()]
+		body := `{}`
+		if status == http.StatusConflict {
+			body = `{"error": {"code": "AlreadyExists", "message": "instance vm-1 already exists"}}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}
+}
+
+func upsertCallNames(calls []upsertCall) []string {
+	var rv []string
+	for _, c := range calls {
+		rv = append(rv, c.method)
+	}
+	return rv
+}
+
+func TestGetUpsertPlan_GetThenWrite(t *testing.T) {
+	plan, err := upsertTestResource().GetUpsertPlan(upsertTestParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.IsNative() {
+		t.Fatalf("expected a get-then-write plan")
+	}
+	get, _ := plan.GetGetMethod()
+	create, _ := plan.GetCreateMethod()
+	update, _ := plan.GetUpdateMethod()
+	if get.GetName() != "get" || create.GetName() != "insert" || update.GetName() != "update" {
+		t.Fatalf("unexpected methods %s, %s, %s", get.GetName(), create.GetName(), update.GetName())
+	}
+	if ids := plan.GetIdentifierParameters(); !reflect.DeepEqual(ids, []string{"instance", "project", "zone"}) {
+		t.Fatalf("unexpected identifier parameters %v", ids)
+	}
+	if plan.GetOnConflict() != UpsertConflictFail {
+		t.Fatalf("expected conflicts to fail by default, got %s", plan.GetOnConflict())
+	}
+}
+
+// TestGetUpsertPlan_MethodsKeepBackReferences: methods resolved by key are
+// copies of the resource's methods, so they must still reach the resource
+// and provider that the loader attached.
+func TestGetUpsertPlan_MethodsKeepBackReferences(t *testing.T) {
+	rs := upsertTestResource()
+	prov := &standardProvider{Name: "google"}
+	for k, m := range rs.Methods {
+		method := m
+		method.setResource(rs)
+		method.setProvider(prov)
+		rs.setMethod(k, &method)
+	}
+	plan, err := rs.GetUpsertPlan(upsertTestParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	create, _ := plan.GetCreateMethod()
+	update, _ := plan.GetUpdateMethod()
+	for _, m := range []StandardOperationStore{create, update} {
+		if m.GetResource() != rs || m.GetProvider() != prov {
+			t.Fatalf("method '%s' lost its back references", m.GetName())
+		}
+	}
+}
+
+func TestGetUpsertPlan_Native(t *testing.T) {
+	rs := upsertTestResource(upsertTestMethod("createOrUpdate", "project", "zone", "instance"))
+	plan, err := rs.GetUpsertPlan(upsertTestParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, isNative := plan.GetUpsertMethod()
+	if !plan.IsNative() || !isNative || m.GetName() != "createOrUpdate" {
+		t.Fatalf("expected a native plan")
+	}
+	var calls []upsertCall
+	result, err := plan.Execute(upsertTestParams, scriptedDoer(map[string]int{"createOrUpdate": http.StatusOK}, &calls))
+	if err != nil || result.Action != UpsertActionUpserted {
+		t.Fatalf("unexpected result %+v, %v", result, err)
+	}
+	if names := upsertCallNames(calls); !reflect.DeepEqual(names, []string{"createOrUpdate"}) {
+		t.Fatalf("unexpected calls %v", names)
+	}
+}
+
+func TestGetUpsertPlan_NeedsIdentifier(t *testing.T) {
+	_, err := upsertTestResource().GetUpsertPlan(map[string]interface{}{"project": "my-project", "zone": "us-east1-b"})
+	if err == nil || !strings.Contains(err.Error(), "cannot infer identifier parameters") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	rs := upsertTestResource()
+	rs.StackQLConfig = &standardStackQLConfig{Upsert: &standardUpsertPolicy{GetMethod: "list"}}
+	plan, err := rs.GetUpsertPlan(map[string]interface{}{"project": "my-project", "zone": "us-east1-b"})
+	if err != nil {
+		t.Fatalf("expected the declared get method to be used, got %v", err)
+	}
+	if get, _ := plan.GetGetMethod(); get.GetName() != "list" {
+		t.Fatalf("unexpected get method %s", get.GetName())
+	}
+}
+
+func TestGetUpsertPlan_PolicyInheritedFromProvider(t *testing.T) {
+	rs := upsertTestResource()
+	rs.Provider = &standardProvider{StackQLConfig: &standardStackQLConfig{Upsert: &standardUpsertPolicy{GetMethod: "list"}}}
+	plan, err := rs.GetUpsertPlan(map[string]interface{}{"project": "my-project", "zone": "us-east1-b"})
+	if err != nil {
+		t.Fatalf("expected the provider's get method to be used, got %v", err)
+	}
+	if get, _ := plan.GetGetMethod(); get.GetName() != "list" {
+		t.Fatalf("unexpected get method %s", get.GetName())
+	}
+}
+
+func TestUpsertPlan_Execute(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		onConflict string
+		statuses   map[string]int
+		action     string
+		calls      []string
+		err        string
+	}{
+		{
+			name:     "absent",
+			statuses: map[string]int{"get": http.StatusNotFound, "insert": http.StatusCreated},
+			action:   UpsertActionCreated,
+			calls:    []string{"get", "insert"},
+		},
+		{
+			name:     "present",
+			statuses: map[string]int{"get": http.StatusOK, "update": http.StatusOK},
+			action:   UpsertActionUpdated,
+			calls:    []string{"get", "update"},
+		},
+		{
+			name:     "conflict fails",
+			statuses: map[string]int{"get": http.StatusNotFound, "insert": http.StatusConflict},
+			calls:    []string{"get", "insert"},
+			err:      "upsert on resource 'instances': create with method 'insert': 409 Conflict: AlreadyExists: instance vm-1 already exists",
+		},
+		{
+			name:       "conflict updates",
+			onConflict: "update",
+			statuses:   map[string]int{"get": http.StatusNotFound, "insert": http.StatusConflict, "update": http.StatusOK},
+			action:     UpsertActionUpdated,
+			calls:      []string{"get", "insert", "update"},
+		},
+		{
+			name:       "conflict ignored",
+			onConflict: "ignore",
+			statuses:   map[string]int{"get": http.StatusNotFound, "insert": http.StatusConflict},
+			action:     UpsertActionUnchanged,
+			calls:      []string{"get", "insert"},
+		},
+		{
+			name:     "get fails",
+			statuses: map[string]int{"get": http.StatusForbidden},
+			calls:    []string{"get"},
+			err:      "get with method 'get': 403 Forbidden",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := upsertTestResource()
+			rs.StackQLConfig = &standardStackQLConfig{Upsert: &standardUpsertPolicy{OnConflict: tc.onConflict}}
+			plan, err := rs.GetUpsertPlan(upsertTestParams)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var calls []upsertCall
+			result, err := plan.Execute(upsertTestParams, scriptedDoer(tc.statuses, &calls))
+			if names := upsertCallNames(calls); !reflect.DeepEqual(names, tc.calls) {
+				t.Fatalf("unexpected calls %v", names)
+			}
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil || result.Action != tc.action {
+				t.Fatalf("unexpected result %+v, %v", result, err)
+			}
+		})
+	}
+}
+
+func TestUpsertPlan_GetSendsOnlyItsParameters(t *testing.T) {
+	plan, err := upsertTestResource().GetUpsertPlan(upsertTestParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var calls []upsertCall
+	_, err = plan.Execute(upsertTestParams, scriptedDoer(map[string]int{"get": http.StatusNotFound, "insert": http.StatusConflict}, &calls))
+	if _, isAPIError := apierror.As(err); !isAPIError {
+		t.Fatalf("expected the conflict to surface as an APIError, got %v", err)
+	}
+	if _, hasName := calls[0].params["name"]; hasName || len(calls[0].params) != 3 {
+		t.Fatalf("unexpected get parameters %v", calls[0].params)
+	}
+	if len(calls[1].params) != len(upsertTestParams) {
+		t.Fatalf("unexpected create parameters %v", calls[1].params)
+	}
+}
@@ -0,0 +1,32 @@
+package anysdk
+
+import (
+	"path"
+	"testing"
+)
+
+// mustLoadWidgetsMethod loads a method of the contrived provider's widgets
+// service from test/registry. Its methods declare the request and response
+// features under test: validation constraints, form and multipart bodies,
+// streamed, tabular and binary responses, and mutating SQL verbs.
+func mustLoadWidgetsMethod(t *testing.T, methodKey string) *standardOpenAPIOperationStore {
+	t.Helper()
+	root := path.Join(OpenapiFileRoot, "contrivedprovider", "v0.1.0")
+	svc, err := LoadProviderAndServiceFromPaths(path.Join(root, "provider.yaml"), path.Join(root, "services", "widgets.yaml"))
+	if err != nil {
+		t.Fatalf("error loading service: %v", err)
+	}
+	rsc, err := svc.GetResource("widgets")
+	if err != nil {
+		t.Fatalf("error loading resource: %v", err)
+	}
+	m, err := rsc.FindMethod(methodKey)
+	if err != nil {
+		t.Fatalf("error loading method: %v", err)
+	}
+	op, isStandard := m.(*standardOpenAPIOperationStore)
+	if !isStandard {
+		t.Fatalf("unexpected method type %T", m)
+	}
+	return op
+}
@@ -0,0 +1,363 @@
+// Package apierror normalises the error bodies of failed API calls into a
+// single APIError type, so that callers can branch on a provider's error
+// code rather than matching strings.
+//
+// The body formats recognised are RFC 7807 problem details, Google's
+// error.status envelope, Azure's error.code envelope, OAuth 2.0 token
+// errors, the AWS JSON and XML envelopes, and custom formats described by
+// a Format.
+package apierror
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+)
+
+const (
+	FormatCustom  = "custom"
+	FormatProblem = "problem"
+	FormatGoogle  = "google"
+	FormatAzure   = "azure"
+	FormatOAuth   = "oauth"
+	FormatAWSJSON = "aws_json"
+	FormatAWSXML  = "aws_xml"
+	FormatJSON    = "json"
+	FormatUnknown = "unknown"
+
+	// maxBodyBytes bounds how much of an error body is read and kept.
+	maxBodyBytes = 1 << 20
+)
+
+var (
+	//nolint:gochecknoglobals // header precedence
+	requestIDHeaders = []string{
+		"X-Request-Id",
+		"X-Amz-Request-Id",
+		"X-Amzn-Requestid",
+		"X-Ms-Request-Id",
+		"X-Goog-Request-Id",
+		"Request-Id",
+		"X-Correlation-Id",
+	}
+
+	//nolint:gochecknoglobals // codes signalling throttling or transient unavailability
+	defaultRetryableCodes = map[string]struct{}{
+		"Throttling":                             {},
+		"ThrottlingException":                    {},
+		"ThrottledException":                     {},
+		"TooManyRequestsException":               {},
+		"RequestLimitExceeded":                   {},
+		"RequestThrottled":                       {},
+		"ProvisionedThroughputExceededException": {},
+		"SlowDown":                               {},
+		"ServiceUnavailable":                     {},
+		"InternalError":                          {},
+		"RESOURCE_EXHAUSTED":                     {},
+		"UNAVAILABLE":                            {},
+		"DEADLINE_EXCEEDED":                      {},
+		"TooManyRequests":                        {},
+		"ServerBusy":                             {},
+		"OperationTimedOut":                      {},
+	}
+)
+
+// APIError is a failed API call, normalised across body formats.
+type APIError struct {
+	StatusCode int
+	// Code is the provider's error code, such as NOT_FOUND,
+	// ResourceGroupNotFound or ThrottlingException; empty when the body
+	// carries none.
+	Code      string
+	Message   string
+	RequestID string
+	// Retryable reports whether retrying the same call may succeed.
+	Retryable bool
+	// Format is the body format the error was parsed from, one of the
+	// Format constants.
+	Format string
+	// Details holds structured detail entries, such as Google's
+	// error.details or Azure's error.details.
+	Details []interface{}
+	// Body is the raw response body, truncated to 1 MiB.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)))
+	if e.Code != "" {
+		sb.WriteString(": " + e.Code)
+	}
+	if e.Message != "" {
+		sb.WriteString(": " + e.Message)
+	}
+	if e.RequestID != "" {
+		sb.WriteString(fmt.Sprintf(" (request id %s)", e.RequestID))
+	}
+	return sb.String()
+}
+
+// As returns the APIError in err's chain, if any.
+func As(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// Format locates the fields of a custom error body. Each path is a
+// JSONPath for JSON bodies or an XPath for XML bodies; fields left empty,
+// or whose path finds nothing, fall back to the built in formats.
+type Format struct {
+	Code      string `json:"code,omitempty" yaml:"code,omitempty"`
+	Message   string `json:"message,omitempty" yaml:"message,omitempty"`
+	RequestID string `json:"request_id,omitempty" yaml:"request_id,omitempty"`
+	// RetryableCodes are further error codes marking an error retryable.
+	RetryableCodes []string `json:"retryable_codes,omitempty" yaml:"retryable_codes,omitempty"`
+}
+
+// FromResponse reads a non-2xx response into an APIError, restoring the
+// body so the response can still be consumed. It returns false for
+// successful responses. format may be nil.
+func FromResponse(resp *http.Response, format *Format) (*APIError, bool) {
+	if resp == nil || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil, false
+	}
+	var body []byte
+	if resp.Body != nil {
+		buf, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		rest := resp.Body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), rest), rest}
+		body = buf
+	}
+	return Parse(resp.StatusCode, resp.Header, body, format), true
+}
+
+// Parse builds an APIError from a status code, headers and body. format
+// may be nil.
+func Parse(statusCode int, header http.Header, body []byte, format *Format) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		Format:     FormatUnknown,
+		Body:       body,
+	}
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+		var doc interface{}
+		if json.Unmarshal(trimmed, &doc) == nil {
+			parseJSON(e, header, doc)
+			if format != nil {
+				applyJSONFormat(e, doc, format)
+			}
+		}
+	case len(trimmed) > 0 && trimmed[0] == '<':
+		if doc, err := xmlquery.Parse(bytes.NewReader(trimmed)); err == nil {
+			parseXML(e, doc)
+			if format != nil {
+				applyXMLFormat(e, doc, format)
+			}
+		}
+	}
+	if e.Code == "" && header != nil {
+		// AWS REST JSON services name the error in a header.
+		e.Code = awsErrorType(header.Get("X-Amzn-Errortype"))
+	}
+	if e.RequestID == "" && header != nil {
+		for _, h := range requestIDHeaders {
+			if v := header.Get(h); v != "" {
+				e.RequestID = v
+				break
+			}
+		}
+	}
+	e.Retryable = isRetryable(statusCode, e.Code, format)
+	return e
+}
+
+func isRetryable(statusCode int, code string, format *Format) bool {
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	case statusCode >= http.StatusInternalServerError && statusCode != http.StatusNotImplemented:
+		return true
+	}
+	if _, ok := defaultRetryableCodes[code]; ok {
+		return true
+	}
+	if format != nil {
+		for _, c := range format.RetryableCodes {
+			if c == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//nolint:gocognit,funlen // one branch per envelope
+func parseJSON(e *APIError, header http.Header, doc interface{}) {
+	obj, isObject := doc.(map[string]interface{})
+	if !isObject {
+		e.Format = FormatJSON
+		return
+	}
+	contentType := ""
+	if header != nil {
+		contentType = strings.ToLower(header.Get("Content-Type"))
+	}
+	if strings.Contains(contentType, "problem+json") || (hasString(obj, "title") && (hasString(obj, "type") || hasString(obj, "detail"))) {
+		e.Format = FormatProblem
+		if t := stringAt(obj, "type"); t != "about:blank" {
+			e.Code = t
+		}
+		e.Message = firstNonEmpty(stringAt(obj, "detail"), stringAt(obj, "title"))
+		e.RequestID = stringAt(obj, "instance")
+		return
+	}
+	if inner, hasInner := obj["error"].(map[string]interface{}); hasInner {
+		details, _ := inner["details"].([]interface{})
+		e.Details = details
+		e.Message = stringAt(inner, "message")
+		if _, isNumeric := inner["code"].(float64); isNumeric || hasString(inner, "status") {
+			e.Format = FormatGoogle
+			e.Code = stringAt(inner, "status")
+			if errs, hasErrs := inner["errors"].([]interface{}); hasErrs && e.Code == "" && len(errs) > 0 {
+				// Older Google APIs give a reason per error instead of a status.
+				if first, isObject := errs[0].(map[string]interface{}); isObject {
+					e.Code = stringAt(first, "reason")
+				}
+			}
+			return
+		}
+		e.Format = FormatAzure
+		e.Code = stringAt(inner, "code")
+		return
+	}
+	if hasString(obj, "error") {
+		e.Format = FormatOAuth
+		e.Code = stringAt(obj, "error")
+		e.Message = stringAt(obj, "error_description")
+		return
+	}
+	if hasString(obj, "__type") {
+		e.Format = FormatAWSJSON
+		e.Code = awsErrorType(stringAt(obj, "__type"))
+		e.Message = firstNonEmpty(stringAt(obj, "message"), stringAt(obj, "Message"))
+		return
+	}
+	e.Format = FormatJSON
+	e.Code = firstNonEmpty(stringAt(obj, "code"), stringAt(obj, "Code"), stringAt(obj, "errorCode"))
+	e.Message = firstNonEmpty(stringAt(obj, "message"), stringAt(obj, "Message"), stringAt(obj, "errorMessage"))
+	e.RequestID = firstNonEmpty(stringAt(obj, "requestId"), stringAt(obj, "RequestId"), stringAt(obj, "request_id"))
+}
+
+// parseXML reads the AWS XML envelopes: S3's bare Error, the query
+// APIs' ErrorResponse and EC2's Response/Errors.
+func parseXML(e *APIError, doc *xmlquery.Node) {
+	errNode := xmlquery.FindOne(doc, "//Error")
+	if errNode == nil {
+		return
+	}
+	e.Format = FormatAWSXML
+	e.Code = xmlText(errNode, "Code")
+	e.Message = xmlText(errNode, "Message")
+	e.RequestID = firstNonEmpty(xmlText(errNode, "RequestId"), xmlText(doc, "//RequestId"), xmlText(doc, "//RequestID"))
+}
+
+func applyJSONFormat(e *APIError, doc interface{}, format *Format) {
+	lookup := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		v, err := jsonpath.Get(path, doc)
+		if err != nil || v == nil {
+			return ""
+		}
+		if s, isString := v.(string); isString {
+			return s
+		}
+		return strings.Trim(fmt.Sprintf("%v", v), "[]")
+	}
+	applyFormat(e, format, lookup)
+}
+
+func applyXMLFormat(e *APIError, doc *xmlquery.Node, format *Format) {
+	applyFormat(e, format, func(path string) string {
+		if path == "" {
+			return ""
+		}
+		return xmlText(doc, path)
+	})
+}
+
+func applyFormat(e *APIError, format *Format, lookup func(string) string) {
+	matched := false
+	for _, f := range []struct {
+		path   string
+		target *string
+	}{
+		{format.Code, &e.Code},
+		{format.Message, &e.Message},
+		{format.RequestID, &e.RequestID},
+	} {
+		if v := lookup(f.path); v != "" {
+			*f.target = v
+			matched = true
+		}
+	}
+	if matched {
+		e.Format = FormatCustom
+	}
+}
+
+func xmlText(node *xmlquery.Node, expr string) string {
+	n, err := xmlquery.Query(node, expr)
+	if err != nil || n == nil {
+		return ""
+	}
+	return strings.TrimSpace(n.InnerText())
+}
+
+// awsErrorType strips the namespace and documentation suffix from an AWS
+// error type such as "com.amazonaws.dynamodb.v20120810#ThrottlingException"
+// or "ValidationException:http://internal.amazon.com/coral/".
+func awsErrorType(s string) string {
+	if i := strings.LastIndex(s, "#"); i >= 0 {
+		s = s[i+1:]
+	}
+	if i := strings.Index(s, ":"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+func hasString(obj map[string]interface{}, key string) bool {
+	return stringAt(obj, key) != ""
+}
+
+func stringAt(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParse_Formats(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		status      int
+		header      http.Header
+		body        string
+		format      string
+		code        string
+		message     string
+		requestID   string
+		retryable   bool
+		detailCount int
+	}{
+		{
+			name:   "problem",
+			status: http.StatusConflict,
+			header: http.Header{"Content-Type": []string{"application/problem+json"}},
+			body: `{"type": "https://example.com/probs/out-of-credit", "title": "You do not have enough credit.",
+				"detail": "Your current balance is 30, but that costs 50.", "instance": "/account/12345/msgs/abc"}`,
+			format:    FormatProblem,
+			code:      "https://example.com/probs/out-of-credit",
+			message:   "Your current balance is 30, but that costs 50.",
+			requestID: "/account/12345/msgs/abc",
+		},
+		{
+			name:   "google",
+			status: http.StatusTooManyRequests,
+			body: `{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED",
+				"details": [{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED"}]}}`,
+			format:      FormatGoogle,
+			code:        "RESOURCE_EXHAUSTED",
+			message:     "Quota exceeded",
+			retryable:   true,
+			detailCount: 1,
+		},
+		{
+			name:    "google legacy",
+			status:  http.StatusNotFound,
+			body:    `{"error": {"code": 404, "message": "The resource was not found", "errors": [{"reason": "notFound"}]}}`,
+			format:  FormatGoogle,
+			code:    "notFound",
+			message: "The resource was not found",
+		},
+		{
+			name:      "azure",
+			status:    http.StatusNotFound,
+			header:    http.Header{"X-Ms-Request-Id": []string{"5d2c1a30"}},
+			body:      `{"error": {"code": "ResourceGroupNotFound", "message": "Resource group 'rg' could not be found."}}`,
+			format:    FormatAzure,
+			code:      "ResourceGroupNotFound",
+			message:   "Resource group 'rg' could not be found.",
+			requestID: "5d2c1a30",
+		},
+		{
+			name:    "oauth",
+			status:  http.StatusBadRequest,
+			body:    `{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`,
+			format:  FormatOAuth,
+			code:    "invalid_grant",
+			message: "Token has been expired or revoked.",
+		},
+		{
+			name:      "aws json",
+			status:    http.StatusBadRequest,
+			header:    http.Header{"X-Amzn-Requestid": []string{"7a62c49f"}},
+			body:      `{"__type": "com.amazonaws.dynamodb.v20120810#ThrottlingException", "message": "Rate exceeded"}`,
+			format:    FormatAWSJSON,
+			code:      "ThrottlingException",
+			message:   "Rate exceeded",
+			requestID: "7a62c49f",
+			retryable: true,
+		},
+		{
+			name:    "aws rest json header",
+			status:  http.StatusBadRequest,
+			header:  http.Header{"X-Amzn-Errortype": []string{"ValidationException:http://internal.amazon.com/coral/com.amazon.coral.validate/"}},
+			body:    `{"message": "1 validation error detected"}`,
+			format:  FormatJSON,
+			code:    "ValidationException",
+			message: "1 validation error detected",
+		},
+		{
+			name:   "aws s3 xml",
+			status: http.StatusNotFound,
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message><RequestId>4442587FB7D0A2F9</RequestId></Error>`,
+			format:    FormatAWSXML,
+			code:      "NoSuchBucket",
+			message:   "The specified bucket does not exist",
+			requestID: "4442587FB7D0A2F9",
+		},
+		{
+			name:   "aws query xml",
+			status: http.StatusBadRequest,
+			body: `<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><Error><Type>Sender</Type>
+<Code>Throttling</Code><Message>Rate exceeded</Message></Error><RequestId>a8b3f2e1</RequestId></ErrorResponse>`,
+			format:    FormatAWSXML,
+			code:      "Throttling",
+			message:   "Rate exceeded",
+			requestID: "a8b3f2e1",
+			retryable: true,
+		},
+		{
+			name:   "aws ec2 xml",
+			status: http.StatusBadRequest,
+			body: `<Response><Errors><Error><Code>InvalidInstanceID.NotFound</Code>
+<Message>The instance ID 'i-1a2b3c4d' does not exist</Message></Error></Errors><RequestID>ea966190</RequestID></Response>`,
+			format:    FormatAWSXML,
+			code:      "InvalidInstanceID.NotFound",
+			message:   "The instance ID 'i-1a2b3c4d' does not exist",
+			requestID: "ea966190",
+		},
+		{
+			name:      "text",
+			status:    http.StatusBadGateway,
+			header:    http.Header{"X-Request-Id": []string{"r-1"}},
+			body:      "upstream connect error",
+			format:    FormatUnknown,
+			requestID: "r-1",
+			retryable: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := Parse(tc.status, tc.header, []byte(tc.body), nil)
+			if e.Format != tc.format || e.Code != tc.code || e.Message != tc.message || e.RequestID != tc.requestID {
+				t.Fatalf("unexpected error %+v", e)
+			}
+			if e.Retryable != tc.retryable || len(e.Details) != tc.detailCount || e.StatusCode != tc.status {
+				t.Fatalf("unexpected error %+v", e)
+			}
+		})
+	}
+}
+
+func TestParse_CustomFormat(t *testing.T) {
+	format := &Format{
+		Code:           "$.fault.faultCode",
+		Message:        "$.fault.faultString",
+		RequestID:      "$.meta.trace",
+		RetryableCodes: []string{"BUSY"},
+	}
+	e := Parse(http.StatusConflict, nil, []byte(`{"fault": {"faultCode": "BUSY", "faultString": "try later"}, "meta": {"trace": "t-9"}}`), format)
+	if e.Format != FormatCustom || e.Code != "BUSY" || e.Message != "try later" || e.RequestID != "t-9" || !e.Retryable {
+		t.Fatalf("unexpected error %+v", e)
+	}
+
+	xmlFormat := &Format{Code: "//fault/@code", Message: "//fault"}
+	e = Parse(http.StatusBadRequest, nil, []byte(`<fault code="E42">bad input</fault>`), xmlFormat)
+	if e.Format != FormatCustom || e.Code != "E42" || e.Message != "bad input" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+
+	// Paths that find nothing leave the built in parse in place.
+	e = Parse(http.StatusNotFound, nil, []byte(`{"error": {"code": "NotFound", "message": "gone"}}`), format)
+	if e.Format != FormatAzure || e.Code != "NotFound" || e.Message != "gone" {
+		t.Fatalf("unexpected error %+v", e)
+	}
+}
+
+func TestFromResponse_RestoresBody(t *testing.T) {
+	body := `{"error": {"code": "Conflict", "message": "already exists"}}`
+	resp := &http.Response{
+		StatusCode: http.StatusConflict,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	e, isError := FromResponse(resp, nil)
+	if !isError || e.Code != "Conflict" || string(e.Body) != body {
+		t.Fatalf("unexpected error %+v", e)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil || string(rest) != body {
+		t.Fatalf("expected the body to be restored, got %q %v", rest, err)
+	}
+	if _, isError = FromResponse(&http.Response{StatusCode: http.StatusOK}, nil); isError {
+		t.Fatalf("expected no error for a successful response")
+	}
+}
+
+func TestAs(t *testing.T) {
+	e := Parse(http.StatusNotFound, nil, []byte(`{"error": {"code": 404, "message": "Not found", "status": "NOT_FOUND"}}`), nil)
+	if e.Error() != "404 Not Found: NOT_FOUND: Not found" {
+		t.Fatalf("unexpected message %q", e.Error())
+	}
+	wrapped := fmt.Errorf("select over HTTP error: %w", e)
+	got, ok := As(wrapped)
+	if !ok || got.Code != "NOT_FOUND" {
+		t.Fatalf("expected the APIError to be found in %v", wrapped)
+	}
+	if _, ok = As(errors.New("plain")); ok {
+		t.Fatalf("expected no APIError in a plain error")
+	}
+}
@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Redacted replaces the values of sensitive parameters.
+	Redacted = "REDACTED"
+)
+
+var (
+	mutatingVerbs = map[string]struct{}{ //nolint:gochecknoglobals // read-only table
+		"INSERT":  {},
+		"UPDATE":  {},
+		"REPLACE": {},
+		"DELETE":  {},
+		"EXEC":    {},
+	}
+
+	// defaultRedact names parameters whose values never reach the log.
+	// Names are compared case insensitively, at any depth.
+	defaultRedact = []string{ //nolint:gochecknoglobals // read-only default
+		"password",
+		"secret",
+		"client_secret",
+		"token",
+		"access_token",
+		"refresh_token",
+		"api_key",
+		"apikey",
+		"private_key",
+		"credentials",
+		"authorization",
+	}
+
+	// requestIDHeaders are response headers that carry a provider request
+	// id, in canonical form.
+	requestIDHeaders = []string{ //nolint:gochecknoglobals // read-only table
+		"X-Request-Id",
+		"Request-Id",
+		"X-Amz-Request-Id",
+		"X-Amzn-Requestid",
+		"X-Amz-Id-2",
+		"X-Ms-Request-Id",
+		"X-Ms-Correlation-Request-Id",
+		"X-Goog-Request-Id",
+		"X-Github-Request-Id",
+		"X-Correlation-Id",
+		"Apigw-Requestid",
+		"Cf-Ray",
+	}
+
+	fileSinksMu sync.Mutex                   //nolint:gochecknoglobals // file sinks are shared by path
+	fileSinks   = make(map[string]*fileSink) //nolint:gochecknoglobals // file sinks are shared by path
+)
+
+// Record is one audited call, written as a single JSON line.
+type Record struct {
+	Timestamp  time.Time              `json:"timestamp"`
+	Provider   string                 `json:"provider,omitempty"`
+	Service    string                 `json:"service,omitempty"`
+	Resource   string                 `json:"resource,omitempty"`
+	Method     string                 `json:"method,omitempty"`
+	SQLVerb    string                 `json:"sql_verb"`
+	Protocol   string                 `json:"protocol,omitempty"`
+	HTTPMethod string                 `json:"http_method,omitempty"`
+	URL        string                 `json:"url,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	StatusCode int                    `json:"status_code,omitempty"`
+	RequestIDs map[string]string      `json:"request_ids,omitempty"`
+	DurationMs float64                `json:"duration_ms"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Sink persists audit records. Implementations must be safe for concurrent
+// use.
+type Sink interface {
+	Write(Record) error
+}
+
+// IsMutatingVerb reports whether a SQL verb changes provider state, and so
+// is audited.
+func IsMutatingVerb(sqlVerb string) bool {
+	_, ok := mutatingVerbs[strings.ToUpper(sqlVerb)]
+	return ok
+}
+
+type jsonLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesSink writes each record to w as one line of JSON.
+func NewJSONLinesSink(w io.Writer) Sink {
+	return &jsonLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonLinesSink) Write(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(r)
+}
+
+type fileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFileSink returns a JSON lines sink appending to the file at path,
+// created if absent. Sinks are shared by path, so concurrent clients
+// never interleave partial lines. Each record is synced to disk before
+// Write returns.
+func OpenFileSink(path string) (Sink, error) {
+	key := filepath.Clean(path)
+	fileSinksMu.Lock()
+	defer fileSinksMu.Unlock()
+	if s, ok := fileSinks[key]; ok {
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(key), 0o750); err != nil { //nolint:mnd // conventional permissions
+		return nil, err
+	}
+	f, err := os.OpenFile(key, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:mnd // owner only
+	if err != nil {
+		return nil, err
+	}
+	s := &fileSink{f: f}
+	fileSinks[key] = s
+	return s, nil
+}
+
+func (s *fileSink) Write(r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// Redactor replaces the values of sensitive parameters.
+type Redactor struct {
+	names map[string]struct{}
+}
+
+// NewRedactor redacts the built in sensitive names plus extra.
+func NewRedactor(extra ...string) *Redactor {
+	names := make(map[string]struct{}, len(defaultRedact)+len(extra))
+	for _, n := range append(append([]string{}, defaultRedact...), extra...) {
+		names[strings.ToLower(n)] = struct{}{}
+	}
+	return &Redactor{names: names}
+}
+
+func (rd *Redactor) isRedacted(name string) bool {
+	_, ok := rd.names[strings.ToLower(name)]
+	return ok
+}
+
+// RedactParameters returns a copy of params with sensitive values replaced,
+// at any depth.
+func (rd *Redactor) RedactParameters(params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	rv, _ := rd.redactValue(params).(map[string]interface{})
+	return rv
+}
+
+func (rd *Redactor) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		rv := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			if rd.isRedacted(k) {
+				rv[k] = Redacted
+				continue
+			}
+			rv[k] = rd.redactValue(child)
+		}
+		return rv
+	case []interface{}:
+		rv := make([]interface{}, len(t))
+		for i, child := range t {
+			rv[i] = rd.redactValue(child)
+		}
+		return rv
+	default:
+		return v
+	}
+}
+
+// RedactURL returns u with sensitive query parameter values and any user
+// info replaced.
+func (rd *Redactor) RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	cp := *u
+	if cp.User != nil {
+		cp.User = url.User(Redacted)
+	}
+	q := cp.Query()
+	changed := false
+	for k, v := range q {
+		if rd.isRedacted(k) {
+			for i := range v {
+				v[i] = Redacted
+			}
+			changed = true
+		}
+	}
+	if changed {
+		cp.RawQuery = q.Encode()
+	}
+	return cp.String()
+}
+
+// GetRequestIDs returns the provider request ids carried by h.
+func GetRequestIDs(h http.Header) map[string]string {
+	var rv map[string]string
+	for _, k := range requestIDHeaders {
+		if v := h.Get(k); v != "" {
+			if rv == nil {
+				rv = make(map[string]string)
+			}
+			rv[k] = v
+		}
+	}
+	return rv
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsMutatingVerb(t *testing.T) {
+	for _, v := range []string{"insert", "UPDATE", "replace", "delete", "exec"} {
+		if !IsMutatingVerb(v) {
+			t.Fatalf("expected %s to be mutating", v)
+		}
+	}
+	if IsMutatingVerb("select") || IsMutatingVerb("") {
+		t.Fatalf("expected select to be read only")
+	}
+}
+
+func TestRedactParametersAtAnyDepth(t *testing.T) {
+	rd := NewRedactor("ssh_key")
+	params := map[string]interface{}{
+		"name":     "vm1",
+		"Password": "hunter2",
+		"body": map[string]interface{}{
+			"ssh_key": "AAAA",
+			"items":   []interface{}{map[string]interface{}{"client_secret": "s"}},
+		},
+	}
+	got := rd.RedactParameters(params)
+	if got["name"] != "vm1" || got["Password"] != Redacted {
+		t.Fatalf("unexpected top level %+v", got)
+	}
+	body := got["body"].(map[string]interface{})
+	item := body["items"].([]interface{})[0].(map[string]interface{})
+	if body["ssh_key"] != Redacted || item["client_secret"] != Redacted {
+		t.Fatalf("unexpected nested %+v", body)
+	}
+	if params["Password"] != "hunter2" {
+		t.Fatalf("expected the input to be left unchanged")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	u, _ := url.Parse("https://user:pw@example.com/v1/things?api_key=abc&zone=a")
+	got := NewRedactor().RedactURL(u)
+	if strings.Contains(got, "abc") || strings.Contains(got, "pw") || !strings.Contains(got, "zone=a") {
+		t.Fatalf("unexpected url %s", got)
+	}
+}
+
+func TestGetRequestIDs(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-amz-request-id", "r1")
+	h.Set("Content-Type", "application/json")
+	ids := GetRequestIDs(h)
+	if len(ids) != 1 || ids["X-Amz-Request-Id"] != "r1" {
+		t.Fatalf("unexpected ids %+v", ids)
+	}
+	if GetRequestIDs(http.Header{}) != nil {
+		t.Fatalf("expected no ids")
+	}
+}
+
+func TestFileSinkAppendsAndIsShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	s1, err := OpenFileSink(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s2, _ := OpenFileSink(path)
+	if s1 != s2 {
+		t.Fatalf("expected sinks to be shared by path")
+	}
+	for _, verb := range []string{"insert", "delete"} {
+		if err := s1.Write(Record{SQLVerb: verb, Provider: "google"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	var verbs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("bad line %q: %v", sc.Text(), err)
+		}
+		verbs = append(verbs, r.SQLVerb)
+	}
+	if strings.Join(verbs, ",") != "insert,delete" {
+		t.Fatalf("unexpected records %v", verbs)
+	}
+}
@@ -0,0 +1,511 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// ModeRecord sends requests upstream and writes each interaction to the
+	// cassette, replacing any earlier recording.
+	ModeRecord = "record"
+	// ModeReplay serves interactions from the cassette and never sends
+	// requests upstream.
+	ModeReplay = "replay"
+
+	MatchMethod = "method"
+	MatchHost   = "host"
+	MatchPath   = "path"
+	MatchQuery  = "query"
+	MatchBody   = "body"
+
+	// Redacted replaces scrubbed values.
+	Redacted = "REDACTED"
+
+	bodyEncodingBase64 = "base64"
+	cassetteVersion    = 1
+)
+
+var (
+	_ http.RoundTripper = &transport{}
+
+	// ErrNoInteraction is returned in replay mode when no unused recorded
+	// interaction matches a request.
+	ErrNoInteraction = errors.New("no matching cassette interaction")
+
+	defaultMatchOn = []string{ //nolint:gochecknoglobals // read-only default
+		MatchMethod,
+		MatchHost,
+		MatchPath,
+		MatchQuery,
+		MatchBody,
+	}
+
+	// defaultScrub names credentials that are redacted wherever they appear:
+	// request and response headers, query parameters, and JSON or form body
+	// fields. Names are compared case insensitively.
+	defaultScrub = []string{ //nolint:gochecknoglobals // read-only default
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Goog-Api-Key",
+		"X-Amz-Security-Token",
+		"X-Amz-Signature",
+		"X-Amz-Credential",
+		"access_token",
+		"refresh_token",
+		"id_token",
+		"client_secret",
+		"password",
+		"api_key",
+		"apikey",
+	}
+
+	registryMu sync.Mutex                   //nolint:gochecknoglobals // cassettes are shared by path
+	registry   = make(map[string]*Cassette) //nolint:gochecknoglobals // cassettes are shared by path
+)
+
+// Config selects a cassette file and how requests are matched and scrubbed.
+type Config struct {
+	// Path is the cassette file.
+	Path string
+	// Mode is ModeRecord or ModeReplay.
+	Mode string
+	// MatchOn lists the request attributes compared in replay mode, from
+	// the Match* values. Defaults to all of them.
+	MatchOn []string
+	// Scrub names further headers, query parameters and body fields to
+	// redact, in addition to the built in credential names.
+	Scrub []string
+}
+
+func (c Config) getMatchOn() []string {
+	if len(c.MatchOn) == 0 {
+		return defaultMatchOn
+	}
+	return c.MatchOn
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+type cassetteFile struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Cassette holds the interactions of one cassette file. It is safe for
+// concurrent use.
+type Cassette struct {
+	mu           sync.Mutex
+	cfg          Config
+	scrub        map[string]struct{}
+	interactions []*Interaction
+	used         []bool
+}
+
+// Open returns the cassette for cfg. Cassettes are shared by path and mode,
+// so that every client built for a session records to, or replays from,
+// the same interactions. In replay mode the file must exist.
+func Open(cfg Config) (*Cassette, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("cassette path is required")
+	}
+	if cfg.Mode != ModeRecord && cfg.Mode != ModeReplay {
+		return nil, fmt.Errorf("unsupported cassette mode '%s'", cfg.Mode)
+	}
+	for _, m := range cfg.MatchOn {
+		switch m {
+		case MatchMethod, MatchHost, MatchPath, MatchQuery, MatchBody:
+		default:
+			return nil, fmt.Errorf("unsupported cassette match rule '%s'", m)
+		}
+	}
+	registryKey := cfg.Mode + ":" + filepath.Clean(cfg.Path)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if c, ok := registry[registryKey]; ok {
+		return c, nil
+	}
+	c := newCassette(cfg)
+	if cfg.Mode == ModeReplay {
+		b, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		var f cassetteFile
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("cassette '%s': %w", cfg.Path, err)
+		}
+		c.interactions = f.Interactions
+		c.used = make([]bool, len(f.Interactions))
+	}
+	registry[registryKey] = c
+	return c, nil
+}
+
+func newCassette(cfg Config) *Cassette {
+	scrub := make(map[string]struct{}, len(defaultScrub)+len(cfg.Scrub))
+	for _, s := range append(append([]string{}, defaultScrub...), cfg.Scrub...) {
+		scrub[strings.ToLower(s)] = struct{}{}
+	}
+	return &Cassette{
+		cfg:   cfg,
+		scrub: scrub,
+	}
+}
+
+// GetInteractions returns the interactions recorded or loaded so far.
+func (c *Cassette) GetInteractions() []*Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Interaction{}, c.interactions...)
+}
+
+type transport struct {
+	next     http.RoundTripper
+	cassette *Cassette
+}
+
+// NewTransport returns a RoundTripper that records to, or replays from, c.
+// In record mode requests are sent through next.
+func NewTransport(next http.RoundTripper, c *Cassette) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{
+		next:     next,
+		cassette: c,
+	}
+}
+
+// IsTransport reports whether rt is a cassette transport.
+func IsTransport(rt http.RoundTripper) bool {
+	_, ok := rt.(*transport)
+	return ok
+}
+
+// GetTransport returns the wrapped RoundTripper.
+func (t *transport) GetTransport() http.RoundTripper {
+	return t.next
+}
+
+// WithTransport returns a copy of the transport wrapping next instead.
+func (t *transport) WithTransport(next http.RoundTripper) http.RoundTripper {
+	return NewTransport(next, t.cassette)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := t.cassette.newRequest(req, reqBody)
+	if t.cassette.cfg.Mode == ModeReplay {
+		interaction, found := t.cassette.take(recorded)
+		if !found {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+		}
+		return interaction.Response.toHTTPResponse(req)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	var respBody []byte
+	if resp.Body != nil {
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	}
+	if err := t.cassette.record(&Interaction{
+		Request:  recorded,
+		Response: t.cassette.newResponse(resp, respBody),
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func (c *Cassette) newRequest(req *http.Request, body []byte) Request {
+	u := *req.URL
+	u.RawQuery = c.scrubQuery(u.Query())
+	encoded, encoding := encodeBody(c.scrubBody(body, req.Header.Get("Content-Type")))
+	return Request{
+		Method:       req.Method,
+		URL:          u.String(),
+		Headers:      c.scrubHeaders(req.Header),
+		Body:         encoded,
+		BodyEncoding: encoding,
+	}
+}
+
+func (c *Cassette) newResponse(resp *http.Response, body []byte) Response {
+	encoded, encoding := encodeBody(c.scrubBody(body, resp.Header.Get("Content-Type")))
+	return Response{
+		StatusCode:   resp.StatusCode,
+		Headers:      c.scrubHeaders(resp.Header),
+		Body:         encoded,
+		BodyEncoding: encoding,
+	}
+}
+
+func (r Response) toHTTPResponse(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(r.Body, r.BodyEncoding)
+	if err != nil {
+		return nil, err
+	}
+	header := r.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// take returns the first unused interaction matching r, and marks it used,
+// so that repeated identical requests such as polling replay in order.
+func (c *Cassette) take(r Request) (*Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, candidate := range c.interactions {
+		if c.used[i] || !c.matches(candidate.Request, r) {
+			continue
+		}
+		c.used[i] = true
+		return candidate, true
+	}
+	return nil, false
+}
+
+func (c *Cassette) record(interaction *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, interaction)
+	return c.save()
+}
+
+// save writes the whole cassette through a temporary file, so that an
+// interrupted session leaves the previous complete recording.
+func (c *Cassette) save() error {
+	b, err := json.MarshalIndent(cassetteFile{Version: cassetteVersion, Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.cfg.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd // conventional permissions
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(c.cfg.Path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.cfg.Path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (c *Cassette) matches(recorded, incoming Request) bool {
+	ru, rErr := url.Parse(recorded.URL)
+	iu, iErr := url.Parse(incoming.URL)
+	if rErr != nil || iErr != nil {
+		return false
+	}
+	for _, m := range c.cfg.getMatchOn() {
+		switch m {
+		case MatchMethod:
+			if recorded.Method != incoming.Method {
+				return false
+			}
+		case MatchHost:
+			if ru.Host != iu.Host {
+				return false
+			}
+		case MatchPath:
+			if ru.Path != iu.Path {
+				return false
+			}
+		case MatchQuery:
+			if ru.Query().Encode() != iu.Query().Encode() {
+				return false
+			}
+		case MatchBody:
+			if canonicalBody(recorded) != canonicalBody(incoming) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canonicalBody compares JSON bodies irrespective of key order and
+// whitespace, and anything else byte for byte.
+func canonicalBody(r Request) string {
+	b, err := decodeBody(r.Body, r.BodyEncoding)
+	if err != nil {
+		return r.Body
+	}
+	var v interface{}
+	if json.Unmarshal(b, &v) == nil {
+		if canonical, marshalErr := json.Marshal(v); marshalErr == nil {
+			return string(canonical)
+		}
+	}
+	return string(b)
+}
+
+func (c *Cassette) isScrubbed(name string) bool {
+	_, ok := c.scrub[strings.ToLower(name)]
+	return ok
+}
+
+func (c *Cassette) scrubHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	rv := h.Clone()
+	for k, v := range rv {
+		if c.isScrubbed(k) {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+	}
+	return rv
+}
+
+func (c *Cassette) scrubQuery(q url.Values) string {
+	for k, v := range q {
+		if c.isScrubbed(k) {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+	}
+	return q.Encode()
+}
+
+// scrubBody redacts fields of JSON and form bodies. Other bodies are
+// returned unchanged.
+func (c *Cassette) scrubBody(body []byte, contentType string) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	if strings.HasPrefix(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+		if q, err := url.ParseQuery(string(body)); err == nil {
+			return []byte(c.scrubQuery(q))
+		}
+		return body
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	if !c.scrubJSON(v) {
+		return body
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return b
+}
+
+func (c *Cassette) scrubJSON(v interface{}) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if c.isScrubbed(k) {
+				t[k] = Redacted
+				changed = true
+				continue
+			}
+			changed = c.scrubJSON(child) || changed
+		}
+	case []interface{}:
+		for _, child := range t {
+			changed = c.scrubJSON(child) || changed
+		}
+	}
+	return changed
+}
+
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), bodyEncodingBase64
+}
+
+func decodeBody(s string, encoding string) ([]byte, error) {
+	if encoding == bodyEncodingBase64 {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return []byte(s), nil
+}
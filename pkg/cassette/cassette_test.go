@@ -0,0 +1,125 @@
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func do(t *testing.T, client *http.Client, method, url, contentType, body string) (int, string, error) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b), nil
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"access_token":"live-token","expires_in":3600}`)
+			return
+		}
+		fmt.Fprintf(w, `{"page":%q,"call":%d}`, r.URL.Query().Get("pageToken"), n)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassettes", "list.json")
+
+	rec, err := Open(Config{Path: path, Mode: ModeRecord, Scrub: []string{"key"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recClient := &http.Client{Transport: NewTransport(nil, rec)}
+	do(t, recClient, http.MethodPost, srv.URL+"/token", "application/x-www-form-urlencoded", "grant_type=client_credentials&client_secret=s3cr3t")
+	_, first, _ := do(t, recClient, http.MethodGet, srv.URL+"/items?key=abc", "", "")
+	_, second, _ := do(t, recClient, http.MethodGet, srv.URL+"/items?key=abc&pageToken=p2", "", "")
+	_, poll1, _ := do(t, recClient, http.MethodPost, srv.URL+"/items", "application/json", `{"a":1,"b":2}`)
+	_, poll2, _ := do(t, recClient, http.MethodPost, srv.URL+"/items", "application/json", `{"a":1,"b":2}`)
+
+	raw, _ := os.ReadFile(path)
+	for _, leaked := range []string{"secret-token", "s3cr3t", "live-token", "session=abc", "key=abc"} {
+		if strings.Contains(string(raw), leaked) {
+			t.Fatalf("cassette leaks %q:\n%s", leaked, raw)
+		}
+	}
+
+	srv.Close()
+	rep, err := Open(Config{Path: path, Mode: ModeReplay, Scrub: []string{"key"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repClient := &http.Client{Transport: NewTransport(nil, rep)}
+	_, tok, _ := do(t, repClient, http.MethodPost, srv.URL+"/token", "application/x-www-form-urlencoded", "client_secret=other&grant_type=client_credentials")
+	if !strings.Contains(tok, Redacted) {
+		t.Fatalf("expected a scrubbed token, got %s", tok)
+	}
+	// out of order, and with a different key, still matches the scrubbed recording
+	_, gotSecond, _ := do(t, repClient, http.MethodGet, srv.URL+"/items?pageToken=p2&key=xyz", "", "")
+	_, gotFirst, _ := do(t, repClient, http.MethodGet, srv.URL+"/items?key=abc", "", "")
+	_, gotPoll1, _ := do(t, repClient, http.MethodPost, srv.URL+"/items", "application/json", `{"b":2, "a":1}`)
+	_, gotPoll2, _ := do(t, repClient, http.MethodPost, srv.URL+"/items", "application/json", `{"a":1,"b":2}`)
+	if gotFirst != first || gotSecond != second || gotPoll1 != poll1 || gotPoll2 != poll2 {
+		t.Fatalf("replay mismatch: %s %s %s %s", gotFirst, gotSecond, gotPoll1, gotPoll2)
+	}
+	_, _, err = do(t, repClient, http.MethodPost, srv.URL+"/items", "application/json", `{"a":1,"b":2}`)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("expected ErrNoInteraction once recordings are used up, got %v", err)
+	}
+}
+
+func TestReplayMatchRules(t *testing.T) {
+	c := newCassette(Config{Mode: ModeReplay, MatchOn: []string{MatchMethod, MatchPath}})
+	c.interactions = []*Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "https://a.example.com/v1/items?page=1"},
+		Response: Response{StatusCode: http.StatusNoContent},
+	}}
+	c.used = []bool{false}
+	client := &http.Client{Transport: NewTransport(nil, c)}
+	status, _, err := do(t, client, http.MethodGet, "https://b.example.com/v1/items?page=9", "", "")
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("expected a match ignoring host and query, got %d %v", status, err)
+	}
+}
+
+func TestOpenValidation(t *testing.T) {
+	if _, err := Open(Config{Path: "x.json", Mode: "sometimes"}); err == nil {
+		t.Fatalf("expected an error for an unknown mode")
+	}
+	if _, err := Open(Config{Path: "x.json", Mode: ModeRecord, MatchOn: []string{"headers"}}); err == nil {
+		t.Fatalf("expected an error for an unknown match rule")
+	}
+	if _, err := Open(Config{Path: filepath.Join(t.TempDir(), "missing.json"), Mode: ModeReplay}); err == nil {
+		t.Fatalf("expected an error for a missing cassette in replay mode")
+	}
+}
+
+func TestBinaryBodiesRoundTrip(t *testing.T) {
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	encoded, encoding := encodeBody(payload)
+	if encoding != bodyEncodingBase64 {
+		t.Fatalf("expected base64 encoding for binary content")
+	}
+	decoded, err := decodeBody(encoded, encoding)
+	if err != nil || string(decoded) != string(payload) {
+		t.Fatalf("unexpected round trip %v %v", decoded, err)
+	}
+}
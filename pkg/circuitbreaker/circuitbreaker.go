@@ -0,0 +1,341 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+
+	DefaultFailureRateThreshold = 0.5
+	DefaultMinimumRequests      = 10
+	DefaultWindowSize           = 20
+	DefaultCoolDown             = 30 * time.Second
+	DefaultHalfOpenMaxRequests  = 1
+)
+
+var (
+	_ Breaker  = &standardBreaker{}
+	_ Registry = &standardRegistry{}
+
+	// ErrOpen is matched (via errors.Is) by every error returned from Allow
+	// while the breaker rejects requests.
+	ErrOpen = errors.New("circuit breaker open")
+
+	defaultRegistry = NewRegistry() //nolint:gochecknoglobals // breakers must be shared process-wide to be useful
+)
+
+// Config describes a single breaker. Zero valued fields take the defaults.
+type Config struct {
+	// FailureRateThreshold in (0, 1]; the breaker opens once the failure
+	// rate over the window reaches it.
+	FailureRateThreshold float64
+	// MinimumRequests is the number of outcomes required in the window
+	// before the failure rate is evaluated.
+	MinimumRequests int
+	// WindowSize is the number of most recent outcomes considered.
+	WindowSize int
+	// CoolDown is how long the breaker stays open before admitting trial requests.
+	CoolDown time.Duration
+	// HalfOpenMaxRequests is the number of trial requests admitted, and
+	// required to succeed, before the breaker closes again.
+	HalfOpenMaxRequests int
+}
+
+func (c Config) withDefaults() Config {
+	if c.FailureRateThreshold <= 0 || c.FailureRateThreshold > 1 {
+		c.FailureRateThreshold = DefaultFailureRateThreshold
+	}
+	if c.WindowSize <= 0 {
+		c.WindowSize = DefaultWindowSize
+	}
+	if c.MinimumRequests <= 0 {
+		c.MinimumRequests = DefaultMinimumRequests
+	}
+	if c.MinimumRequests > c.WindowSize {
+		c.MinimumRequests = c.WindowSize
+	}
+	if c.CoolDown <= 0 {
+		c.CoolDown = DefaultCoolDown
+	}
+	if c.HalfOpenMaxRequests <= 0 {
+		c.HalfOpenMaxRequests = DefaultHalfOpenMaxRequests
+	}
+	return c
+}
+
+// OpenError is returned by Allow while the breaker is rejecting requests.
+type OpenError struct {
+	Key           string
+	NextAttemptAt time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s for '%s' until %s", ErrOpen.Error(), e.Key, e.NextAttemptAt.Format(time.RFC3339))
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Snapshot is a point-in-time view of a breaker, for diagnostics.
+type Snapshot struct {
+	Key           string
+	Config        Config
+	State         State
+	Requests      int
+	Failures      int
+	OpenedAt      time.Time
+	NextAttemptAt time.Time
+}
+
+// Breaker guards calls to one endpoint (typically a provider/host pair).
+//
+// Allow either admits a call, returning a func through which the caller
+// reports the outcome exactly once, or rejects it with an *OpenError.
+type Breaker interface {
+	Allow() (func(success bool), error)
+	GetState() State
+	GetConfig() Config
+	Snapshot() Snapshot
+}
+
+// Registry shares breakers across every caller in the process.
+type Registry interface {
+	GetBreaker(key string, cfg Config) Breaker
+	Snapshots() []Snapshot
+}
+
+type standardRegistry struct {
+	mutex    sync.Mutex
+	breakers map[string]*standardBreaker
+}
+
+func NewRegistry() Registry {
+	return &standardRegistry{
+		breakers: make(map[string]*standardBreaker),
+	}
+}
+
+// GetDefaultRegistry returns the process-wide registry.
+func GetDefaultRegistry() Registry {
+	return defaultRegistry
+}
+
+// GetBreaker returns the breaker for key, creating it on first use. A
+// changed config is applied to the live breaker in place, so its state and
+// recent outcomes carry over.
+func (r *standardRegistry) GetBreaker(key string, cfg Config) Breaker {
+	cfg = cfg.withDefaults()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if existing, ok := r.breakers[key]; ok {
+		existing.reconfigure(cfg)
+		return existing
+	}
+	rv := newBreaker(key, cfg, time.Now)
+	r.breakers[key] = rv
+	return rv
+}
+
+// Snapshots returns the state of every known breaker, ordered by key.
+func (r *standardRegistry) Snapshots() []Snapshot {
+	r.mutex.Lock()
+	breakers := make([]Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mutex.Unlock()
+	rv := make([]Snapshot, 0, len(breakers))
+	for _, b := range breakers {
+		rv = append(rv, b.Snapshot())
+	}
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Key < rv[j].Key })
+	return rv
+}
+
+type standardBreaker struct {
+	key   string
+	cfg   Config
+	now   func() time.Time
+	mutex sync.Mutex
+	state State
+	// outcomes is a ring buffer of the most recent results; true is a failure.
+	outcomes         []bool
+	next             int
+	count            int
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+	halfOpenSuccess  int
+	// generation invalidates outcome reports from calls admitted before the
+	// last state transition.
+	generation uint64
+}
+
+func NewBreaker(key string, cfg Config) Breaker {
+	return newBreaker(key, cfg.withDefaults(), time.Now)
+}
+
+func newBreaker(key string, cfg Config, now func() time.Time) *standardBreaker {
+	return &standardBreaker{
+		key:      key,
+		cfg:      cfg,
+		now:      now,
+		state:    StateClosed,
+		outcomes: make([]bool, cfg.WindowSize),
+	}
+}
+
+func (b *standardBreaker) GetConfig() Config {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.cfg
+}
+
+// reconfigure applies cfg, which has its defaults, in place. The state is
+// kept, and the window keeps its most recent outcomes, up to the new size.
+func (b *standardBreaker) reconfigure(cfg Config) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if cfg == b.cfg {
+		return
+	}
+	if cfg.WindowSize != len(b.outcomes) {
+		kept := b.count
+		if kept > cfg.WindowSize {
+			kept = cfg.WindowSize
+		}
+		outcomes := make([]bool, cfg.WindowSize)
+		failures := 0
+		for i := 0; i < kept; i++ {
+			idx := (b.next - kept + i + len(b.outcomes)) % len(b.outcomes)
+			outcomes[i] = b.outcomes[idx]
+			if outcomes[i] {
+				failures++
+			}
+		}
+		b.outcomes = outcomes
+		b.next = kept % cfg.WindowSize
+		b.count = kept
+		b.failures = failures
+	}
+	b.cfg = cfg
+}
+
+func (b *standardBreaker) GetState() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.advance()
+	return b.state
+}
+
+func (b *standardBreaker) Snapshot() Snapshot {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.advance()
+	rv := Snapshot{
+		Key:      b.key,
+		Config:   b.cfg,
+		State:    b.state,
+		Requests: b.count,
+		Failures: b.failures,
+	}
+	if b.state != StateClosed {
+		rv.OpenedAt = b.openedAt
+		rv.NextAttemptAt = b.openedAt.Add(b.cfg.CoolDown)
+	}
+	return rv
+}
+
+func (b *standardBreaker) Allow() (func(success bool), error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.advance()
+	switch b.state {
+	case StateOpen:
+		return nil, &OpenError{Key: b.key, NextAttemptAt: b.openedAt.Add(b.cfg.CoolDown)}
+	case StateHalfOpen:
+		if b.halfOpenInFlight+b.halfOpenSuccess >= b.cfg.HalfOpenMaxRequests {
+			return nil, &OpenError{Key: b.key, NextAttemptAt: b.now()}
+		}
+		b.halfOpenInFlight++
+	}
+	return b.doneFunc(b.generation), nil
+}
+
+func (b *standardBreaker) doneFunc(generation uint64) func(success bool) {
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			if generation != b.generation {
+				return
+			}
+			b.record(success)
+		})
+	}
+}
+
+// advance moves an open breaker to half-open once its cool-down has elapsed.
+func (b *standardBreaker) advance() {
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.cfg.CoolDown)) {
+		b.transition(StateHalfOpen)
+	}
+}
+
+func (b *standardBreaker) record(success bool) {
+	switch b.state {
+	case StateHalfOpen:
+		b.halfOpenInFlight--
+		if !success {
+			b.transition(StateOpen)
+			return
+		}
+		b.halfOpenSuccess++
+		if b.halfOpenSuccess >= b.cfg.HalfOpenMaxRequests {
+			b.transition(StateClosed)
+		}
+	case StateClosed:
+		if b.count == len(b.outcomes) && b.outcomes[b.next] {
+			b.failures--
+		}
+		b.outcomes[b.next] = !success
+		b.next = (b.next + 1) % len(b.outcomes)
+		if b.count < len(b.outcomes) {
+			b.count++
+		}
+		if !success {
+			b.failures++
+		}
+		if b.count >= b.cfg.MinimumRequests &&
+			float64(b.failures)/float64(b.count) >= b.cfg.FailureRateThreshold {
+			b.transition(StateOpen)
+		}
+	}
+}
+
+func (b *standardBreaker) transition(to State) {
+	b.state = to
+	b.generation++
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccess = 0
+	switch to {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateClosed:
+		for i := range b.outcomes {
+			b.outcomes[i] = false
+		}
+		b.next, b.count, b.failures = 0, 0, 0
+	}
+}
@@ -0,0 +1,137 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestBreaker(cfg Config) (*standardBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	return newBreaker("prov/host", cfg.withDefaults(), clock.now), clock
+}
+
+func call(t *testing.T, b Breaker, success bool) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected call to be admitted, got %v", err)
+	}
+	done(success)
+}
+
+func TestBreaker_OpensAtFailureRate(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureRateThreshold: 0.5, MinimumRequests: 4, WindowSize: 4})
+	call(t, b, true)
+	call(t, b, false)
+	call(t, b, true)
+	if b.GetState() != StateClosed {
+		t.Fatalf("expected closed below minimum requests")
+	}
+	call(t, b, false)
+	if b.GetState() != StateOpen {
+		t.Fatalf("expected open at 2/4 failures, got %s", b.GetState())
+	}
+	_, err := b.Allow()
+	var openErr *OpenError
+	if !errors.Is(err, ErrOpen) || !errors.As(err, &openErr) || openErr.Key != "prov/host" {
+		t.Fatalf("expected *OpenError matching ErrOpen, got %v", err)
+	}
+}
+
+func TestBreaker_WindowSlides(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureRateThreshold: 0.75, MinimumRequests: 4, WindowSize: 4})
+	call(t, b, false)
+	call(t, b, false)
+	call(t, b, true)
+	call(t, b, true)
+	// oldest failures slide out as successes arrive
+	call(t, b, true)
+	call(t, b, false)
+	if snap := b.Snapshot(); snap.State != StateClosed || snap.Failures != 1 || snap.Requests != 4 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+}
+
+func TestBreaker_HalfOpenRecoversOrReopens(t *testing.T) {
+	b, clock := newTestBreaker(Config{MinimumRequests: 1, WindowSize: 1, CoolDown: time.Minute, HalfOpenMaxRequests: 1})
+	call(t, b, false)
+	if b.GetState() != StateOpen {
+		t.Fatalf("expected open")
+	}
+	clock.advance(time.Minute)
+	if b.GetState() != StateHalfOpen {
+		t.Fatalf("expected half open after cool down, got %s", b.GetState())
+	}
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected trial request to be admitted: %v", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected a second concurrent trial to be rejected")
+	}
+	done(false)
+	if b.GetState() != StateOpen {
+		t.Fatalf("expected failed trial to reopen, got %s", b.GetState())
+	}
+	clock.advance(time.Minute)
+	call(t, b, true)
+	if b.GetState() != StateClosed {
+		t.Fatalf("expected successful trial to close, got %s", b.GetState())
+	}
+	if snap := b.Snapshot(); snap.Requests != 0 || snap.Failures != 0 {
+		t.Fatalf("expected window to reset on close, got %+v", snap)
+	}
+}
+
+func TestBreaker_StaleOutcomesAreIgnored(t *testing.T) {
+	b, _ := newTestBreaker(Config{MinimumRequests: 1, WindowSize: 1})
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call(t, b, false)
+	slow(true)
+	if b.GetState() != StateOpen {
+		t.Fatalf("expected a pre-open success not to affect the open breaker")
+	}
+}
+
+func TestRegistry_ReconfiguresBreakersInPlace(t *testing.T) {
+	r := NewRegistry()
+	a := r.GetBreaker("p/a", Config{})
+	if r.GetBreaker("p/a", Config{}) != a {
+		t.Fatalf("expected same breaker for same key and config")
+	}
+	for _, success := range []bool{true, false, false} {
+		done, err := a.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		done(success)
+	}
+	if r.GetBreaker("p/a", Config{CoolDown: time.Second, WindowSize: 2}) != a {
+		t.Fatalf("expected a changed config to keep the live breaker")
+	}
+	snap := a.Snapshot()
+	if snap.Config.CoolDown != time.Second || snap.Requests != 2 || snap.Failures != 2 {
+		t.Fatalf("expected the changed config and the most recent outcomes, got %+v", snap)
+	}
+	r.GetBreaker("p/b", Config{})
+	snaps := r.Snapshots()
+	if len(snaps) != 2 || snaps[0].Key != "p/a" || snaps[1].Key != "p/b" {
+		t.Fatalf("unexpected snapshots %+v", snaps)
+	}
+}
@@ -0,0 +1,217 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingBrotli   = "br"
+	EncodingZstd     = "zstd"
+	EncodingIdentity = "identity"
+
+	// DefaultMaxDecodedBytes bounds a decoded body when no limit is configured.
+	DefaultMaxDecodedBytes int64 = 128 << 20
+)
+
+var (
+	// ErrTooLarge is returned by decoded bodies that exceed their size limit.
+	ErrTooLarge = errors.New("decompressed body exceeds size limit")
+
+	supportedEncodings = []string{ //nolint:gochecknoglobals // read-only table
+		EncodingBrotli,
+		EncodingZstd,
+		EncodingGzip,
+		EncodingDeflate,
+	}
+)
+
+// SupportedEncodings returns the content codings this package can encode and
+// decode, in order of preference.
+func SupportedEncodings() []string {
+	rv := make([]string, len(supportedEncodings))
+	copy(rv, supportedEncodings)
+	return rv
+}
+
+// IsSupported reports whether encoding (case-insensitive) can be encoded and decoded.
+func IsSupported(encoding string) bool {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	for _, e := range supportedEncodings {
+		if e == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode compresses payload with the given content coding.
+func Encode(encoding string, payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case EncodingDeflate:
+		// the HTTP "deflate" coding is the zlib format (RFC 9110 section 8.4.1.2)
+		w = zlib.NewWriter(&buf)
+	case EncodingBrotli:
+		w = brotli.NewWriter(&buf)
+	case EncodingZstd:
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		w = zw
+	case EncodingIdentity, "":
+		return payload, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding '%s'", encoding)
+	}
+	if _, err := w.Write(payload); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseContentEncoding splits a Content-Encoding header value into its
+// codings, in the order they were applied, dropping "identity".
+func ParseContentEncoding(header string) []string {
+	var rv []string
+	for _, e := range strings.Split(header, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e != "" && e != EncodingIdentity {
+			rv = append(rv, e)
+		}
+	}
+	return rv
+}
+
+// NewDecodingReader undoes the codings listed in contentEncoding (as sent in
+// a Content-Encoding header) and caps the decoded output at maxBytes, beyond
+// which reads fail with ErrTooLarge. maxBytes <= 0 means
+// DefaultMaxDecodedBytes. An empty body decodes to an empty body.
+func NewDecodingReader(body io.ReadCloser, contentEncoding string, maxBytes int64) (io.ReadCloser, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDecodedBytes
+	}
+	codings := ParseContentEncoding(contentEncoding)
+	br := bufio.NewReader(body)
+	if _, peekErr := br.Peek(1); errors.Is(peekErr, io.EOF) {
+		return body, nil
+	}
+	var r io.Reader = br
+	var closers []io.Closer
+	// codings are listed in the order applied, so undo them in reverse
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoded, closer, err := newDecoder(codings[i], r)
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+		r = decoded
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+	return &limitedReadCloser{
+		r:         r,
+		remaining: maxBytes,
+		closers:   append(closers, body),
+	}, nil
+}
+
+func newDecoder(encoding string, r io.Reader) (io.Reader, io.Closer, error) {
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gr, gr, nil
+	case EncodingDeflate:
+		br := bufio.NewReader(r)
+		if header, peekErr := br.Peek(2); peekErr == nil && isZlibHeader(header) {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, nil, err
+			}
+			return zr, zr, nil
+		}
+		// some servers send raw DEFLATE despite the spec
+		fr := flate.NewReader(br)
+		return fr, fr, nil
+	case EncodingBrotli:
+		return brotli.NewReader(r), nil, nil
+	case EncodingZstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, closerFunc(func() error { zr.Close(); return nil }), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported content encoding '%s'", encoding)
+	}
+}
+
+func isZlibHeader(h []byte) bool {
+	return h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+type limitedReadCloser struct {
+	r         io.Reader
+	remaining int64
+	closers   []io.Closer
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// probe for one more byte to distinguish "exactly at limit" from "over"
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	var rv error
+	for _, c := range l.closers {
+		if err := c.Close(); err != nil && rv == nil {
+			rv = err
+		}
+	}
+	return rv
+}
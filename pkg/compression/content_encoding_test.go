@@ -0,0 +1,89 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"name":"instance-1","zone":"us-east1-b"}`, 64))
+	for _, encoding := range SupportedEncodings() {
+		encoded, err := Encode(encoding, payload)
+		if err != nil {
+			t.Fatalf("%s: unexpected encode error: %v", encoding, err)
+		}
+		if bytes.Equal(encoded, payload) {
+			t.Fatalf("%s: expected payload to be encoded", encoding)
+		}
+		rdr, err := NewDecodingReader(io.NopCloser(bytes.NewReader(encoded)), encoding, 0)
+		if err != nil {
+			t.Fatalf("%s: unexpected decode error: %v", encoding, err)
+		}
+		decoded, err := io.ReadAll(rdr)
+		if err != nil {
+			t.Fatalf("%s: unexpected read error: %v", encoding, err)
+		}
+		if !bytes.Equal(decoded, payload) {
+			t.Fatalf("%s: round trip mismatch", encoding)
+		}
+		_ = rdr.Close()
+	}
+}
+
+func TestDecode_StackedCodingsAndRawDeflate(t *testing.T) {
+	inner, _ := Encode(EncodingGzip, []byte("stacked"))
+	outer, _ := Encode(EncodingZstd, inner)
+	rdr, err := NewDecodingReader(io.NopCloser(bytes.NewReader(outer)), "gzip, zstd", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := io.ReadAll(rdr); string(got) != "stacked" {
+		t.Fatalf("expected stacked codings to be undone in reverse, got %q", got)
+	}
+
+	var raw bytes.Buffer
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	_, _ = fw.Write([]byte("raw deflate"))
+	_ = fw.Close()
+	rdr, err = NewDecodingReader(io.NopCloser(&raw), EncodingDeflate, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := io.ReadAll(rdr); string(got) != "raw deflate" {
+		t.Fatalf("expected raw deflate to decode, got %q", got)
+	}
+}
+
+func TestDecode_SizeLimit(t *testing.T) {
+	bomb, _ := Encode(EncodingGzip, make([]byte, 1<<20))
+	rdr, err := NewDecodingReader(io.NopCloser(bytes.NewReader(bomb)), EncodingGzip, 1024)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = io.ReadAll(rdr); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	exact, _ := Encode(EncodingBrotli, make([]byte, 1024))
+	rdr, _ = NewDecodingReader(io.NopCloser(bytes.NewReader(exact)), EncodingBrotli, 1024)
+	if got, err := io.ReadAll(rdr); err != nil || len(got) != 1024 {
+		t.Fatalf("expected a body exactly at the limit to decode, got %d bytes, %v", len(got), err)
+	}
+}
+
+func TestDecode_EmptyAndUnknown(t *testing.T) {
+	rdr, err := NewDecodingReader(io.NopCloser(bytes.NewReader(nil)), EncodingGzip, 0)
+	if err != nil {
+		t.Fatalf("expected an empty body to pass through, got %v", err)
+	}
+	if got, _ := io.ReadAll(rdr); len(got) != 0 {
+		t.Fatalf("expected empty body, got %q", got)
+	}
+	if _, err = NewDecodingReader(io.NopCloser(strings.NewReader("x")), "compress", 0); err == nil {
+		t.Fatalf("expected an unsupported encoding to fail")
+	}
+}
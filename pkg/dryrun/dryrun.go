@@ -0,0 +1,522 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// FormatCurl writes each request as a curl command.
+	FormatCurl = "curl"
+	// FormatHTTP writes each request as raw HTTP/1.1.
+	FormatHTTP = "http"
+	// FormatHAR writes all requests to a HAR 1.2 file.
+	FormatHAR = "har"
+
+	// Masked replaces credential values unless secrets are shown.
+	Masked = "REDACTED"
+
+	// Header marks the synthetic response returned in place of a sent
+	// request.
+	Header = "X-Anysdk-Dry-Run"
+
+	harVersion = "1.2"
+	harCreator = "any-sdk"
+)
+
+var (
+	_ http.RoundTripper = &transport{}
+
+	// credentialNames are headers and query parameters whose values are
+	// masked unless secrets are shown. Names are compared case
+	// insensitively.
+	credentialNames = []string{ //nolint:gochecknoglobals // read-only default
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"X-Api-Key",
+		"X-Goog-Api-Key",
+		"X-Auth-Token",
+		"X-Amz-Security-Token",
+		"X-Amz-Signature",
+		"X-Amz-Credential",
+		"access_token",
+		"client_secret",
+		"api_key",
+		"apikey",
+		"key",
+	}
+
+	registryMu sync.Mutex                   //nolint:gochecknoglobals // recorders are shared by output
+	registry   = make(map[string]*Recorder) //nolint:gochecknoglobals // recorders are shared by output
+)
+
+type captureKey struct{}
+
+// WithCapture marks the requests made with ctx as ones to capture rather
+// than send. Unmarked requests, such as token exchanges made while
+// authenticating, pass through a dry run transport to the network.
+func WithCapture(ctx context.Context) context.Context {
+	return context.WithValue(ctx, captureKey{}, true)
+}
+
+// IsCaptured reports whether requests made with ctx are captured.
+func IsCaptured(ctx context.Context) bool {
+	captured, _ := ctx.Value(captureKey{}).(bool)
+	return captured
+}
+
+// Config selects where and how captured requests are written.
+type Config struct {
+	// Path is the output file. Empty writes curl and http output to
+	// standard output; HAR output requires a path.
+	Path string
+	// Format is FormatCurl, FormatHTTP or FormatHAR. Defaults to
+	// FormatCurl.
+	Format string
+	// ShowSecrets writes credential values as sent, instead of masked.
+	ShowSecrets bool
+}
+
+func (c Config) getFormat() string {
+	if c.Format == "" {
+		return FormatCurl
+	}
+	return c.Format
+}
+
+// Recorder writes captured requests. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	cfg     Config
+	w       io.Writer
+	entries []harEntry
+}
+
+// Open returns the recorder for cfg. Recorders are shared by format and
+// path, so that every client built for a session writes to one output.
+// curl and http output is appended to an existing file; a HAR file is
+// rewritten in full on each capture.
+func Open(cfg Config) (*Recorder, error) {
+	format := cfg.getFormat()
+	switch format {
+	case FormatCurl, FormatHTTP:
+	case FormatHAR:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("dry run format '%s' requires an output path", FormatHAR)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dry run format '%s'", cfg.Format)
+	}
+	registryKey := format + ":"
+	if cfg.Path != "" {
+		registryKey += filepath.Clean(cfg.Path)
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if r, ok := registry[registryKey]; ok {
+		return r, nil
+	}
+	r := &Recorder{cfg: cfg}
+	r.cfg.Format = format
+	switch {
+	case format == FormatHAR:
+	case cfg.Path == "":
+		r.w = os.Stdout
+	default:
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil { //nolint:mnd // conventional permissions
+			return nil, err
+		}
+		f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:mnd // may hold credentials
+		if err != nil {
+			return nil, err
+		}
+		r.w = f
+	}
+	registry[registryKey] = r
+	return r, nil
+}
+
+// NewRecorder returns an unshared recorder writing curl or http output to
+// w, for tests and embedding.
+func NewRecorder(w io.Writer, format string, showSecrets bool) *Recorder {
+	return &Recorder{
+		cfg: Config{Format: format, ShowSecrets: showSecrets},
+		w:   w,
+	}
+}
+
+// Capture writes req, whose body is body, to the output.
+func (r *Recorder) Capture(req *http.Request, body []byte) error {
+	header := r.maskHeader(req.Header)
+	u := r.maskURL(req.URL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.cfg.Format {
+	case FormatHAR:
+		r.entries = append(r.entries, newHAREntry(req.Method, u, header, body))
+		return r.saveHAR()
+	case FormatHTTP:
+		_, err := io.WriteString(r.w, renderHTTP(req, u, header, body))
+		return err
+	default:
+		_, err := io.WriteString(r.w, renderCurl(req.Method, u, header, body))
+		return err
+	}
+}
+
+func (r *Recorder) isCredential(name string) bool {
+	if r.cfg.ShowSecrets {
+		return false
+	}
+	for _, c := range credentialNames {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) maskHeader(h http.Header) http.Header {
+	rv := h.Clone()
+	if rv == nil {
+		rv = make(http.Header)
+	}
+	for k := range rv {
+		if r.isCredential(k) {
+			rv[k] = []string{Masked}
+		}
+	}
+	return rv
+}
+
+func (r *Recorder) maskURL(u *url.URL) string {
+	cp := *u
+	q := cp.Query()
+	masked := false
+	for k, v := range q {
+		if r.isCredential(k) {
+			for i := range v {
+				v[i] = Masked
+			}
+			masked = true
+		}
+	}
+	if masked {
+		cp.RawQuery = q.Encode()
+	}
+	return cp.String()
+}
+
+func sortedHeaderNames(h http.Header) []string {
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func renderCurl(method string, u string, h http.Header, body []byte) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X " + method + " " + shellQuote(u))
+	for _, k := range sortedHeaderNames(h) {
+		for _, v := range h[k] {
+			sb.WriteString(" \\\n  -H " + shellQuote(k+": "+v))
+		}
+	}
+	if len(body) > 0 {
+		if utf8.Valid(body) {
+			sb.WriteString(" \\\n  --data-binary " + shellQuote(string(body)))
+		} else {
+			sb.WriteString(" \\\n  --data-binary @<(printf %s " +
+				shellQuote(base64.StdEncoding.EncodeToString(body)) + " | base64 -d)")
+		}
+	}
+	sb.WriteString("\n\n")
+	return sb.String()
+}
+
+func renderHTTP(req *http.Request, u string, h http.Header, body []byte) string {
+	var sb strings.Builder
+	parsed, err := url.Parse(u)
+	target := u
+	host := req.Host
+	if err == nil {
+		target = parsed.RequestURI()
+		if host == "" {
+			host = parsed.Host
+		}
+	}
+	fmt.Fprintf(&sb, "%s %s HTTP/1.1\r\n", req.Method, target)
+	fmt.Fprintf(&sb, "Host: %s\r\n", host)
+	for _, k := range sortedHeaderNames(h) {
+		for _, v := range h[k] {
+			fmt.Fprintf(&sb, "%s: %s\r\n", k, v)
+		}
+	}
+	if len(body) > 0 && h.Get("Content-Length") == "" {
+		fmt.Fprintf(&sb, "Content-Length: %d\r\n", len(body))
+	}
+	sb.WriteString("\r\n")
+	sb.Write(body)
+	sb.WriteString("\r\n\r\n")
+	return sb.String()
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    int `json:"send"`
+	Wait    int `json:"wait"`
+	Receive int `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int         `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harCreatorInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harLog struct {
+	Version string         `json:"version"`
+	Creator harCreatorInfo `json:"creator"`
+	Entries []harEntry     `json:"entries"`
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+// newHAREntry records a request that was never sent: its response has
+// status 0, as HAR uses for requests without a response.
+func newHAREntry(method string, u string, h http.Header, body []byte) harEntry {
+	headers := make([]harNameValue, 0, len(h))
+	for _, k := range sortedHeaderNames(h) {
+		for _, v := range h[k] {
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
+	query := []harNameValue{}
+	if parsed, err := url.Parse(u); err == nil {
+		q := parsed.Query()
+		for _, k := range sortedKeys(q) {
+			for _, v := range q[k] {
+				query = append(query, harNameValue{Name: k, Value: v})
+			}
+		}
+	}
+	req := harRequest{
+		Method:      method,
+		URL:         u,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     headers,
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		pd := &harPostData{MimeType: h.Get("Content-Type"), Text: string(body)}
+		if !utf8.Valid(body) {
+			pd.Text = base64.StdEncoding.EncodeToString(body)
+			pd.Encoding = "base64"
+		}
+		req.PostData = pd
+	}
+	return harEntry{
+		StartedDateTime: time.Now().UTC().Format(time.RFC3339Nano),
+		Request:         req,
+		Response: harResponse{
+			StatusText:  "dry run",
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Comment: "not sent: dry run",
+	}
+}
+
+func sortedKeys(q url.Values) []string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// saveHAR writes the whole HAR file through a temporary file, so that an
+// interrupted session leaves the previous complete file.
+func (r *Recorder) saveHAR() error {
+	b, err := json.MarshalIndent(harFile{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreatorInfo{Name: harCreator, Version: harVersion},
+			Entries: r.entries,
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(r.cfg.Path)
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd // conventional permissions
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(r.cfg.Path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), r.cfg.Path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+type transport struct {
+	next     http.RoundTripper
+	recorder *Recorder
+}
+
+// NewTransport returns a RoundTripper that writes captured requests to rec
+// and answers them with an empty successful response, without sending
+// them. Other requests are sent through next.
+func NewTransport(next http.RoundTripper, rec *Recorder) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{
+		next:     next,
+		recorder: rec,
+	}
+}
+
+// IsTransport reports whether rt is a dry run transport.
+func IsTransport(rt http.RoundTripper) bool {
+	_, ok := rt.(*transport)
+	return ok
+}
+
+// GetTransport returns the wrapped RoundTripper.
+func (t *transport) GetTransport() http.RoundTripper {
+	return t.next
+}
+
+// WithTransport returns a copy of the transport wrapping next instead.
+func (t *transport) WithTransport(next http.RoundTripper) http.RoundTripper {
+	return NewTransport(next, t.recorder)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsCaptured(req.Context()) {
+		return t.next.RoundTrip(req)
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	if err := t.recorder.Capture(req, body); err != nil {
+		return nil, fmt.Errorf("dry run: %w", err)
+	}
+	return newSyntheticResponse(req), nil
+}
+
+// newSyntheticResponse answers a captured request with an empty JSON
+// object, so that paging stops and mutations appear to succeed.
+func newSyntheticResponse(req *http.Request) *http.Response {
+	body := []byte("{}")
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	header.Set(Header, "true")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
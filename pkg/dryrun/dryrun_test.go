@@ -0,0 +1,130 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newCapturedRequest(t *testing.T, method, u, body string) *http.Request {
+	t.Helper()
+	var br io.Reader
+	if body != "" {
+		br = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(WithCapture(context.Background()), method, u, br)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return req
+}
+
+func TestTransportCapturesWithoutSending(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+	var buf bytes.Buffer
+	rt := NewTransport(http.DefaultTransport, NewRecorder(&buf, FormatCurl, false))
+
+	req := newCapturedRequest(t, http.MethodDelete, srv.URL+"/things/1?key=abc&zone=a", "")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(Header) != "true" || hits != 0 {
+		t.Fatalf("expected a synthetic response and no traffic, got %d with %d hits", resp.StatusCode, hits)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "curl -X DELETE '"+srv.URL+"/things/1?key=REDACTED&zone=a'") {
+		t.Fatalf("unexpected curl %q", out)
+	}
+	if strings.Contains(out, "secret") || strings.Contains(out, "abc") || !strings.Contains(out, "-H 'Authorization: REDACTED'") {
+		t.Fatalf("expected credentials to be masked %q", out)
+	}
+
+	plain, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+	resp, err = rt.RoundTrip(plain)
+	if err != nil || resp.StatusCode != http.StatusTeapot || hits != 1 {
+		t.Fatalf("expected uncaptured requests to be sent, got %v %v", resp, err)
+	}
+	resp.Body.Close()
+}
+
+func TestCurlQuotesBody(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, FormatCurl, true)
+	req := newCapturedRequest(t, http.MethodPost, "https://example.com/v1/things", `{"name":"it's"}`)
+	req.Header.Set("Authorization", "Bearer secret")
+	if err := rec.Capture(req, []byte(`{"name":"it's"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `--data-binary '{"name":"it'\''s"}'`) || !strings.Contains(out, "Bearer secret") {
+		t.Fatalf("unexpected curl %q", out)
+	}
+}
+
+func TestRawHTTP(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, FormatHTTP, false)
+	req := newCapturedRequest(t, http.MethodPost, "https://example.com/v1/things?a=1", "{}")
+	req.Header.Set("Content-Type", "application/json")
+	if err := rec.Capture(req, []byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "POST /v1/things?a=1 HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n{}"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Fatalf("unexpected http %q", buf.String())
+	}
+}
+
+func TestHARFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "requests.har")
+	rec, err := Open(Config{Path: path, Format: FormatHAR})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := Open(Config{Path: path, Format: FormatHAR}); again != rec {
+		t.Fatalf("expected recorders to be shared by path")
+	}
+	for _, m := range []string{http.MethodGet, http.MethodDelete} {
+		req := newCapturedRequest(t, m, "https://example.com/v1/things?api_key=abc", "")
+		if err := rec.Capture(req, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var f harFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatalf("bad har: %v", err)
+	}
+	if f.Log.Version != harVersion || len(f.Log.Entries) != 2 || f.Log.Entries[1].Request.Method != http.MethodDelete {
+		t.Fatalf("unexpected har %+v", f.Log)
+	}
+	if q := f.Log.Entries[0].Request.QueryString; len(q) != 1 || q[0].Value != Masked {
+		t.Fatalf("expected masked query %+v", q)
+	}
+}
+
+func TestOpenRejectsBadConfig(t *testing.T) {
+	if _, err := Open(Config{Format: FormatHAR}); err == nil {
+		t.Fatalf("expected har without a path to fail")
+	}
+	if _, err := Open(Config{Format: "postman"}); err == nil {
+		t.Fatalf("expected an unknown format to fail")
+	}
+}
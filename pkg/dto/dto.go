@@ -1,73 +1,86 @@
 package dto
 
 const (
-	AuthAPIKeyStr                   string = "api_key"
-	AuthAWSSigningv4Str             string = "aws_signing_v4"
-	AuthAWSAssumeRoleStr            string = "aws_assume_role"
-	AuthAzureDefaultStr             string = "azure_default"
-	AuthBasicStr                    string = "basic"
-	AuthBearerStr                   string = "bearer"
-	AuthCustomStr                   string = "custom"
-	AuthInteractiveStr              string = "interactive"
-	AuthServiceAccountStr           string = "service_account"
-	AuthNullStr                     string = "null_auth"
-	DryRunFlagKey                   string = "dryrun"
-	ExecutionConcurrencyLimitKey    string = "execution.concurrency.limit"
-	AuthCtxKey                      string = "auth"
-	APIRequestTimeoutKey            string = "apirequesttimeout"
-	CacheKeyCountKey                string = "cachekeycount"
-	CacheTTLKey                     string = "metadatattl"
-	ClientCredentialsStr            string = "client_credentials"
-	ColorSchemeKey                  string = "colorscheme" // deprecated
-	ConfigFilePathKey               string = "configfile"
-	CPUProfileKey                   string = "cpuprofile"
-	CSVHeadersDisableKey            string = "hideheaders"
-	DelimiterKey                    string = "delimiter"
-	ErrorPresentationKey            string = "errorpresentation"
-	IndirectDepthMaxKey             string = "indirect.depth.max"
-	DataflowDependencyMaxKey        string = "dataflow.dependency.max"
-	DataflowComponentsMaxKey        string = "dataflow.components.max"
-	HTTPLogEnabledKey               string = "http.log.enabled"
-	HTTPMaxResultsKey               string = "http.response.maxResults"
-	HTTPPAgeLimitKey                string = "http.response.pageLimit"
-	HTTPProxyHostKey                string = "http.proxy.host"
-	HTTPProxyPasswordKey            string = "http.proxy.password" //nolint:gosec // no hardcoded credentials
-	HTTPProxyPortKey                string = "http.proxy.port"
-	HTTPProxySchemeKey              string = "http.proxy.scheme"
-	HTTPProxyUserKey                string = "http.proxy.user"
-	CABundleKey                     string = "tls.CABundle"
-	AllowInsecureKey                string = "tls.allowInsecure"
-	InfilePathKey                   string = "infile"
-	LogLevelStrKey                  string = "loglevel"
-	OAuth2Str                       string = "oauth2"
-	OutfilePathKey                  string = "outfile"
-	OutputFormatKey                 string = "output"
-	ApplicationFilesRootPathKey     string = "approot"
-	ApplicationFilesRootPathModeKey string = "approotfilemode"
-	PgSrvAddressKey                 string = "pgsrv.address"
-	ExportAliasKey                  string = "export.alias"
-	PgSrvLogLevelKey                string = "pgsrv.loglevel"
-	PgSrvPortKey                    string = "pgsrv.port"
-	PgSrvRawTLSCfgKey               string = "pgsrv.tls"
-	PgSrvRawSrvCfgKey               string = "pgsrv.sundry"
-	PgSrvIsDebugNoticesEnabledKey   string = "pgsrv.debug.enable"
-	ProviderStrKey                  string = "provider"
-	QueryCacheSizeKey               string = "querycachesize"
-	RegistryRawKey                  string = "registry"
-	SessionCtxKey                   string = "session"
-	GCCfgRawKey                     string = "gc"
-	ACIDCfgRawKey                   string = "acid"
-	NamespaceCfgRawKey              string = "namespaces"
-	SQLBackendCfgRawKey             string = "sqlBackend"
-	DBInternalCfgRawKey             string = "dbInternal"
-	StoreTxnCfgRawKey               string = "store.txn"
-	TemplateCtxFilePathKey          string = "iqldata"
-	TestWithoutAPICallsKey          string = "TestWithoutAPICalls"
-	UseNonPreferredAPIsKEy          string = "usenonpreferredapis"
-	VarListKey                      string = "var"
-	VerboseFlagKey                  string = "verbose"
-	ViperCfgFileNameKey             string = "viperconfigfilename"
-	WorkOfflineKey                  string = "offline"
+	AuthAPIKeyStr                    string = "api_key"
+	AuthAWSSigningv4Str              string = "aws_signing_v4"
+	AuthAWSAssumeRoleStr             string = "aws_assume_role"
+	AuthAzureDefaultStr              string = "azure_default"
+	AuthBasicStr                     string = "basic"
+	AuthBearerStr                    string = "bearer"
+	AuthCustomStr                    string = "custom"
+	AuthInteractiveStr               string = "interactive"
+	AuthServiceAccountStr            string = "service_account"
+	AuthNullStr                      string = "null_auth"
+	DryRunFlagKey                    string = "dryrun"
+	ExecutionConcurrencyLimitKey     string = "execution.concurrency.limit"
+	ExecutionConcurrencyUnorderedKey string = "execution.concurrency.unordered"
+	AuthCtxKey                       string = "auth"
+	APIRequestTimeoutKey             string = "apirequesttimeout"
+	CacheKeyCountKey                 string = "cachekeycount"
+	CacheTTLKey                      string = "metadatattl"
+	ClientCredentialsStr             string = "client_credentials"
+	ColorSchemeKey                   string = "colorscheme" // deprecated
+	ConfigFilePathKey                string = "configfile"
+	CPUProfileKey                    string = "cpuprofile"
+	CSVHeadersDisableKey             string = "hideheaders"
+	DelimiterKey                     string = "delimiter"
+	ErrorPresentationKey             string = "errorpresentation"
+	IndirectDepthMaxKey              string = "indirect.depth.max"
+	DataflowDependencyMaxKey         string = "dataflow.dependency.max"
+	DataflowComponentsMaxKey         string = "dataflow.components.max"
+	HTTPLogEnabledKey                string = "http.log.enabled"
+	HTTPCassettePathKey              string = "http.cassette.path"
+	HTTPCassetteModeKey              string = "http.cassette.mode"
+	HTTPCassetteMatchKey             string = "http.cassette.match"
+	HTTPCassetteScrubKey             string = "http.cassette.scrub"
+	AuditLogPathKey                  string = "audit.log.path"
+	AuditRedactKey                   string = "audit.redact"
+	DryRunFormatKey                  string = "dryrun.format"
+	DryRunOutputKey                  string = "dryrun.output"
+	DryRunShowSecretsKey             string = "dryrun.show.secrets"
+	ResponseValidationKey            string = "response.validation"
+	HTTPMaxResultsKey                string = "http.response.maxResults"
+	HTTPPAgeLimitKey                 string = "http.response.pageLimit"
+	HTTPProxyHostKey                 string = "http.proxy.host"
+	HTTPProxyPasswordKey             string = "http.proxy.password" //nolint:gosec // no hardcoded credentials
+	HTTPProxyPortKey                 string = "http.proxy.port"
+	HTTPProxySchemeKey               string = "http.proxy.scheme"
+	HTTPProxyUserKey                 string = "http.proxy.user"
+	HTTPProxyRulesKey                string = "http.proxy.rules"
+	HTTPProxyNoProxyKey              string = "http.proxy.noProxy"
+	CABundleKey                      string = "tls.CABundle"
+	AllowInsecureKey                 string = "tls.allowInsecure"
+	InfilePathKey                    string = "infile"
+	LogLevelStrKey                   string = "loglevel"
+	OAuth2Str                        string = "oauth2"
+	OutfilePathKey                   string = "outfile"
+	OutputFormatKey                  string = "output"
+	ApplicationFilesRootPathKey      string = "approot"
+	ApplicationFilesRootPathModeKey  string = "approotfilemode"
+	PgSrvAddressKey                  string = "pgsrv.address"
+	ExportAliasKey                   string = "export.alias"
+	PgSrvLogLevelKey                 string = "pgsrv.loglevel"
+	PgSrvPortKey                     string = "pgsrv.port"
+	PgSrvRawTLSCfgKey                string = "pgsrv.tls"
+	PgSrvRawSrvCfgKey                string = "pgsrv.sundry"
+	PgSrvIsDebugNoticesEnabledKey    string = "pgsrv.debug.enable"
+	ProviderStrKey                   string = "provider"
+	QueryCacheSizeKey                string = "querycachesize"
+	RegistryRawKey                   string = "registry"
+	SessionCtxKey                    string = "session"
+	GCCfgRawKey                      string = "gc"
+	ACIDCfgRawKey                    string = "acid"
+	NamespaceCfgRawKey               string = "namespaces"
+	SQLBackendCfgRawKey              string = "sqlBackend"
+	DBInternalCfgRawKey              string = "dbInternal"
+	StoreTxnCfgRawKey                string = "store.txn"
+	TemplateCtxFilePathKey           string = "iqldata"
+	TestWithoutAPICallsKey           string = "TestWithoutAPICalls"
+	UseNonPreferredAPIsKEy           string = "usenonpreferredapis"
+	VarListKey                       string = "var"
+	VerboseFlagKey                   string = "verbose"
+	ViperCfgFileNameKey              string = "viperconfigfilename"
+	WorkOfflineKey                   string = "offline"
 )
 
 func inferKeyFileType(keyFileType string) string {
@@ -3,11 +3,15 @@ package dto
 type MarshalledBody interface {
 	GetBytes() []byte
 	GetError() (error, bool)
+	// GetContentType returns a Content-Type that must replace the declared
+	// media type, for example a multipart body with its boundary.
+	GetContentType() (string, bool)
 }
 
 type standardMarshalledBody struct {
-	bytes []byte
-	err   error
+	bytes       []byte
+	err         error
+	contentType string
 }
 
 func NewMarshalledBody(b []byte, e error) MarshalledBody {
@@ -17,6 +21,14 @@ func NewMarshalledBody(b []byte, e error) MarshalledBody {
 	}
 }
 
+func NewMarshalledBodyWithContentType(b []byte, contentType string, e error) MarshalledBody {
+	return &standardMarshalledBody{
+		bytes:       b,
+		err:         e,
+		contentType: contentType,
+	}
+}
+
 func (mb *standardMarshalledBody) GetBytes() []byte {
 	return mb.bytes
 }
@@ -24,3 +36,7 @@ func (mb *standardMarshalledBody) GetBytes() []byte {
 func (mb *standardMarshalledBody) GetError() (error, bool) {
 	return mb.err, mb.err != nil
 }
+
+func (mb *standardMarshalledBody) GetContentType() (string, bool) {
+	return mb.contentType, mb.contentType != ""
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ProxyDirect as a rule's proxy sends matching requests without a proxy.
+	ProxyDirect string = "direct"
+)
+
+// ProxyRule routes the requests it matches through a proxy. A rule naming
+// providers matches only requests to those providers, and a rule naming
+// host patterns, such as "*.corp.example.com", only requests to matching
+// hosts; a rule naming neither matches every request.
+type ProxyRule struct {
+	Providers []string `json:"providers,omitempty" yaml:"providers,omitempty"`
+	Hosts     []string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	// Proxy is an http, https, socks5 or socks5h URL, or ProxyDirect.
+	Proxy string `json:"proxy" yaml:"proxy"`
+	// NoProxy lists hosts that bypass this rule's proxy, with NO_PROXY
+	// semantics.
+	NoProxy string `json:"noProxy,omitempty" yaml:"noProxy,omitempty"`
+	// Auth supplies proxy credentials from the same sources as provider
+	// credentials.
+	Auth *AuthCtx `json:"auth,omitempty" yaml:"auth,omitempty"`
+}
+
+type ProxyRulesCfg struct {
+	Rules []ProxyRule `json:"rules" yaml:"rules"`
+}
+
+func GetProxyRulesCfg(s string) (ProxyRulesCfg, error) {
+	rv := ProxyRulesCfg{}
+	err := yaml.Unmarshal([]byte(s), &rv)
+	return rv, err
+}
+
+// GetProxyCredentials returns the username and password in ac. Inline and
+// environment variable usernames and passwords are read first; otherwise
+// the credentials environment variable or file must hold
+// "username:password".
+func (ac *AuthCtx) GetProxyCredentials() (string, string, error) {
+	if ac.Username != "" {
+		return ac.Username, ac.Password, nil
+	}
+	if ac.EnvVarUsername != "" {
+		return os.Getenv(ac.EnvVarUsername), os.Getenv(ac.EnvVarPassword), nil
+	}
+	b, err := ac.GetCredentialsBytes()
+	if err != nil {
+		return "", "", err
+	}
+	user, password, found := strings.Cut(strings.TrimSpace(string(b)), ":")
+	if !found {
+		return "", "", fmt.Errorf("proxy credentials must be of the form 'username:password'")
+	}
+	return user, password, nil
+}
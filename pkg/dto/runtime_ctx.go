@@ -1,80 +1,97 @@
 package dto
 
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type RuntimeCtx struct {
-	APIRequestTimeout            int
-	AuthRaw                      string
-	CABundle                     string
-	AllowInsecure                bool
-	CacheKeyCount                int
-	CacheTTL                     int
-	ConfigFilePath               string
-	CPUProfile                   string
-	CSVHeadersDisable            bool
-	Delimiter                    string
-	DryRunFlag                   bool
-	ErrorPresentation            string
-	ExecutionConcurrencyLimit    int
-	HTTPLogEnabled               bool
-	HTTPMaxResults               int
-	HTTPPageLimit                int
-	HTTPProxyHost                string
-	HTTPProxyPassword            string
-	HTTPProxyPort                int
-	HTTPProxyScheme              string
-	HTTPProxyUser                string
-	IndirectDepthMax             int
-	DataflowComponentsMax        int
-	DataflowDependencyMax        int
-	InfilePath                   string
-	LogLevelStr                  string
-	OutfilePath                  string
-	OutputFormat                 string
-	ApplicationFilesRootPath     string
-	ApplicationFilesRootPathMode uint32
-	PGSrvAddress                 string
-	PGSrvLogLevel                string
-	PGSrvPort                    int
-	PGSrvRawTLSCfg               string
-	PGSrvRawSrvCfg               string
-	PGSrvIsDebugNoticesEnabled   bool
-	ExportAlias                  string
-	ProviderStr                  string
-	RegistryRaw                  string
-	SessionCtxRaw                string
-	SQLBackendCfgRaw             string
-	DBInternalCfgRaw             string
-	NamespaceCfgRaw              string
-	StoreTxnCfgRaw               string
-	GCCfgRaw                     string
-	ACIDCfgRaw                   string
-	QueryCacheSize               int
-	TemplateCtxFilePath          string
-	TestWithoutAPICalls          bool
-	UseNonPreferredAPIs          bool
-	VarList                      []string
-	VerboseFlag                  bool
-	ViperCfgFileName             string
-	WorkOffline                  bool
-	CLIPayload                   string
-	CLIPayloadType               string
-	CLIParameters                string
-	CLIProvFilePath              string
-	CLISvcFilePath               string
-	CLIProviderName              string
-	CLIResourceStr               string
-	CLIMethodName                string
-	CLISchemaDir                 string
-	CLISkipSchemaValidation      bool
-	CLIRewriteURL                string
-	CLIMockOutputDir             string
-	CLIMockExpectationDir        string
-	CLIMockQueryDir              string
-	CLIProviderOut               string
-	CLIClosureOutputDir          string
-	CLIStdoutFile                string
-	CLIStderrFile                string
+	APIRequestTimeout             int
+	AuthRaw                       string
+	CABundle                      string
+	AllowInsecure                 bool
+	CacheKeyCount                 int
+	CacheTTL                      int
+	ConfigFilePath                string
+	CPUProfile                    string
+	CSVHeadersDisable             bool
+	Delimiter                     string
+	DryRunFlag                    bool
+	ErrorPresentation             string
+	ExecutionConcurrencyLimit     int
+	ExecutionConcurrencyUnordered bool
+	HTTPLogEnabled                bool
+	HTTPCassettePath              string
+	HTTPCassetteMode              string
+	HTTPCassetteMatch             string
+	HTTPCassetteScrub             string
+	AuditLogPath                  string
+	AuditRedact                   string
+	DryRunFormat                  string
+	DryRunOutput                  string
+	DryRunShowSecrets             bool
+	ResponseValidation            string
+	HTTPMaxResults                int
+	HTTPPageLimit                 int
+	HTTPProxyHost                 string
+	HTTPProxyPassword             string
+	HTTPProxyPort                 int
+	HTTPProxyScheme               string
+	HTTPProxyUser                 string
+	HTTPProxyRules                string
+	HTTPProxyNoProxy              string
+	IndirectDepthMax              int
+	DataflowComponentsMax         int
+	DataflowDependencyMax         int
+	InfilePath                    string
+	LogLevelStr                   string
+	OutfilePath                   string
+	OutputFormat                  string
+	ApplicationFilesRootPath      string
+	ApplicationFilesRootPathMode  uint32
+	PGSrvAddress                  string
+	PGSrvLogLevel                 string
+	PGSrvPort                     int
+	PGSrvRawTLSCfg                string
+	PGSrvRawSrvCfg                string
+	PGSrvIsDebugNoticesEnabled    bool
+	ExportAlias                   string
+	ProviderStr                   string
+	RegistryRaw                   string
+	SessionCtxRaw                 string
+	SQLBackendCfgRaw              string
+	DBInternalCfgRaw              string
+	NamespaceCfgRaw               string
+	StoreTxnCfgRaw                string
+	GCCfgRaw                      string
+	ACIDCfgRaw                    string
+	QueryCacheSize                int
+	TemplateCtxFilePath           string
+	TestWithoutAPICalls           bool
+	UseNonPreferredAPIs           bool
+	VarList                       []string
+	VerboseFlag                   bool
+	ViperCfgFileName              string
+	WorkOffline                   bool
+	CLIPayload                    string
+	CLIPayloadType                string
+	CLIParameters                 string
+	CLIProvFilePath               string
+	CLISvcFilePath                string
+	CLIProviderName               string
+	CLIResourceStr                string
+	CLIMethodName                 string
+	CLISchemaDir                  string
+	CLISkipSchemaValidation       bool
+	CLIRewriteURL                 string
+	CLIMockOutputDir              string
+	CLIMockExpectationDir         string
+	CLIMockQueryDir               string
+	CLIProviderOut                string
+	CLIClosureOutputDir           string
+	CLIStdoutFile                 string
+	CLIStderrFile                 string
 }
 
 func setInt(iPtr *int, val string) error {
@@ -134,8 +151,30 @@ func (rc *RuntimeCtx) Set(key string, val string) error {
 		rc.ErrorPresentation = val
 	case ExecutionConcurrencyLimitKey:
 		retVal = setInt(&rc.ExecutionConcurrencyLimit, val)
+	case ExecutionConcurrencyUnorderedKey:
+		retVal = setBool(&rc.ExecutionConcurrencyUnordered, val)
 	case HTTPLogEnabledKey:
 		retVal = setBool(&rc.HTTPLogEnabled, val)
+	case HTTPCassettePathKey:
+		rc.HTTPCassettePath = val
+	case HTTPCassetteModeKey:
+		rc.HTTPCassetteMode = val
+	case HTTPCassetteMatchKey:
+		rc.HTTPCassetteMatch = val
+	case HTTPCassetteScrubKey:
+		rc.HTTPCassetteScrub = val
+	case AuditLogPathKey:
+		rc.AuditLogPath = val
+	case AuditRedactKey:
+		rc.AuditRedact = val
+	case DryRunFormatKey:
+		rc.DryRunFormat = val
+	case DryRunOutputKey:
+		rc.DryRunOutput = val
+	case DryRunShowSecretsKey:
+		retVal = setBool(&rc.DryRunShowSecrets, val)
+	case ResponseValidationKey:
+		rc.ResponseValidation = val
 	case HTTPMaxResultsKey:
 		retVal = setInt(&rc.HTTPMaxResults, val)
 	case HTTPPAgeLimitKey:
@@ -156,6 +195,10 @@ func (rc *RuntimeCtx) Set(key string, val string) error {
 		rc.HTTPProxyScheme = val
 	case HTTPProxyUserKey:
 		rc.HTTPProxyUser = val
+	case HTTPProxyRulesKey:
+		rc.HTTPProxyRules = val
+	case HTTPProxyNoProxyKey:
+		rc.HTTPProxyNoProxy = val
 	case InfilePathKey:
 		rc.InfilePath = val
 	case LogLevelStrKey:
@@ -216,60 +259,73 @@ func (rc *RuntimeCtx) Set(key string, val string) error {
 
 func (rc RuntimeCtx) Copy() RuntimeCtx {
 	return RuntimeCtx{
-		APIRequestTimeout:            rc.APIRequestTimeout,
-		AuthRaw:                      rc.AuthRaw,
-		CABundle:                     rc.CABundle,
-		AllowInsecure:                rc.AllowInsecure,
-		CacheKeyCount:                rc.CacheKeyCount,
-		CacheTTL:                     rc.CacheTTL,
-		ConfigFilePath:               rc.ConfigFilePath,
-		CPUProfile:                   rc.CPUProfile,
-		CSVHeadersDisable:            rc.CSVHeadersDisable,
-		Delimiter:                    rc.Delimiter,
-		DryRunFlag:                   rc.DryRunFlag,
-		ErrorPresentation:            rc.ErrorPresentation,
-		ExecutionConcurrencyLimit:    rc.ExecutionConcurrencyLimit,
-		HTTPLogEnabled:               rc.HTTPLogEnabled,
-		HTTPMaxResults:               rc.HTTPMaxResults,
-		HTTPPageLimit:                rc.HTTPPageLimit,
-		HTTPProxyHost:                rc.HTTPProxyHost,
-		HTTPProxyPassword:            rc.HTTPProxyPassword,
-		HTTPProxyPort:                rc.HTTPProxyPort,
-		HTTPProxyScheme:              rc.HTTPProxyScheme,
-		HTTPProxyUser:                rc.HTTPProxyUser,
-		IndirectDepthMax:             rc.IndirectDepthMax,
-		DataflowComponentsMax:        rc.DataflowComponentsMax,
-		DataflowDependencyMax:        rc.DataflowDependencyMax,
-		InfilePath:                   rc.InfilePath,
-		LogLevelStr:                  rc.LogLevelStr,
-		OutfilePath:                  rc.OutfilePath,
-		OutputFormat:                 rc.OutputFormat,
-		ApplicationFilesRootPath:     rc.ApplicationFilesRootPath,
-		ApplicationFilesRootPathMode: rc.ApplicationFilesRootPathMode,
-		PGSrvAddress:                 rc.PGSrvAddress,
-		PGSrvLogLevel:                rc.PGSrvLogLevel,
-		PGSrvPort:                    rc.PGSrvPort,
-		PGSrvRawTLSCfg:               rc.PGSrvRawTLSCfg,
-		PGSrvRawSrvCfg:               rc.PGSrvRawSrvCfg,
-		PGSrvIsDebugNoticesEnabled:   rc.PGSrvIsDebugNoticesEnabled,
-		ExportAlias:                  rc.ExportAlias,
-		ProviderStr:                  rc.ProviderStr,
-		RegistryRaw:                  rc.RegistryRaw,
-		SessionCtxRaw:                rc.SessionCtxRaw,
-		SQLBackendCfgRaw:             rc.SQLBackendCfgRaw,
-		DBInternalCfgRaw:             rc.DBInternalCfgRaw,
-		NamespaceCfgRaw:              rc.NamespaceCfgRaw,
-		StoreTxnCfgRaw:               rc.StoreTxnCfgRaw,
-		GCCfgRaw:                     rc.GCCfgRaw,
-		ACIDCfgRaw:                   rc.ACIDCfgRaw,
-		QueryCacheSize:               rc.QueryCacheSize,
-		TemplateCtxFilePath:          rc.TemplateCtxFilePath,
-		TestWithoutAPICalls:          rc.TestWithoutAPICalls,
-		UseNonPreferredAPIs:          rc.UseNonPreferredAPIs,
-		VarList:                      rc.VarList,
-		VerboseFlag:                  rc.VerboseFlag,
-		ViperCfgFileName:             rc.ViperCfgFileName,
-		WorkOffline:                  rc.WorkOffline,
+		APIRequestTimeout:             rc.APIRequestTimeout,
+		AuthRaw:                       rc.AuthRaw,
+		CABundle:                      rc.CABundle,
+		AllowInsecure:                 rc.AllowInsecure,
+		CacheKeyCount:                 rc.CacheKeyCount,
+		CacheTTL:                      rc.CacheTTL,
+		ConfigFilePath:                rc.ConfigFilePath,
+		CPUProfile:                    rc.CPUProfile,
+		CSVHeadersDisable:             rc.CSVHeadersDisable,
+		Delimiter:                     rc.Delimiter,
+		DryRunFlag:                    rc.DryRunFlag,
+		ErrorPresentation:             rc.ErrorPresentation,
+		ExecutionConcurrencyLimit:     rc.ExecutionConcurrencyLimit,
+		ExecutionConcurrencyUnordered: rc.ExecutionConcurrencyUnordered,
+		HTTPLogEnabled:                rc.HTTPLogEnabled,
+		HTTPCassettePath:              rc.HTTPCassettePath,
+		HTTPCassetteMode:              rc.HTTPCassetteMode,
+		HTTPCassetteMatch:             rc.HTTPCassetteMatch,
+		HTTPCassetteScrub:             rc.HTTPCassetteScrub,
+		AuditLogPath:                  rc.AuditLogPath,
+		AuditRedact:                   rc.AuditRedact,
+		DryRunFormat:                  rc.DryRunFormat,
+		DryRunOutput:                  rc.DryRunOutput,
+		DryRunShowSecrets:             rc.DryRunShowSecrets,
+		ResponseValidation:            rc.ResponseValidation,
+		HTTPMaxResults:                rc.HTTPMaxResults,
+		HTTPPageLimit:                 rc.HTTPPageLimit,
+		HTTPProxyHost:                 rc.HTTPProxyHost,
+		HTTPProxyPassword:             rc.HTTPProxyPassword,
+		HTTPProxyPort:                 rc.HTTPProxyPort,
+		HTTPProxyScheme:               rc.HTTPProxyScheme,
+		HTTPProxyUser:                 rc.HTTPProxyUser,
+		HTTPProxyRules:                rc.HTTPProxyRules,
+		HTTPProxyNoProxy:              rc.HTTPProxyNoProxy,
+		IndirectDepthMax:              rc.IndirectDepthMax,
+		DataflowComponentsMax:         rc.DataflowComponentsMax,
+		DataflowDependencyMax:         rc.DataflowDependencyMax,
+		InfilePath:                    rc.InfilePath,
+		LogLevelStr:                   rc.LogLevelStr,
+		OutfilePath:                   rc.OutfilePath,
+		OutputFormat:                  rc.OutputFormat,
+		ApplicationFilesRootPath:      rc.ApplicationFilesRootPath,
+		ApplicationFilesRootPathMode:  rc.ApplicationFilesRootPathMode,
+		PGSrvAddress:                  rc.PGSrvAddress,
+		PGSrvLogLevel:                 rc.PGSrvLogLevel,
+		PGSrvPort:                     rc.PGSrvPort,
+		PGSrvRawTLSCfg:                rc.PGSrvRawTLSCfg,
+		PGSrvRawSrvCfg:                rc.PGSrvRawSrvCfg,
+		PGSrvIsDebugNoticesEnabled:    rc.PGSrvIsDebugNoticesEnabled,
+		ExportAlias:                   rc.ExportAlias,
+		ProviderStr:                   rc.ProviderStr,
+		RegistryRaw:                   rc.RegistryRaw,
+		SessionCtxRaw:                 rc.SessionCtxRaw,
+		SQLBackendCfgRaw:              rc.SQLBackendCfgRaw,
+		DBInternalCfgRaw:              rc.DBInternalCfgRaw,
+		NamespaceCfgRaw:               rc.NamespaceCfgRaw,
+		StoreTxnCfgRaw:                rc.StoreTxnCfgRaw,
+		GCCfgRaw:                      rc.GCCfgRaw,
+		ACIDCfgRaw:                    rc.ACIDCfgRaw,
+		QueryCacheSize:                rc.QueryCacheSize,
+		TemplateCtxFilePath:           rc.TemplateCtxFilePath,
+		TestWithoutAPICalls:           rc.TestWithoutAPICalls,
+		UseNonPreferredAPIs:           rc.UseNonPreferredAPIs,
+		VarList:                       rc.VarList,
+		VerboseFlag:                   rc.VerboseFlag,
+		ViperCfgFileName:              rc.ViperCfgFileName,
+		WorkOffline:                   rc.WorkOffline,
 	}
 }
 
@@ -304,3 +360,68 @@ func (rc RuntimeCtx) GetAPIRequestTimeout() int {
 func (rc RuntimeCtx) GetTLSAllowInsecure() bool {
 	return rc.AllowInsecure
 }
+
+func (rc RuntimeCtx) GetCacheTTL() int {
+	return rc.CacheTTL
+}
+
+func (rc RuntimeCtx) GetHTTPCassettePath() string {
+	return rc.HTTPCassettePath
+}
+
+func (rc RuntimeCtx) GetHTTPCassetteMode() string {
+	return rc.HTTPCassetteMode
+}
+
+func (rc RuntimeCtx) GetHTTPCassetteMatchOn() []string {
+	return splitCommaList(rc.HTTPCassetteMatch)
+}
+
+func (rc RuntimeCtx) GetHTTPCassetteScrub() []string {
+	return splitCommaList(rc.HTTPCassetteScrub)
+}
+
+func (rc RuntimeCtx) GetAuditRedact() []string {
+	return splitCommaList(rc.AuditRedact)
+}
+
+func (rc RuntimeCtx) GetDryRun() bool {
+	return rc.DryRunFlag
+}
+
+func (rc RuntimeCtx) GetDryRunFormat() string {
+	return rc.DryRunFormat
+}
+
+func (rc RuntimeCtx) GetDryRunOutput() string {
+	return rc.DryRunOutput
+}
+
+func (rc RuntimeCtx) GetDryRunShowSecrets() bool {
+	return rc.DryRunShowSecrets
+}
+
+func (rc RuntimeCtx) GetHTTPProxyRules() ([]ProxyRule, error) {
+	if rc.HTTPProxyRules == "" {
+		return nil, nil
+	}
+	cfg, err := GetProxyRulesCfg(rc.HTTPProxyRules)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse proxy rules: %w", err)
+	}
+	return cfg.Rules, nil
+}
+
+func (rc RuntimeCtx) GetHTTPProxyNoProxy() string {
+	return rc.HTTPProxyNoProxy
+}
+
+func splitCommaList(s string) []string {
+	var rv []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			rv = append(rv, v)
+		}
+	}
+	return rv
+}
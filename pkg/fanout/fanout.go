@@ -0,0 +1,217 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	_ Executor = &standardExecutor{}
+	_ Task     = &standardTask{}
+	_ error    = &TaskError{}
+)
+
+// Task is one unit of fanned-out work. Run is invoked on a worker goroutine
+// and may execute concurrently with other tasks; it must not touch shared
+// sinks. Commit is invoked serially by the executor once Run has returned,
+// so it is the place to flush buffered side effects (row inserts, messages,
+// log output) into shared, non-thread-safe consumers.
+type Task interface {
+	Run(ctx context.Context) error
+	Commit() error
+}
+
+// Executor runs a batch of tasks under a bounded worker pool.
+//
+// When ordered, commits happen strictly in submission order, so downstream
+// consumers observe the same sequence as a sequential loop would produce.
+// Otherwise commits happen in completion order.
+//
+// Every task is run even if earlier tasks fail; the returned error joins a
+// TaskError for each failure, or is nil when all tasks succeed.
+type Executor interface {
+	Execute(ctx context.Context, tasks []Task) error
+	GetConcurrencyLimit() int
+	IsOrdered() bool
+}
+
+// TaskError annotates a task failure with the task's submission index.
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+func (te *TaskError) Error() string {
+	return fmt.Sprintf("fanout task %d: %s", te.Index, te.Err.Error())
+}
+
+func (te *TaskError) Unwrap() error {
+	return te.Err
+}
+
+type standardTask struct {
+	run    func(ctx context.Context) error
+	commit func() error
+}
+
+// NewTask adapts a pair of closures to the Task interface.
+// Either closure may be nil.
+func NewTask(run func(ctx context.Context) error, commit func() error) Task {
+	return &standardTask{
+		run:    run,
+		commit: commit,
+	}
+}
+
+func (t *standardTask) Run(ctx context.Context) error {
+	if t.run == nil {
+		return nil
+	}
+	return t.run(ctx)
+}
+
+func (t *standardTask) Commit() error {
+	if t.commit == nil {
+		return nil
+	}
+	return t.commit()
+}
+
+type standardExecutor struct {
+	concurrencyLimit int
+	isOrdered        bool
+}
+
+// NewExecutor returns an executor with the given concurrency limit.
+// Limits of 0 and 1 both run one task at a time, mirroring the zero value
+// of dto.RuntimeCtx; a negative limit means unbounded, ie: one worker per task.
+func NewExecutor(concurrencyLimit int, isOrdered bool) Executor {
+	return &standardExecutor{
+		concurrencyLimit: concurrencyLimit,
+		isOrdered:        isOrdered,
+	}
+}
+
+func (ex *standardExecutor) GetConcurrencyLimit() int {
+	return ex.concurrencyLimit
+}
+
+func (ex *standardExecutor) IsOrdered() bool {
+	return ex.isOrdered
+}
+
+type taskOutcome struct {
+	index int
+	err   error
+}
+
+func (ex *standardExecutor) workerCount(taskCount int) int {
+	if ex.concurrencyLimit < 0 || ex.concurrencyLimit > taskCount {
+		return taskCount
+	}
+	if ex.concurrencyLimit == 0 {
+		return 1
+	}
+	return ex.concurrencyLimit
+}
+
+func (ex *standardExecutor) Execute(ctx context.Context, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	indices := make(chan int)
+	outcomes := make(chan taskOutcome, len(tasks))
+	var wg sync.WaitGroup
+	for w := 0; w < ex.workerCount(len(tasks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					outcomes <- taskOutcome{index: i, err: ctxErr}
+					continue
+				}
+				outcomes <- taskOutcome{index: i, err: tasks[i].Run(ctx)}
+			}
+		}()
+	}
+	go func() {
+		for i := range tasks {
+			indices <- i
+		}
+		close(indices)
+		wg.Wait()
+		close(outcomes)
+	}()
+	errs := make([]error, len(tasks))
+	if ex.isOrdered {
+		ex.commitOrdered(tasks, outcomes, errs)
+	} else {
+		for o := range outcomes {
+			errs[o.index] = commitOutcome(tasks[o.index], o)
+		}
+	}
+	var joined []error
+	for i, err := range errs {
+		if err != nil {
+			joined = append(joined, &TaskError{Index: i, Err: err})
+		}
+	}
+	return errors.Join(joined...)
+}
+
+// commitOrdered holds completed outcomes back until every earlier task
+// has been committed.
+func (ex *standardExecutor) commitOrdered(tasks []Task, outcomes <-chan taskOutcome, errs []error) {
+	pending := make(map[int]taskOutcome)
+	next := 0
+	for o := range outcomes {
+		pending[o.index] = o
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			errs[next] = commitOutcome(tasks[next], ready)
+			next++
+		}
+	}
+}
+
+// commitOutcome commits a task regardless of whether Run failed, so that
+// partial output gathered before the failure is not lost.
+func commitOutcome(task Task, o taskOutcome) error {
+	commitErr := task.Commit()
+	if o.err != nil {
+		return o.err
+	}
+	return commitErr
+}
+
+// TaskErrors unpacks the per-task failures from an error returned by Execute.
+func TaskErrors(err error) []*TaskError {
+	if err == nil {
+		return nil
+	}
+	var rv []*TaskError
+	if multi, isMulti := err.(interface{ Unwrap() []error }); isMulti { //nolint:errorlint // we want the direct join only
+		for _, e := range multi.Unwrap() {
+			var te *TaskError
+			if errors.As(e, &te) {
+				rv = append(rv, te)
+			}
+		}
+		return rv
+	}
+	var te *TaskError
+	if errors.As(err, &te) {
+		rv = append(rv, te)
+	}
+	return rv
+}
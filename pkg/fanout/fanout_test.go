@@ -0,0 +1,122 @@
+package fanout_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/fanout"
+)
+
+// reverseDelayTasks builds tasks whose Run duration is inversely proportional
+// to their index, so completion order is the reverse of submission order.
+func reverseDelayTasks(n int, committed *[]int, failOn map[int]bool) []fanout.Task {
+	tasks := make([]fanout.Task, n)
+	for i := 0; i < n; i++ {
+		idx := i
+		tasks[idx] = fanout.NewTask(
+			func(context.Context) error {
+				time.Sleep(time.Duration(n-idx) * 5 * time.Millisecond)
+				if failOn[idx] {
+					return fmt.Errorf("task %d failed", idx)
+				}
+				return nil
+			},
+			func() error {
+				*committed = append(*committed, idx)
+				return nil
+			},
+		)
+	}
+	return tasks
+}
+
+func TestExecute_OrderedCommitsInSubmissionOrder(t *testing.T) {
+	var committed []int
+	err := fanout.NewExecutor(4, true).Execute(context.Background(), reverseDelayTasks(4, &committed, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, v := range committed {
+		if v != i {
+			t.Fatalf("expected commit order [0 1 2 3], got %v", committed)
+		}
+	}
+}
+
+func TestExecute_UnorderedCommitsInCompletionOrder(t *testing.T) {
+	var committed []int
+	err := fanout.NewExecutor(4, false).Execute(context.Background(), reverseDelayTasks(4, &committed, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(committed) != 4 || committed[0] != 3 {
+		t.Fatalf("expected slowest-first task to commit last, got %v", committed)
+	}
+}
+
+func TestExecute_RespectsConcurrencyLimit(t *testing.T) {
+	var inFlight, peak int64
+	tasks := make([]fanout.Task, 12)
+	for i := range tasks {
+		tasks[i] = fanout.NewTask(
+			func(context.Context) error {
+				cur := atomic.AddInt64(&inFlight, 1)
+				for {
+					prev := atomic.LoadInt64(&peak)
+					if cur <= prev || atomic.CompareAndSwapInt64(&peak, prev, cur) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt64(&inFlight, -1)
+				return nil
+			},
+			nil,
+		)
+	}
+	if err := fanout.NewExecutor(3, false).Execute(context.Background(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt64(&peak); got > 3 || got < 1 {
+		t.Fatalf("expected peak concurrency in [1, 3], got %d", got)
+	}
+}
+
+func TestExecute_AggregatesErrorsAndRunsEveryTask(t *testing.T) {
+	var committed []int
+	err := fanout.NewExecutor(2, true).Execute(
+		context.Background(),
+		reverseDelayTasks(5, &committed, map[int]bool{1: true, 3: true}),
+	)
+	if err == nil {
+		t.Fatalf("expected aggregated error")
+	}
+	if len(committed) != 5 {
+		t.Fatalf("expected every task to commit, got %v", committed)
+	}
+	taskErrs := fanout.TaskErrors(err)
+	if len(taskErrs) != 2 || taskErrs[0].Index != 1 || taskErrs[1].Index != 3 {
+		t.Fatalf("expected task errors for indices 1 and 3, got %v", taskErrs)
+	}
+}
+
+func TestExecute_CancelledContextSkipsRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var runs int64
+	tasks := []fanout.Task{
+		fanout.NewTask(func(context.Context) error { atomic.AddInt64(&runs, 1); return nil }, nil),
+		fanout.NewTask(func(context.Context) error { atomic.AddInt64(&runs, 1); return nil }, nil),
+	}
+	err := fanout.NewExecutor(-1, false).Execute(ctx, tasks)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if atomic.LoadInt64(&runs) != 0 {
+		t.Fatalf("expected no task to run after cancellation")
+	}
+}
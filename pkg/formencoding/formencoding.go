@@ -0,0 +1,426 @@
+package formencoding
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	StyleForm           = "form"
+	StyleSpaceDelimited = "spaceDelimited"
+	StylePipeDelimited  = "pipeDelimited"
+	StyleDeepObject     = "deepObject"
+
+	// FileReferencePrefix marks a string value of a binary field as a local
+	// file path, following the curl convention: "@/tmp/report.pdf".
+	FileReferencePrefix = "@"
+
+	defaultFileContentType = "application/octet-stream"
+	jsonContentType        = "application/json"
+)
+
+// FieldEncoding carries the OpenAPI encoding object for one top level
+// property of a form body, plus whether the property holds file content.
+type FieldEncoding struct {
+	ContentType   string
+	Style         string
+	Explode       *bool
+	AllowReserved bool
+	Binary        bool
+}
+
+func (fe FieldEncoding) getStyle() string {
+	if fe.Style == "" {
+		return StyleForm
+	}
+	return fe.Style
+}
+
+func (fe FieldEncoding) isExplode() bool {
+	if fe.Explode == nil {
+		return fe.getStyle() == StyleForm
+	}
+	return *fe.Explode
+}
+
+func (fe FieldEncoding) isJSON() bool {
+	mediaType, _, err := mime.ParseMediaType(fe.ContentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == jsonContentType || strings.HasSuffix(mediaType, "+json")
+}
+
+// isConcreteContentType reports whether the encoding names a single media
+// type, as opposed to a range such as "image/*" or a comma separated list.
+func (fe FieldEncoding) isConcreteContentType() bool {
+	return fe.ContentType != "" && !strings.ContainsAny(fe.ContentType, "*,")
+}
+
+// File is a file part of a multipart body.
+type File struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// EncodeURLEncoded encodes body as application/x-www-form-urlencoded.
+// Top level keys are written in sorted order; each value follows the style
+// and explode rules of its encoding, which default to style form, explode true.
+func EncodeURLEncoded(body interface{}, fields map[string]FieldEncoding) ([]byte, error) {
+	bodyMap, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("form body must be an object, got %T", body)
+	}
+	var pairs []string
+	for _, k := range sortedKeys(bodyMap) {
+		fe := fields[k]
+		kvs, err := encodeFormField(k, bodyMap[k], fe)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			pairs = append(pairs, escape(kv[0], false)+"="+escape(kv[1], fe.AllowReserved))
+		}
+	}
+	return []byte(strings.Join(pairs, "&")), nil
+}
+
+//nolint:gocognit // one branch per style
+func encodeFormField(key string, value interface{}, fe FieldEncoding) ([][2]string, error) {
+	if fe.isJSON() {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return [][2]string{{key, string(b)}}, nil
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		if fe.getStyle() == StyleDeepObject {
+			return encodeDeepObject(key, v)
+		}
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := encodeScalarOrJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, s)
+		}
+		if fe.isExplode() && fe.getStyle() == StyleForm {
+			rv := make([][2]string, 0, len(items))
+			for _, item := range items {
+				rv = append(rv, [2]string{key, item})
+			}
+			return rv, nil
+		}
+		return [][2]string{{key, strings.Join(items, arrayDelimiter(fe.getStyle()))}}, nil
+	case map[string]interface{}:
+		switch {
+		case fe.getStyle() == StyleDeepObject:
+			return encodeDeepObject(key, v)
+		case fe.isExplode():
+			var rv [][2]string
+			for _, k := range sortedKeys(v) {
+				s, err := encodeScalarOrJSON(v[k])
+				if err != nil {
+					return nil, err
+				}
+				rv = append(rv, [2]string{k, s})
+			}
+			return rv, nil
+		default:
+			var items []string
+			for _, k := range sortedKeys(v) {
+				s, err := encodeScalarOrJSON(v[k])
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, k, s)
+			}
+			return [][2]string{{key, strings.Join(items, ",")}}, nil
+		}
+	default:
+		s, err := encodeScalarOrJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return [][2]string{{key, s}}, nil
+	}
+}
+
+// encodeDeepObject writes nested objects and arrays as bracketed keys, as
+// in "metadata[order_id]=6735" or "items[0][price]=5".
+func encodeDeepObject(prefix string, value interface{}) ([][2]string, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var rv [][2]string
+		for _, k := range sortedKeys(v) {
+			kvs, err := encodeDeepObject(prefix+"["+k+"]", v[k])
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, kvs...)
+		}
+		return rv, nil
+	case []interface{}:
+		var rv [][2]string
+		for i, item := range v {
+			kvs, err := encodeDeepObject(prefix+"["+strconv.Itoa(i)+"]", item)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, kvs...)
+		}
+		return rv, nil
+	default:
+		s, err := encodeScalarOrJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return [][2]string{{prefix, s}}, nil
+	}
+}
+
+func arrayDelimiter(style string) string {
+	switch style {
+	case StyleSpaceDelimited:
+		return " "
+	case StylePipeDelimited:
+		return "|"
+	default:
+		return ","
+	}
+}
+
+// encodeScalarOrJSON renders a scalar as text. Composite values nested below
+// the level their style covers are rendered as JSON.
+func encodeScalarOrJSON(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case json.Number:
+		return v.String(), nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		return string(b), err
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// reservedReplacer undoes the escaping of RFC 3986 reserved characters that
+// are safe inside a form value, for fields with allowReserved.
+var reservedReplacer = strings.NewReplacer( //nolint:gochecknoglobals // read-only table
+	"%3A", ":", "%2F", "/", "%3F", "?", "%5B", "[", "%5D", "]", "%40", "@",
+	"%21", "!", "%24", "$", "%27", "'", "%28", "(", "%29", ")", "%2A", "*",
+	"%2C", ",", "%3B", ";",
+)
+
+func escape(s string, allowReserved bool) string {
+	escaped := url.QueryEscape(s)
+	if allowReserved {
+		return reservedReplacer.Replace(escaped)
+	}
+	return escaped
+}
+
+// EncodeMultipart encodes body as multipart/form-data and returns the bytes
+// together with the Content-Type header value, which carries the boundary.
+//
+// Each top level key becomes one part, or one part per element for arrays.
+// Binary fields, []byte values and File values become file parts; a string
+// value of a binary field that starts with "@" names a local file to read.
+// Objects are sent as application/json parts unless the encoding says otherwise.
+func EncodeMultipart(body interface{}, fields map[string]FieldEncoding) ([]byte, string, error) {
+	bodyMap, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("multipart body must be an object, got %T", body)
+	}
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, k := range sortedKeys(bodyMap) {
+		fe := fields[k]
+		values := []interface{}{bodyMap[k]}
+		if arr, isArr := bodyMap[k].([]interface{}); isArr && !fe.isJSON() {
+			values = arr
+		}
+		for _, v := range values {
+			if err := writePart(w, k, v, fe); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+func writePart(w *multipart.Writer, key string, value interface{}, fe FieldEncoding) error {
+	f, isFile, err := resolveFile(key, value, fe)
+	if err != nil {
+		return err
+	}
+	if isFile {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(key), escapeQuotes(f.Name)))
+		h.Set("Content-Type", f.ContentType)
+		pw, partErr := w.CreatePart(h)
+		if partErr != nil {
+			return partErr
+		}
+		_, err = pw.Write(f.Content)
+		return err
+	}
+	var content []byte
+	contentType := fe.ContentType
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		content, err = json.Marshal(value)
+		if !fe.isJSON() {
+			contentType = jsonContentType
+		}
+	default:
+		if fe.isJSON() {
+			content, err = json.Marshal(value)
+		} else {
+			var s string
+			s, err = encodeScalarOrJSON(value)
+			content = []byte(s)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(key)))
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	pw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(content)
+	return err
+}
+
+// resolveFile decides whether value is file content and, if so, loads it.
+// A map is a file when it carries any of "path", "content" or
+// "content_base64"; "filename" and "content_type" override the defaults.
+//
+//nolint:gocognit // one branch per source
+func resolveFile(key string, value interface{}, fe FieldEncoding) (File, bool, error) {
+	var f File
+	switch v := value.(type) {
+	case File:
+		f = v
+	case *File:
+		f = *v
+	case []byte:
+		f = File{Name: key, Content: v}
+	case string:
+		if !fe.Binary {
+			return File{}, false, nil
+		}
+		if !strings.HasPrefix(v, FileReferencePrefix) {
+			f = File{Name: key, Content: []byte(v)}
+			break
+		}
+		loaded, err := readFile(strings.TrimPrefix(v, FileReferencePrefix))
+		if err != nil {
+			return File{}, false, err
+		}
+		f = loaded
+	case map[string]interface{}:
+		path, hasPath := v["path"].(string)
+		content, hasContent := v["content"].(string)
+		encoded, hasEncoded := v["content_base64"].(string)
+		switch {
+		case hasPath:
+			loaded, err := readFile(path)
+			if err != nil {
+				return File{}, false, err
+			}
+			f = loaded
+		case hasEncoded:
+			b, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return File{}, false, fmt.Errorf("field '%s': invalid content_base64: %w", key, err)
+			}
+			f = File{Name: key, Content: b}
+		case hasContent:
+			f = File{Name: key, Content: []byte(content)}
+		default:
+			return File{}, false, nil
+		}
+		if name, ok := v["filename"].(string); ok && name != "" {
+			f.Name = name
+		}
+		if ct, ok := v["content_type"].(string); ok && ct != "" {
+			f.ContentType = ct
+		}
+	default:
+		return File{}, false, nil
+	}
+	if f.ContentType == "" && fe.isConcreteContentType() {
+		f.ContentType = fe.ContentType
+	}
+	if f.ContentType == "" {
+		f.ContentType = mime.TypeByExtension(filepath.Ext(f.Name))
+	}
+	if f.ContentType == "" {
+		f.ContentType = defaultFileContentType
+	}
+	return f, true, nil
+}
+
+func readFile(path string) (File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("could not read file part '%s': %w", path, err)
+	}
+	return File{Name: filepath.Base(path), Content: b}, nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`) //nolint:gochecknoglobals // read-only table
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package formencoding
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeURLEncoded_Styles(t *testing.T) {
+	noExplode := false
+	body := map[string]interface{}{
+		"amount":   float64(2000),
+		"currency": "usd",
+		"expand":   []interface{}{"customer", "invoice"},
+		"metadata": map[string]interface{}{"order_id": "6735"},
+		"items": []interface{}{
+			map[string]interface{}{"price": "price_1", "quantity": float64(2)},
+		},
+		"tags":     []interface{}{"a", "b"},
+		"filter":   map[string]interface{}{"x": "1", "y": "2"},
+		"callback": "https://example.com/cb?x=1",
+		"config":   map[string]interface{}{"on": true},
+	}
+	fields := map[string]FieldEncoding{
+		"metadata": {Style: StyleDeepObject},
+		"items":    {Style: StyleDeepObject},
+		"tags":     {Style: StylePipeDelimited},
+		"filter":   {Style: StyleForm, Explode: &noExplode},
+		"callback": {AllowReserved: true},
+		"config":   {ContentType: "application/json"},
+	}
+	b, err := EncodeURLEncoded(body, fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "amount=2000" +
+		"&callback=https://example.com/cb?x%3D1" +
+		"&config=%7B%22on%22%3Atrue%7D" +
+		"&currency=usd" +
+		"&expand=customer&expand=invoice" +
+		"&filter=x%2C1%2Cy%2C2" +
+		"&items%5B0%5D%5Bprice%5D=price_1&items%5B0%5D%5Bquantity%5D=2" +
+		"&metadata%5Border_id%5D=6735" +
+		"&tags=a%7Cb"
+	if string(b) != expected {
+		t.Fatalf("unexpected body:\n got: %s\nwant: %s", b, expected)
+	}
+}
+
+func TestEncodeURLEncoded_ExplodedObjectFlattens(t *testing.T) {
+	b, err := EncodeURLEncoded(map[string]interface{}{
+		"To": map[string]interface{}{"Body": "hi", "From": "+1555"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != "Body=hi&From=%2B1555" {
+		t.Fatalf("unexpected body: %s", b)
+	}
+}
+
+func TestEncodeURLEncoded_RejectsNonObject(t *testing.T) {
+	if _, err := EncodeURLEncoded([]interface{}{"a"}, nil); err == nil {
+		t.Fatalf("expected an error for a non-object body")
+	}
+}
+
+type part struct {
+	name, filename, contentType string
+	content                     []byte
+}
+
+func readParts(t *testing.T, b []byte, contentType string) []part {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		t.Fatalf("unexpected content type %q: %v", contentType, err)
+	}
+	r := multipart.NewReader(bytes.NewReader(b), params["boundary"])
+	var rv []part
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return rv
+		}
+		if err != nil {
+			t.Fatalf("unexpected part error: %v", err)
+		}
+		content, _ := io.ReadAll(p)
+		rv = append(rv, part{p.FormName(), p.FileName(), p.Header.Get("Content-Type"), content})
+	}
+}
+
+func TestEncodeMultipart_Parts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.7"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	body := map[string]interface{}{
+		"description": "quarterly",
+		"file":        "@" + path,
+		"avatar": map[string]interface{}{
+			"filename":       "me.png",
+			"content_base64": base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}),
+		},
+		"raw":      []byte("bytes"),
+		"labels":   []interface{}{"x", "y"},
+		"metadata": map[string]interface{}{"k": "v"},
+		"note":     "@not-a-file",
+	}
+	fields := map[string]FieldEncoding{
+		"file":   {Binary: true},
+		"avatar": {Binary: true, ContentType: "image/png, image/jpeg"},
+	}
+	b, contentType, err := EncodeMultipart(body, fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := readParts(t, b, contentType)
+	expected := []part{
+		{"avatar", "me.png", "image/png", []byte{0x89, 'P', 'N', 'G'}},
+		{"description", "", "", []byte("quarterly")},
+		{"file", "report.pdf", "application/pdf", []byte("%PDF-1.7")},
+		{"labels", "", "", []byte("x")},
+		{"labels", "", "", []byte("y")},
+		{"metadata", "", "application/json", []byte(`{"k":"v"}`)},
+		{"note", "", "", []byte("@not-a-file")},
+		{"raw", "raw", "application/octet-stream", []byte("bytes")},
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d: %+v", len(expected), len(parts), parts)
+	}
+	for i, p := range parts {
+		e := expected[i]
+		if p.name != e.name || p.filename != e.filename || p.contentType != e.contentType || !bytes.Equal(p.content, e.content) {
+			t.Fatalf("part %d: got %+v, want %+v", i, p, e)
+		}
+	}
+}
+
+func TestEncodeMultipart_MissingFile(t *testing.T) {
+	_, _, err := EncodeMultipart(
+		map[string]interface{}{"file": "@" + filepath.Join(t.TempDir(), "absent.bin")},
+		map[string]FieldEncoding{"file": {Binary: true}},
+	)
+	if err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
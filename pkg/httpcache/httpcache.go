@@ -0,0 +1,385 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+const (
+	// StatusHeader reports how a response was served: one of the Status* values.
+	StatusHeader = "X-Stackql-Cache"
+
+	StatusHit         = "hit"
+	StatusMiss        = "miss"
+	StatusRevalidated = "revalidated"
+
+	keyPrefix = "httpcache:"
+)
+
+var (
+	_ http.RoundTripper = &cachingTransport{}
+
+	// defaultKeyHeaders partition the cache by caller identity, so credentials
+	// never share entries.
+	defaultKeyHeaders = []string{
+		"Authorization",
+		"X-Api-Key",
+	}
+
+	cacheableStatusCodes = map[int]struct{}{
+		http.StatusOK:                   {},
+		http.StatusNonAuthoritativeInfo: {},
+	}
+)
+
+// Store is the persistence the cache writes through. It is satisfied by the
+// SQL engines and persistence systems, whose CacheStorePut expires entries
+// after ttl seconds. A missing or expired entry is reported as an error.
+type Store interface {
+	CacheStoreGet(key string) ([]byte, error)
+	CacheStorePut(key string, value []byte, expiration string, ttl int) error
+}
+
+// Config tunes the cache. The zero value caches only responses carrying
+// explicit freshness (Cache-Control max-age or Expires) or validators.
+type Config struct {
+	// DefaultTTL is the freshness lifetime for responses without explicit
+	// freshness information. When zero, the client configurator fills it from
+	// RuntimeCtx.CacheTTL; zero there too means such responses are always
+	// revalidated.
+	DefaultTTL time.Duration
+	// RetentionTTL is how long a stale entry with validators (ETag or
+	// Last-Modified) is kept for revalidation. Zero means DefaultTTL.
+	RetentionTTL time.Duration
+	// KeyHeaders are request headers folded into the cache key in addition
+	// to the method and URL. Defaults to Authorization and X-Api-Key.
+	KeyHeaders []string
+	// Now overrides the clock, for tests.
+	Now func() time.Time
+}
+
+func (c Config) getKeyHeaders() []string {
+	if len(c.KeyHeaders) == 0 {
+		return defaultKeyHeaders
+	}
+	return c.KeyHeaders
+}
+
+func (c Config) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// ConfigFromRuntimeCtx derives a config whose default freshness lifetime is
+// the runtime context's cache TTL (in seconds).
+func ConfigFromRuntimeCtx(rtCtx dto.RuntimeCtx) Config {
+	return Config{
+		DefaultTTL: time.Duration(rtCtx.CacheTTL) * time.Second,
+	}
+}
+
+// entry is the stored form of a response.
+type entry struct {
+	StatusCode int                 `json:"status_code"`
+	Header     http.Header         `json:"header"`
+	Body       []byte              `json:"body"`
+	StoredAt   time.Time           `json:"stored_at"`
+	FreshUntil time.Time           `json:"fresh_until"`
+	Vary       map[string][]string `json:"vary,omitempty"`
+}
+
+func (e *entry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+func (e *entry) matchesVary(req *http.Request) bool {
+	for name, values := range e.Vary {
+		if strings.Join(req.Header.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *entry) toResponse(req *http.Request, status string, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set(StatusHeader, status)
+	header.Set("Age", strconv.Itoa(int(math.Max(0, now.Sub(e.StoredAt).Seconds()))))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+type cachingTransport struct {
+	next  http.RoundTripper
+	store Store
+	cfg   Config
+}
+
+// NewTransport returns a RoundTripper that serves GET requests from store
+// while fresh, revalidates stale entries with If-None-Match /
+// If-Modified-Since, and invalidates the URL on successful unsafe methods.
+// Cache-Control no-store (on request or response) bypasses the cache and
+// no-cache forces revalidation. Store failures degrade to a cache miss.
+func NewTransport(next http.RoundTripper, store Store, cfg Config) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cachingTransport{
+		next:  next,
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// GetTransport returns the wrapped RoundTripper.
+func (t *cachingTransport) GetTransport() http.RoundTripper {
+	return t.next
+}
+
+// WithTransport returns a copy of the cache wrapping next instead.
+func (t *cachingTransport) WithTransport(next http.RoundTripper) http.RoundTripper {
+	return NewTransport(next, t.store, t.cfg)
+}
+
+// WithDefaultTTL returns a copy of the cache using ttl as its default
+// freshness lifetime, unless one is already configured.
+func (t *cachingTransport) WithDefaultTTL(ttl time.Duration) http.RoundTripper {
+	if t.cfg.DefaultTTL > 0 {
+		return t
+	}
+	cfg := t.cfg
+	cfg.DefaultTTL = ttl
+	return NewTransport(t.next, t.store, cfg)
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && isUnsafe(req.Method) && resp.StatusCode < http.StatusBadRequest {
+			t.invalidate(req)
+		}
+		return resp, err
+	}
+	reqDirectives := parseCacheControl(req.Header)
+	if _, noStore := reqDirectives["no-store"]; noStore {
+		return t.next.RoundTrip(req)
+	}
+	key := t.key(req)
+	now := t.cfg.now()
+	cached, hasCached := t.load(key, req)
+	_, forceRevalidate := reqDirectives["no-cache"]
+	if hasCached && !forceRevalidate && now.Before(cached.FreshUntil) {
+		return cached.toResponse(req, StatusHit, now), nil
+	}
+	outReq := req
+	if hasCached && cached.hasValidators() {
+		outReq = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			outReq.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+	resp, err := t.next.RoundTrip(outReq)
+	if err != nil {
+		return resp, err
+	}
+	now = t.cfg.now()
+	if resp.StatusCode == http.StatusNotModified && hasCached && outReq != req {
+		_ = resp.Body.Close()
+		for k, v := range resp.Header {
+			if k != "Content-Length" {
+				cached.Header[k] = v
+			}
+		}
+		cached.StoredAt = now
+		cached.FreshUntil = t.freshUntil(cached.Header, now)
+		t.save(key, cached, now)
+		return cached.toResponse(req, StatusRevalidated, now), nil
+	}
+	if _, cacheable := cacheableStatusCodes[resp.StatusCode]; !cacheable {
+		return resp, nil
+	}
+	if _, noStore := parseCacheControl(resp.Header)["no-store"]; noStore || isVaryAll(resp.Header) {
+		return resp, nil
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, readErr
+	}
+	fresh := &entry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   now,
+		FreshUntil: t.freshUntil(resp.Header, now),
+		Vary:       varyValues(req, resp.Header),
+	}
+	t.save(key, fresh, now)
+	resp.Header.Set(StatusHeader, StatusMiss)
+	return resp, nil
+}
+
+// freshUntil applies Cache-Control max-age (and no-cache), then Expires,
+// then the configured default.
+func (t *cachingTransport) freshUntil(header http.Header, now time.Time) time.Time {
+	directives := parseCacheControl(header)
+	if _, noCache := directives["no-cache"]; noCache {
+		return now
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		if secs, err := strconv.Atoi(maxAge); err == nil {
+			return now.Add(time.Duration(secs) * time.Second)
+		}
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		if at, err := http.ParseTime(expires); err == nil {
+			return at
+		}
+		return now
+	}
+	return now.Add(t.cfg.DefaultTTL)
+}
+
+// retention is how long the store should keep an entry: while it is fresh
+// and, when it can be revalidated, for the retention period beyond.
+func (t *cachingTransport) retention(e *entry, now time.Time) time.Duration {
+	rv := e.FreshUntil.Sub(now)
+	if e.hasValidators() {
+		retention := t.cfg.RetentionTTL
+		if retention <= 0 {
+			retention = t.cfg.DefaultTTL
+		}
+		if retention > rv {
+			rv = retention
+		}
+	}
+	return rv
+}
+
+func (t *cachingTransport) load(key string, req *http.Request) (*entry, bool) {
+	raw, err := t.store.CacheStoreGet(key)
+	if err != nil || len(raw) == 0 {
+		return nil, false
+	}
+	var rv entry
+	if jsonErr := json.Unmarshal(raw, &rv); jsonErr != nil {
+		return nil, false
+	}
+	if rv.Header == nil || !rv.matchesVary(req) {
+		return nil, false
+	}
+	return &rv, true
+}
+
+func (t *cachingTransport) save(key string, e *entry, now time.Time) {
+	ttl := int(math.Ceil(t.retention(e, now).Seconds()))
+	if ttl <= 0 {
+		return
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	_ = t.store.CacheStorePut(key, raw, "", ttl)
+}
+
+// invalidate overwrites the GET entry for the request URL with a short lived
+// empty value, which load treats as a miss.
+func (t *cachingTransport) invalidate(req *http.Request) {
+	getReq := req.Clone(req.Context())
+	getReq.Method = http.MethodGet
+	_ = t.store.CacheStorePut(t.key(getReq), nil, "", 1)
+}
+
+// key is derived from the method, URL and key headers. Header values are
+// hashed so that credentials are never stored in the clear.
+func (t *cachingTransport) key(req *http.Request) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", req.Method, req.URL.String())
+	for _, name := range t.cfg.getKeyHeaders() {
+		fmt.Fprintf(h, "%s=%s\n", http.CanonicalHeaderKey(name), strings.Join(req.Header.Values(name), ","))
+	}
+	return keyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+func varyValues(req *http.Request, header http.Header) map[string][]string {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" && name != "*" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	rv := make(map[string][]string, len(names))
+	for _, name := range names {
+		rv[name] = req.Header.Values(name)
+	}
+	return rv
+}
+
+func isVaryAll(header http.Header) bool {
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if strings.TrimSpace(name) == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseCacheControl(header http.Header) map[string]string {
+	rv := make(map[string]string)
+	for _, v := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(directive, "=")
+			rv[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return rv
+}
+
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	default:
+		return true
+	}
+}
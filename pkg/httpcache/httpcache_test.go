@@ -0,0 +1,215 @@
+package httpcache
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/netutils"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// memoryStore mimics the SQL engine cache store semantics against a fake clock.
+type memoryStore struct {
+	mutex   sync.Mutex
+	now     func() time.Time
+	entries map[string]memoryEntry
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{now: now, entries: make(map[string]memoryEntry)}
+}
+
+func (s *memoryStore) CacheStoreGet(key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[key]
+	if !ok || (!e.expiresAt.IsZero() && !s.now().Before(e.expiresAt)) {
+		return nil, sql.ErrNoRows
+	}
+	return e.value, nil
+}
+
+func (s *memoryStore) CacheStorePut(key string, value []byte, _ string, ttl int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e := memoryEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = s.now().Add(time.Duration(ttl) * time.Second)
+	}
+	s.entries[key] = e
+	return nil
+}
+
+type fakeClock struct {
+	mutex sync.Mutex
+	t     time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, cfg Config) (*http.Client, string, *fakeClock, *int64) {
+	t.Helper()
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	cfg.Now = clock.now
+	return &http.Client{Transport: NewTransport(nil, newMemoryStore(clock.now), cfg)}, srv.URL, clock, &calls
+}
+
+func get(t *testing.T, c *http.Client, url string, header map[string]string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestCache_MaxAgeServesFromStoreUntilStale(t *testing.T) {
+	c, url, clock, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = io.WriteString(w, "inventory")
+	}, Config{})
+
+	resp, body := get(t, c, url, nil)
+	if resp.Header.Get(StatusHeader) != StatusMiss || body != "inventory" {
+		t.Fatalf("expected miss with body, got %s / %q", resp.Header.Get(StatusHeader), body)
+	}
+	resp, body = get(t, c, url, nil)
+	if resp.Header.Get(StatusHeader) != StatusHit || body != "inventory" || atomic.LoadInt64(calls) != 1 {
+		t.Fatalf("expected hit without a request, got %s / %q after %d calls", resp.Header.Get(StatusHeader), body, atomic.LoadInt64(calls))
+	}
+	clock.advance(61 * time.Second)
+	if resp, _ = get(t, c, url, nil); resp.Header.Get(StatusHeader) != StatusMiss || atomic.LoadInt64(calls) != 2 {
+		t.Fatalf("expected expired entry to be refetched")
+	}
+}
+
+func TestCache_ETagRevalidation(t *testing.T) {
+	c, url, _, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "body-v1")
+	}, Config{RetentionTTL: time.Hour})
+
+	get(t, c, url, nil)
+	resp, body := get(t, c, url, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(StatusHeader) != StatusRevalidated || body != "body-v1" {
+		t.Fatalf("expected revalidated 200 with stored body, got %d %s %q", resp.StatusCode, resp.Header.Get(StatusHeader), body)
+	}
+	if got := atomic.LoadInt64(calls); got != 2 {
+		t.Fatalf("expected a conditional request, got %d calls", got)
+	}
+}
+
+func TestCache_KeyedByCredentialsAndVary(t *testing.T) {
+	c, url, _, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept")
+		_, _ = io.WriteString(w, r.Header.Get("Authorization")+"|"+r.Header.Get("Accept"))
+	}, Config{})
+
+	get(t, c, url, map[string]string{"Authorization": "Bearer a", "Accept": "application/json"})
+	if _, body := get(t, c, url, map[string]string{"Authorization": "Bearer b", "Accept": "application/json"}); body != "Bearer b|application/json" {
+		t.Fatalf("expected a distinct entry per credential, got %q", body)
+	}
+	if _, body := get(t, c, url, map[string]string{"Authorization": "Bearer a", "Accept": "text/csv"}); body != "Bearer a|text/csv" {
+		t.Fatalf("expected a Vary mismatch to miss, got %q", body)
+	}
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Fatalf("expected 3 origin calls, got %d", got)
+	}
+}
+
+func TestCache_NoStoreAndDefaultTTL(t *testing.T) {
+	c, url, _, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		_, _ = io.WriteString(w, "x")
+	}, Config{DefaultTTL: time.Minute})
+
+	get(t, c, url+"/private", nil)
+	get(t, c, url+"/private", nil)
+	get(t, c, url+"/plain", nil)
+	if resp, _ := get(t, c, url+"/plain", nil); resp.Header.Get(StatusHeader) != StatusHit {
+		t.Fatalf("expected default TTL to make a plain response cacheable")
+	}
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Fatalf("expected no-store to bypass the cache, got %d calls", got)
+	}
+}
+
+func TestCache_UnsafeMethodInvalidates(t *testing.T) {
+	c, url, _, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = io.WriteString(w, "x")
+	}, Config{})
+
+	get(t, c, url, nil)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp, _ := get(t, c, url, nil); resp.Header.Get(StatusHeader) != StatusMiss {
+		t.Fatalf("expected DELETE to invalidate the cached GET")
+	}
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Fatalf("expected 3 origin calls, got %d", got)
+	}
+}
+
+func TestCache_DefaultTTLFromRuntimeCtx(t *testing.T) {
+	c, url, _, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "x")
+	}, Config{})
+	c = netutils.GetHTTPClient(dto.RuntimeCtx{CacheTTL: 60}, c)
+
+	get(t, c, url, nil)
+	if resp, _ := get(t, c, url, nil); resp.Header.Get(StatusHeader) != StatusHit {
+		t.Fatalf("expected the runtime context cache TTL to make a plain response cacheable")
+	}
+	if got := atomic.LoadInt64(calls); got != 1 {
+		t.Fatalf("expected 1 origin call, got %d", got)
+	}
+}
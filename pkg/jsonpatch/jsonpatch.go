@@ -0,0 +1,144 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Operation is one RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps an explicit null value on add and replace, which the
+// omitempty tag would otherwise drop.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == OpRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// CreateMergePatch returns the RFC 7396 merge patch that turns current into
+// desired. Only the keys of desired are considered, so a partial desired
+// state leaves other members alone; a nil value removes the member. Nested
+// objects are diffed recursively and arrays are replaced whole. A nil
+// current yields desired unchanged.
+func CreateMergePatch(current, desired map[string]interface{}) map[string]interface{} {
+	if current == nil {
+		return desired
+	}
+	rv := make(map[string]interface{})
+	for k, dv := range desired {
+		cv, exists := current[k]
+		if dv == nil {
+			if exists {
+				rv[k] = nil
+			}
+			continue
+		}
+		if exists && equal(cv, dv) {
+			continue
+		}
+		dm, dIsMap := dv.(map[string]interface{})
+		cm, cIsMap := cv.(map[string]interface{})
+		if exists && dIsMap && cIsMap {
+			rv[k] = CreateMergePatch(cm, dm)
+			continue
+		}
+		rv[k] = dv
+	}
+	return rv
+}
+
+// CreateJSONPatch returns the RFC 6902 operations that turn current into
+// desired, in key order. As with CreateMergePatch only the keys of desired
+// are considered and a nil value removes the member. Without a current
+// state every member is written with add, which replaces existing members.
+func CreateJSONPatch(current, desired map[string]interface{}) []Operation {
+	return appendJSONPatch(nil, "", current, desired, current != nil)
+}
+
+func appendJSONPatch(ops []Operation, prefix string, current, desired map[string]interface{}, known bool) []Operation {
+	for _, k := range sortedKeys(desired) {
+		path := prefix + "/" + EscapePointerToken(k)
+		dv := desired[k]
+		if !known {
+			if dv == nil {
+				ops = append(ops, Operation{Op: OpRemove, Path: path})
+			} else {
+				ops = append(ops, Operation{Op: OpAdd, Path: path, Value: dv})
+			}
+			continue
+		}
+		cv, exists := current[k]
+		switch {
+		case dv == nil:
+			if exists {
+				ops = append(ops, Operation{Op: OpRemove, Path: path})
+			}
+		case !exists:
+			ops = append(ops, Operation{Op: OpAdd, Path: path, Value: dv})
+		case equal(cv, dv):
+		default:
+			dm, dIsMap := dv.(map[string]interface{})
+			cm, cIsMap := cv.(map[string]interface{})
+			if dIsMap && cIsMap {
+				ops = appendJSONPatch(ops, path, cm, dm, true)
+				continue
+			}
+			ops = append(ops, Operation{Op: OpReplace, Path: path, Value: dv})
+		}
+	}
+	return ops
+}
+
+// EscapePointerToken escapes a member name for use in a JSON Pointer.
+func EscapePointerToken(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// equal compares two decoded JSON values. Values are round tripped through
+// encoding/json first, so that an int and the float64 of the same number,
+// as produced by decoding, compare equal.
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalise(a), normalise(b))
+}
+
+func normalise(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var rv interface{}
+	if err := json.Unmarshal(b, &rv); err != nil {
+		return v
+	}
+	return rv
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
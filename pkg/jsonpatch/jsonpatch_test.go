@@ -0,0 +1,81 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+func decode(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var rv map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &rv); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return rv
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	current := decode(t, `{"name":"vm","tags":{"env":"dev","team":"a"},"size":2,"zones":["a"],"note":"x"}`)
+	desired := map[string]interface{}{
+		"name":  "vm",
+		"tags":  map[string]interface{}{"env": "prod", "team": "a"},
+		"size":  2,
+		"zones": []interface{}{"a", "b"},
+		"note":  nil,
+		"gone":  nil,
+	}
+	got := mustJSON(t, CreateMergePatch(current, desired))
+	expected := `{"note":null,"tags":{"env":"prod"},"zones":["a","b"]}`
+	if got != expected {
+		t.Fatalf("got %s, want %s", got, expected)
+	}
+}
+
+func TestCreateMergePatch_NoCurrent(t *testing.T) {
+	desired := map[string]interface{}{"a": float64(1), "b": nil}
+	got := mustJSON(t, CreateMergePatch(nil, desired))
+	if got != `{"a":1,"b":null}` {
+		t.Fatalf("unexpected patch %s", got)
+	}
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	current := decode(t, `{"metadata":{"labels":{"app":"web"}},"spec":{"replicas":1},"old":true}`)
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web", "a/b": "c"}},
+		"spec":     map[string]interface{}{"replicas": 3},
+		"old":      nil,
+		"status":   nil,
+	}
+	got := mustJSON(t, CreateJSONPatch(current, desired))
+	expected := `[{"op":"add","path":"/metadata/labels/a~1b","value":"c"},` +
+		`{"op":"remove","path":"/old"},` +
+		`{"op":"replace","path":"/spec/replicas","value":3}]`
+	if got != expected {
+		t.Fatalf("got %s, want %s", got, expected)
+	}
+}
+
+func TestCreateJSONPatch_NoCurrent(t *testing.T) {
+	got := mustJSON(t, CreateJSONPatch(nil, map[string]interface{}{"x~y": "1", "z": nil}))
+	expected := `[{"op":"add","path":"/x~0y","value":"1"},{"op":"remove","path":"/z"}]`
+	if got != expected {
+		t.Fatalf("got %s, want %s", got, expected)
+	}
+}
+
+func TestOperation_KeepsNullValue(t *testing.T) {
+	got := mustJSON(t, Operation{Op: OpReplace, Path: "/a", Value: nil})
+	if got != `{"op":"replace","path":"/a","value":null}` {
+		t.Fatalf("unexpected operation %s", got)
+	}
+}
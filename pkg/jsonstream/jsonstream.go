@@ -0,0 +1,288 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrResponseTooLarge is returned by readers from LimitReader once more
+	// than the limit has been read.
+	ErrResponseTooLarge = errors.New("response body exceeds size limit")
+)
+
+const (
+	stateSearching = iota
+	stateStreaming
+	stateFinishing
+	stateDone
+)
+
+// ParsePath splits a JSON path into object keys. Accepted forms are "$",
+// "$.a.b", "$['a'].b" and a bare key such as "items", each optionally
+// ending in "[*]". The bool is false for paths that address anything other
+// than a chain of object keys, such as filters, indexes or wildcards before
+// the last segment.
+//
+//nolint:gocognit // small hand written scanner
+func ParsePath(path string) ([]string, bool) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "$" || path == "$[*]" {
+		return nil, true
+	}
+	if !strings.HasPrefix(path, "$") {
+		if strings.ContainsAny(path, "$.[]*?@/") {
+			return nil, false
+		}
+		return []string{path}, true
+	}
+	path = strings.TrimSuffix(path, "[*]")
+	rest := path[1:]
+	var rv []string
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end < 0 {
+				return nil, false
+			}
+			rv = append(rv, rest[2:2+end])
+			rest = rest[2+end+2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" || strings.ContainsAny(key, "*?@$") {
+				return nil, false
+			}
+			rv = append(rv, key)
+			rest = rest[end:]
+		default:
+			return nil, false
+		}
+	}
+	return rv, true
+}
+
+// Streamer walks a JSON document's token stream to an array at a path and
+// yields its elements one at a time, without holding the document in memory.
+// Everything outside the array is kept and is available from Remainder once
+// Next has returned io.EOF.
+type Streamer struct {
+	dec       *json.Decoder
+	path      []string
+	stack     []*level
+	remainder interface{}
+	state     int
+	found     bool
+	count     int
+}
+
+type level struct {
+	obj       map[string]interface{}
+	descended bool
+}
+
+// NewStreamer returns a Streamer over the array at path in r. See ParsePath
+// for the accepted paths.
+func NewStreamer(r io.Reader, path string) (*Streamer, error) {
+	keys, ok := ParsePath(path)
+	if !ok {
+		return nil, fmt.Errorf("path '%s' cannot be streamed", path)
+	}
+	return &Streamer{dec: json.NewDecoder(r), path: keys}, nil
+}
+
+// Next returns the next element of the array, or io.EOF after the last one.
+// When the path does not lead to an array there are no elements, and the
+// whole document is in Remainder.
+func (s *Streamer) Next() (interface{}, error) {
+	if s.state == stateSearching {
+		if err := s.start(); err != nil {
+			return nil, err
+		}
+	}
+	if s.state == stateStreaming {
+		if s.dec.More() {
+			var rv interface{}
+			if err := s.dec.Decode(&rv); err != nil {
+				return nil, err
+			}
+			s.count++
+			return rv, nil
+		}
+		if _, err := s.dec.Token(); err != nil {
+			return nil, err
+		}
+		s.state = stateFinishing
+	}
+	if s.state == stateFinishing {
+		if err := s.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, io.EOF
+}
+
+// Found reports whether the path led to an array. It is only meaningful
+// after the first call to Next.
+func (s *Streamer) Found() bool {
+	return s.found
+}
+
+// GetCount returns the number of elements yielded so far.
+func (s *Streamer) GetCount() int {
+	return s.count
+}
+
+// Remainder returns the document without the streamed array, for example to
+// read pagination tokens. It is complete once Next has returned io.EOF.
+func (s *Streamer) Remainder() interface{} {
+	return s.remainder
+}
+
+func (s *Streamer) start() error {
+	t, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if len(s.path) == 0 && t == json.Delim('[') {
+		s.found = true
+		s.state = stateStreaming
+		return nil
+	}
+	if len(s.path) == 0 || t != json.Delim('{') {
+		s.remainder, err = s.decodeFrom(t)
+		s.state = stateDone
+		return err
+	}
+	root := make(map[string]interface{})
+	s.remainder = root
+	s.stack = []*level{{obj: root}}
+	return s.advance()
+}
+
+// advance reads object members until the array at the path opens, or the
+// document ends. Members off the path are decoded into the remainder.
+func (s *Streamer) advance() error {
+	for len(s.stack) > 0 {
+		top := s.stack[len(s.stack)-1]
+		if !s.dec.More() {
+			if _, err := s.dec.Token(); err != nil {
+				return err
+			}
+			s.stack = s.stack[:len(s.stack)-1]
+			continue
+		}
+		keyToken, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := keyToken.(string)
+		depth := len(s.stack) - 1
+		if s.state != stateSearching || top.descended || key != s.path[depth] {
+			var v interface{}
+			if err := s.dec.Decode(&v); err != nil {
+				return err
+			}
+			top.obj[key] = v
+			continue
+		}
+		top.descended = true
+		t, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch {
+		case depth == len(s.path)-1 && t == json.Delim('['):
+			s.found = true
+			s.state = stateStreaming
+			return nil
+		case depth < len(s.path)-1 && t == json.Delim('{'):
+			child := make(map[string]interface{})
+			top.obj[key] = child
+			s.stack = append(s.stack, &level{obj: child})
+		default:
+			v, err := s.decodeFrom(t)
+			if err != nil {
+				return err
+			}
+			top.obj[key] = v
+		}
+	}
+	s.state = stateDone
+	return nil
+}
+
+// decodeFrom decodes the rest of a value whose first token has been read.
+func (s *Streamer) decodeFrom(t json.Token) (interface{}, error) {
+	switch t {
+	case json.Delim('{'):
+		rv := make(map[string]interface{})
+		for s.dec.More() {
+			keyToken, err := s.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			var v interface{}
+			if err := s.dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			rv[key] = v
+		}
+		_, err := s.dec.Token()
+		return rv, err
+	case json.Delim('['):
+		rv := []interface{}{}
+		for s.dec.More() {
+			var v interface{}
+			if err := s.dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			rv = append(rv, v)
+		}
+		_, err := s.dec.Token()
+		return rv, err
+	default:
+		return t, nil
+	}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+// LimitReader returns a reader that fails with an error matching
+// ErrResponseTooLarge once more than limit bytes have been read. A limit of
+// zero or less leaves r unlimited.
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, remaining: limit + 1, limit: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, l.limit)
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining <= 0 {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, l.limit)
+	}
+	return n, err
+}
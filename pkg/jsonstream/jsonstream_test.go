@@ -0,0 +1,126 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		path string
+		keys []string
+		ok   bool
+	}{
+		{"", nil, true},
+		{"$", nil, true},
+		{"$[*]", nil, true},
+		{"items", []string{"items"}, true},
+		{"$.items", []string{"items"}, true},
+		{"$.data.items[*]", []string{"data", "items"}, true},
+		{"$['odata.value'].rows", []string{"odata.value", "rows"}, true},
+		{`$["a"]`, []string{"a"}, true},
+		{"$.items[*].name", nil, false},
+		{"$.items[0]", nil, false},
+		{"$..items", nil, false},
+		{"/*", nil, false},
+	}
+	for _, c := range cases {
+		keys, ok := ParsePath(c.path)
+		if ok != c.ok || (ok && !reflect.DeepEqual(keys, c.keys)) {
+			t.Fatalf("%q: got %v %v, want %v %v", c.path, keys, ok, c.keys, c.ok)
+		}
+	}
+}
+
+func drain(t *testing.T, s *Streamer) []interface{} {
+	t.Helper()
+	var rv []interface{}
+	for {
+		row, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			return rv
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rv = append(rv, row)
+	}
+}
+
+func TestStreamer_NestedPathKeepsRemainder(t *testing.T) {
+	body := `{"kind":"list","data":{"total":2,"items":[{"id":1},{"id":2}],"next":"tok"},"items":"decoy","nextPageToken":"abc"}`
+	s, err := NewStreamer(strings.NewReader(body), "$.data.items[*]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := drain(t, s)
+	b, _ := json.Marshal(rows)
+	if string(b) != `[{"id":1},{"id":2}]` || !s.Found() || s.GetCount() != 2 {
+		t.Fatalf("unexpected rows %s", b)
+	}
+	b, _ = json.Marshal(s.Remainder())
+	expected := `{"data":{"next":"tok","total":2},"items":"decoy","kind":"list","nextPageToken":"abc"}`
+	if string(b) != expected {
+		t.Fatalf("unexpected remainder %s", b)
+	}
+}
+
+func TestStreamer_RootArray(t *testing.T) {
+	s, _ := NewStreamer(strings.NewReader(`[1,2,3]`), "$")
+	if rows := drain(t, s); len(rows) != 3 || !s.Found() {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
+
+func TestStreamer_NotAnArrayYieldsWholeDocument(t *testing.T) {
+	for _, c := range []struct{ body, path string }{
+		{`{"items":{"id":1},"x":2}`, "items"},
+		{`{"x":2}`, "$.items"},
+		{`{"id":1}`, "$"},
+	} {
+		s, _ := NewStreamer(strings.NewReader(c.body), c.path)
+		if rows := drain(t, s); len(rows) != 0 || s.Found() {
+			t.Fatalf("%s: unexpected rows %v", c.body, rows)
+		}
+		b, _ := json.Marshal(s.Remainder())
+		var expected interface{}
+		_ = json.Unmarshal([]byte(c.body), &expected)
+		e, _ := json.Marshal(expected)
+		if string(b) != string(e) {
+			t.Fatalf("%s: unexpected remainder %s", c.body, b)
+		}
+	}
+}
+
+func TestStreamer_MalformedDocument(t *testing.T) {
+	s, _ := NewStreamer(strings.NewReader(`{"items":[{"id":1},{"id":`), "$.items")
+	if _, err := s.Next(); err != nil {
+		t.Fatalf("unexpected error on first row: %v", err)
+	}
+	if _, err := s.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("expected a decode error, got %v", err)
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	b, err := io.ReadAll(LimitReader(strings.NewReader("12345"), 5))
+	if err != nil || string(b) != "12345" {
+		t.Fatalf("a body at the limit should be read whole: %q %v", b, err)
+	}
+	_, err = io.ReadAll(LimitReader(strings.NewReader("123456"), 5))
+	if !errors.Is(err, ErrResponseTooLarge) || !strings.Contains(err.Error(), "more than 5 bytes") {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+	s, _ := NewStreamer(LimitReader(strings.NewReader(`{"items":[1,2,3,4,5,6,7,8]}`), 12), "items")
+	var streamErr error
+	for streamErr == nil {
+		_, streamErr = s.Next()
+	}
+	if !errors.Is(streamErr, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge from the streamer, got %v", streamErr)
+	}
+}
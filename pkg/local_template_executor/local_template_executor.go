@@ -2,9 +2,12 @@ package local_template_executor
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os/exec"
 	"text/template"
+
+	"github.com/stackql/any-sdk/pkg/telemetry"
 )
 
 var (
@@ -59,14 +62,14 @@ type localTemplateExecutor struct {
 	stdErrBuffer *bytes.Buffer
 }
 
-func (lt *localTemplateExecutor) Execute(context map[string]any) (ExecutionResponse, error) {
+func (lt *localTemplateExecutor) Execute(templateCtx map[string]any) (ExecutionResponse, error) {
 	// Execute the template file.
 	cmdTpl, cmdTplErr := template.New("letter").Parse(lt.commandName)
 	if cmdTplErr != nil {
 		return nil, cmdTplErr
 	}
 	var cmdBuffer bytes.Buffer
-	err := cmdTpl.Execute(&cmdBuffer, context)
+	err := cmdTpl.Execute(&cmdBuffer, templateCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +81,7 @@ func (lt *localTemplateExecutor) Execute(context map[string]any) (ExecutionRespo
 			return nil, cmdTplErr
 		}
 		cmdBuffer.Reset()
-		err = cmdTpl.Execute(&cmdBuffer, context)
+		err = cmdTpl.Execute(&cmdBuffer, templateCtx)
 		if err != nil {
 			return nil, err
 		}
@@ -88,7 +91,12 @@ func (lt *localTemplateExecutor) Execute(context map[string]any) (ExecutionRespo
 	// cmd.Stdin = lt.stdInStream
 	cmd.Stdout = lt.stdOutBuffer
 	cmd.Stderr = lt.stdErrBuffer
+	_, span := telemetry.StartSpan(context.Background(), telemetry.SpanLocalExec, telemetry.String(telemetry.AttrCommand, cmdString))
 	err = cmd.Run()
+	if cmd.ProcessState != nil {
+		span.SetAttributes(telemetry.Int(telemetry.AttrExitCode, cmd.ProcessState.ExitCode()))
+	}
+	telemetry.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -9,16 +9,43 @@ import (
 )
 
 const (
-	MediaTypeHTML        string = "text/html"
-	MediaTypeJson        string = "application/json"
-	MediaTypeScimJson    string = "application/scim+json"
-	MediaTypeOctetStream string = "application/octet-stream"
-	MediaTypeTextPlain   string = "text/plain"
-	MediaTypeXML         string = "application/xml"
-	MediaTypeTextXML     string = "text/xml"
+	MediaTypeHTML           string = "text/html"
+	MediaTypeJson           string = "application/json"
+	MediaTypeScimJson       string = "application/scim+json"
+	MediaTypeOctetStream    string = "application/octet-stream"
+	MediaTypeTextPlain      string = "text/plain"
+	MediaTypeXML            string = "application/xml"
+	MediaTypeTextXML        string = "text/xml"
+	MediaTypeFormURLEncoded string = "application/x-www-form-urlencoded"
+	MediaTypeMultipartForm  string = "multipart/form-data"
+	MediaTypeMergePatchJson string = "application/merge-patch+json"
+	MediaTypeJsonPatchJson  string = "application/json-patch+json"
+	MediaTypeNDJson         string = "application/x-ndjson"
+	MediaTypeCSV            string = "text/csv"
+	MediaTypeTSV            string = "text/tab-separated-values"
+	MediaTypeYAML           string = "application/yaml"
 )
 
 var (
+	// recordMediaTypes maps line delimited and tabular media types, with
+	// their common synonyms, to the canonical media type. They are checked
+	// before the JSON synonym matcher, which would otherwise claim ndjson.
+	recordMediaTypes = map[string]string{ //nolint:gochecknoglobals // read-only table
+		MediaTypeNDJson:           MediaTypeNDJson,
+		"application/ndjson":      MediaTypeNDJson,
+		"application/jsonl":       MediaTypeNDJson,
+		"application/jsonlines":   MediaTypeNDJson,
+		"application/x-jsonlines": MediaTypeNDJson,
+		"application/json-lines":  MediaTypeNDJson,
+		MediaTypeCSV:              MediaTypeCSV,
+		"application/csv":         MediaTypeCSV,
+		MediaTypeTSV:              MediaTypeTSV,
+		"text/tsv":                MediaTypeTSV,
+		MediaTypeYAML:             MediaTypeYAML,
+		"application/x-yaml":      MediaTypeYAML,
+		"text/yaml":               MediaTypeYAML,
+		"text/x-yaml":             MediaTypeYAML,
+	}
 	synonymJSONRegexp        *regexp.Regexp                  = regexp.MustCompile(`^application/[\S]*json[\S]*$`)
 	synonymXMLRegexp         *regexp.Regexp                  = regexp.MustCompile(`^(?:application|text)/[\S]*xml[\S]*$`)
 	DefaultMediaFuzzyMatcher fuzzymatch.FuzzyMatcher[string] = fuzzymatch.NewRegexpStringMetcher(
@@ -44,6 +71,22 @@ func isJSONSynonym(mediaType string) bool {
 	return synonymJSONRegexp.MatchString(mediaType)
 }
 
+// StripMediaTypeParameters returns mediaType without parameters such as charset.
+func StripMediaTypeParameters(mediaType string) string {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return mediaType
+	}
+	return mt
+}
+
+// GetRecordMediaType returns the canonical line delimited or tabular media
+// type for mediaType, if it is one.
+func GetRecordMediaType(mediaType string) (string, bool) {
+	rv, ok := recordMediaTypes[StripMediaTypeParameters(mediaType)]
+	return rv, ok
+}
+
 func NormaliseMediaType(mediaType string) string {
 	if isJSONSynonym(mediaType) {
 		return MediaTypeJson
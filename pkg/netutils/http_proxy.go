@@ -5,9 +5,11 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"time"
+
+	"github.com/stackql/any-sdk/pkg/cassette"
+	"github.com/stackql/any-sdk/pkg/dryrun"
 )
 
 type HTTPContext interface {
@@ -21,11 +23,55 @@ type HTTPContext interface {
 	GetTLSAllowInsecure() bool
 }
 
+// WrappingRoundTripper is implemented by middleware transports (eg: a
+// response cache) so that TLS and proxy settings are applied to the
+// transport they wrap rather than bypassed.
+type WrappingRoundTripper interface {
+	http.RoundTripper
+	GetTransport() http.RoundTripper
+	WithTransport(next http.RoundTripper) http.RoundTripper
+}
+
+// CachingRoundTripper is implemented by response cache transports, so that
+// a cache configured without a default freshness lifetime takes the one
+// from the runtime context.
+type CachingRoundTripper interface {
+	WrappingRoundTripper
+	WithDefaultTTL(ttl time.Duration) http.RoundTripper
+}
+
+// CacheContext is optionally implemented by an HTTPContext to supply the
+// default freshness lifetime, in seconds, of a response cache in the chain.
+type CacheContext interface {
+	GetCacheTTL() int
+}
+
+// CassetteContext is optionally implemented by an HTTPContext to record
+// HTTP interactions to, or replay them from, a cassette file.
+type CassetteContext interface {
+	GetHTTPCassettePath() string
+	GetHTTPCassetteMode() string
+	GetHTTPCassetteMatchOn() []string
+	GetHTTPCassetteScrub() []string
+}
+
+// DryRunContext is optionally implemented by an HTTPContext to write
+// requests out for review instead of sending them.
+type DryRunContext interface {
+	GetDryRun() bool
+	GetDryRunFormat() string
+	GetDryRunOutput() string
+	GetDryRunShowSecrets() bool
+}
+
 func GetRoundTripper(httpCtx HTTPContext, existingTransport http.RoundTripper) http.RoundTripper {
 	return getRoundTripper(httpCtx, existingTransport)
 }
 
 func getRoundTripper(httpCtx HTTPContext, existingTransport http.RoundTripper) http.RoundTripper {
+	if wrapper, isWrapper := existingTransport.(WrappingRoundTripper); isWrapper {
+		return withCacheTTL(httpCtx, wrapper.WithTransport(getRoundTripper(httpCtx, wrapper.GetTransport())))
+	}
 	var tr *http.Transport
 	var rt http.RoundTripper
 	if existingTransport != nil {
@@ -53,25 +99,14 @@ func getRoundTripper(httpCtx HTTPContext, existingTransport http.RoundTripper) h
 		}
 		tr.TLSClientConfig = config
 	}
-	host := httpCtx.GetHTTPProxyHost()
-	if host != "" {
-		if httpCtx.GetHTTPProxyPort() > 0 {
-			host = fmt.Sprintf("%s:%d", httpCtx.GetHTTPProxyHost(), httpCtx.GetHTTPProxyPort())
-		}
-		var usr *url.Userinfo
-		if httpCtx.GetHTTPProxyUser() != "" {
-			usr = url.UserPassword(httpCtx.GetHTTPProxyUser(), httpCtx.GetHTTPProxyPassword())
-		}
-		proxyURL := &url.URL{
-			Host:   host,
-			Scheme: httpCtx.GetHTTPProxyScheme(),
-			User:   usr,
+	if tr != nil {
+		proxy, err := getProxy(httpCtx)
+		if err != nil {
+			return &failingRoundTripper{prefix: "http proxy", err: err}
 		}
-		if tr != nil {
-			tr.Proxy = http.ProxyURL(proxyURL)
+		if proxy != nil {
+			tr.Proxy = proxy
 		}
-	}
-	if tr != nil {
 		rt = tr
 	}
 	return rt
@@ -88,8 +123,85 @@ func getHTTPClient(httpCtx HTTPContext, existingClient *http.Client) *http.Clien
 	}
 	return &http.Client{
 		Timeout:   time.Second * time.Duration(httpCtx.GetAPIRequestTimeout()),
-		Transport: getRoundTripper(httpCtx, rt),
+		Transport: wrapDryRun(httpCtx, wrapCassette(httpCtx, getRoundTripper(httpCtx, rt))),
+	}
+}
+
+// withCacheTTL hands the runtime context's cache TTL to a response cache.
+func withCacheTTL(httpCtx HTTPContext, rt http.RoundTripper) http.RoundTripper {
+	cache, isCache := rt.(CachingRoundTripper)
+	cacheCtx, isCacheCtx := httpCtx.(CacheContext)
+	if !isCache || !isCacheCtx || cacheCtx.GetCacheTTL() <= 0 {
+		return rt
+	}
+	return cache.WithDefaultTTL(time.Duration(cacheCtx.GetCacheTTL()) * time.Second)
+}
+
+// wrapCassette wraps rt in a cassette transport when httpCtx names a
+// cassette. A cassette that cannot be opened fails every request, rather
+// than silently reaching the live API.
+func wrapCassette(httpCtx HTTPContext, rt http.RoundTripper) http.RoundTripper {
+	cassetteCtx, isCassetteCtx := httpCtx.(CassetteContext)
+	if !isCassetteCtx || cassetteCtx.GetHTTPCassettePath() == "" || hasTransport(rt, cassette.IsTransport) {
+		return rt
+	}
+	c, err := cassette.Open(cassette.Config{
+		Path:    cassetteCtx.GetHTTPCassettePath(),
+		Mode:    cassetteCtx.GetHTTPCassetteMode(),
+		MatchOn: cassetteCtx.GetHTTPCassetteMatchOn(),
+		Scrub:   cassetteCtx.GetHTTPCassetteScrub(),
+	})
+	if err != nil {
+		return &failingRoundTripper{prefix: "http cassette", err: err}
+	}
+	return cassette.NewTransport(rt, c)
+}
+
+// wrapDryRun wraps rt in a dry run transport when httpCtx enables dry
+// run. It sits outside any cassette, so that captured requests are neither
+// recorded nor replayed. Output that cannot be opened fails every request,
+// rather than silently reaching the live API.
+func wrapDryRun(httpCtx HTTPContext, rt http.RoundTripper) http.RoundTripper {
+	dryRunCtx, isDryRunCtx := httpCtx.(DryRunContext)
+	if !isDryRunCtx || !dryRunCtx.GetDryRun() || hasTransport(rt, dryrun.IsTransport) {
+		return rt
+	}
+	rec, err := dryrun.Open(dryrun.Config{
+		Path:        dryRunCtx.GetDryRunOutput(),
+		Format:      dryRunCtx.GetDryRunFormat(),
+		ShowSecrets: dryRunCtx.GetDryRunShowSecrets(),
+	})
+	if err != nil {
+		return &failingRoundTripper{prefix: "dry run", err: err}
+	}
+	return dryrun.NewTransport(rt, rec)
+}
+
+// hasTransport reports whether rt, or any transport it wraps, satisfies is.
+func hasTransport(rt http.RoundTripper, is func(http.RoundTripper) bool) bool {
+	for rt != nil {
+		if is(rt) {
+			return true
+		}
+		wrapper, isWrapper := rt.(WrappingRoundTripper)
+		if !isWrapper {
+			return false
+		}
+		rt = wrapper.GetTransport()
+	}
+	return false
+}
+
+type failingRoundTripper struct {
+	prefix string
+	err    error
+}
+
+func (f *failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
 	}
+	return nil, fmt.Errorf("%s: %w", f.prefix, f.err)
 }
 
 func getCertPool(localCaBundlePath string) (*x509.CertPool, error) {
@@ -0,0 +1,202 @@
+package netutils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyRoutingContext is optionally implemented by an HTTPContext to route
+// requests through different proxies by provider and host.
+type ProxyRoutingContext interface {
+	GetHTTPProxyRules() ([]dto.ProxyRule, error)
+	GetHTTPProxyNoProxy() string
+}
+
+type providerNameKey struct{}
+
+// WithProviderName marks the requests made with ctx as calls to the named
+// provider, for proxy rules that match on provider.
+func WithProviderName(ctx context.Context, providerName string) context.Context {
+	return context.WithValue(ctx, providerNameKey{}, providerName)
+}
+
+// GetProviderName returns the provider named by WithProviderName, if any.
+func GetProviderName(ctx context.Context) string {
+	providerName, _ := ctx.Value(providerNameKey{}).(string)
+	return providerName
+}
+
+type proxyFunc func(*url.URL) (*url.URL, error)
+
+type proxyRoute struct {
+	providers map[string]struct{}
+	hosts     []string
+	// proxy is nil for a direct route.
+	proxy proxyFunc
+}
+
+// matches reports whether the route applies to a request for host made on
+// behalf of providerName. Each criterion the route names must match.
+func (r proxyRoute) matches(providerName, host string) bool {
+	if len(r.providers) > 0 {
+		if _, ok := r.providers[providerName]; !ok {
+			return false
+		}
+	}
+	if len(r.hosts) == 0 {
+		return true
+	}
+	for _, pattern := range r.hosts {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+type proxyRouter struct {
+	routes   []proxyRoute
+	fallback proxyFunc
+}
+
+func (pr *proxyRouter) proxy(req *http.Request) (*url.URL, error) {
+	providerName := GetProviderName(req.Context())
+	host := strings.ToLower(req.URL.Hostname())
+	for _, r := range pr.routes {
+		if !r.matches(providerName, host) {
+			continue
+		}
+		if r.proxy == nil {
+			return nil, nil
+		}
+		return r.proxy(req.URL)
+	}
+	if pr.fallback == nil {
+		return nil, nil
+	}
+	return pr.fallback(req.URL)
+}
+
+// getProxy returns the transport proxy function for httpCtx, or nil when
+// no proxy is configured. The first proxy rule matching a request wins;
+// requests matching no rule use the single proxy host, if any.
+func getProxy(httpCtx HTTPContext) (func(*http.Request) (*url.URL, error), error) {
+	defaultProxy := getDefaultProxyURL(httpCtx)
+	var rules []dto.ProxyRule
+	var noProxy string
+	if routingCtx, isRoutingCtx := httpCtx.(ProxyRoutingContext); isRoutingCtx {
+		var err error
+		rules, err = routingCtx.GetHTTPProxyRules()
+		if err != nil {
+			return nil, err
+		}
+		noProxy = routingCtx.GetHTTPProxyNoProxy()
+	}
+	if len(rules) == 0 && noProxy == "" {
+		if defaultProxy == nil {
+			return nil, nil
+		}
+		return http.ProxyURL(defaultProxy), nil
+	}
+	router := &proxyRouter{}
+	if defaultProxy != nil {
+		router.fallback = newProxyFunc(defaultProxy, noProxy)
+	}
+	for i, rule := range rules {
+		route, err := newProxyRoute(rule, noProxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy rule %d: %w", i, err)
+		}
+		router.routes = append(router.routes, route)
+	}
+	return router.proxy, nil
+}
+
+func getDefaultProxyURL(httpCtx HTTPContext) *url.URL {
+	host := httpCtx.GetHTTPProxyHost()
+	if host == "" {
+		return nil
+	}
+	if httpCtx.GetHTTPProxyPort() > 0 {
+		host = fmt.Sprintf("%s:%d", httpCtx.GetHTTPProxyHost(), httpCtx.GetHTTPProxyPort())
+	}
+	scheme := httpCtx.GetHTTPProxyScheme()
+	if scheme == "" {
+		scheme = "http"
+	}
+	var usr *url.Userinfo
+	if httpCtx.GetHTTPProxyUser() != "" {
+		usr = url.UserPassword(httpCtx.GetHTTPProxyUser(), httpCtx.GetHTTPProxyPassword())
+	}
+	return &url.URL{
+		Host:   host,
+		Scheme: scheme,
+		User:   usr,
+	}
+}
+
+func newProxyRoute(rule dto.ProxyRule, noProxy string) (proxyRoute, error) {
+	route := proxyRoute{}
+	if len(rule.Providers) > 0 {
+		route.providers = make(map[string]struct{}, len(rule.Providers))
+		for _, p := range rule.Providers {
+			route.providers[p] = struct{}{}
+		}
+	}
+	for _, h := range rule.Hosts {
+		pattern := strings.ToLower(h)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return route, fmt.Errorf("invalid host pattern '%s': %w", h, err)
+		}
+		route.hosts = append(route.hosts, pattern)
+	}
+	if rule.Proxy == dto.ProxyDirect {
+		return route, nil
+	}
+	proxyURL, err := url.Parse(rule.Proxy)
+	if err != nil {
+		return route, err
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return route, fmt.Errorf("unsupported proxy '%s', expected an http, https, socks5 or socks5h URL or '%s'", rule.Proxy, dto.ProxyDirect)
+	}
+	if rule.Auth != nil {
+		user, password, credErr := rule.Auth.GetProxyCredentials()
+		if credErr != nil {
+			return route, credErr
+		}
+		proxyURL.User = url.UserPassword(user, password)
+	}
+	route.proxy = newProxyFunc(proxyURL, joinNoProxy(noProxy, rule.NoProxy))
+	return route, nil
+}
+
+// newProxyFunc sends requests through proxyURL, except those for hosts in
+// noProxy, which has NO_PROXY semantics.
+func newProxyFunc(proxyURL *url.URL, noProxy string) proxyFunc {
+	cfg := &httpproxy.Config{
+		HTTPProxy:  proxyURL.String(),
+		HTTPSProxy: proxyURL.String(),
+		NoProxy:    noProxy,
+	}
+	return cfg.ProxyFunc()
+}
+
+func joinNoProxy(lists ...string) string {
+	var rv []string
+	for _, l := range lists {
+		if l = strings.TrimSpace(l); l != "" {
+			rv = append(rv, l)
+		}
+	}
+	return strings.Join(rv, ",")
+}
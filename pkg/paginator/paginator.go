@@ -0,0 +1,626 @@
+package paginator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+)
+
+const (
+	// StyleToken reads a continuation token from the response and sends it
+	// back in the next request.
+	StyleToken = "token"
+	// StyleCursor is StyleToken with an optional has-more flag.
+	StyleCursor = "cursor"
+	// StyleLink follows the rel="next" target of the RFC 8288 Link header,
+	// or of the header named by ResponseToken.
+	StyleLink = "link"
+	// StyleNextURL follows a next page URL found in the response, such as
+	// OData's @odata.nextLink.
+	StyleNextURL = "next_url"
+	// StyleOffset advances an offset query parameter by the page size.
+	StyleOffset = "offset"
+	// StylePageNumber advances a page number query parameter.
+	StylePageNumber = "page_number"
+
+	LocationHeader = "header"
+	LocationBody   = "body"
+	LocationQuery  = "query"
+)
+
+var (
+	// ErrDone is returned by Next once there are no more pages.
+	ErrDone = errors.New("no more pages")
+	// ErrRepeatedToken is matched by errors for a continuation token or
+	// next URL that was already followed, which would otherwise loop forever.
+	ErrRepeatedToken = errors.New("pagination token repeated")
+)
+
+// Config describes how pages are linked. Body paths are JSON paths; a bare
+// key such as "nextPageToken" is read as "$.nextPageToken".
+type Config struct {
+	Style string
+
+	// ResponseTokenLocation (header or body) and ResponseToken locate the
+	// continuation token, for StyleToken and StyleCursor, or the next URL,
+	// for StyleNextURL. For StyleLink, a header ResponseToken names the
+	// header holding the links instead of Link.
+	ResponseTokenLocation string
+	ResponseToken         string
+	// RequestTokenLocation (query, header or body) and RequestToken name
+	// where the token is sent.
+	RequestTokenLocation string
+	RequestToken         string
+	// HasMorePath, for StyleCursor, locates a boolean; false ends paging.
+	HasMorePath string
+
+	// OffsetParam and LimitParam, for StyleOffset, name the query
+	// parameters. PageSize is sent as the limit; a shorter page ends paging.
+	OffsetParam string
+	LimitParam  string
+	PageSize    int
+	StartOffset int
+	// TotalPath, for StyleOffset, locates the total row count.
+	TotalPath string
+
+	// PageParam, for StylePageNumber, names the page query parameter,
+	// starting at StartPage (default 1). CurrentPagePath and TotalPagesPath
+	// locate the current page and page count in the response; paging ends
+	// when either is missing or not a number.
+	PageParam       string
+	StartPage       int
+	CurrentPagePath string
+	TotalPagesPath  string
+
+	// ItemsPath locates the rows of a page. When empty, an array body is
+	// the rows and any other body is a single row.
+	ItemsPath string
+	// MaxPages and MaxRows stop paging early; zero means unlimited. Rows
+	// beyond MaxRows are dropped from the last page.
+	MaxPages int
+	MaxRows  int
+}
+
+func (c Config) getStartPage() int {
+	if c.StartPage <= 0 {
+		return 1
+	}
+	return c.StartPage
+}
+
+// Page is one response. Its body has been read into Body.
+type Page struct {
+	Number   int
+	Request  *http.Request
+	Response *http.Response
+	Body     []byte
+	Rows     []interface{}
+}
+
+// Doer sends a request. *http.Client.Do satisfies it, as does a wrapper
+// around formulation.CallFromSignature that applies authentication.
+type Doer func(req *http.Request) (*http.Response, error)
+
+// Paginator yields the pages of a listing, starting with a prepared request.
+type Paginator interface {
+	// Next returns the next page, or ErrDone.
+	Next() (*Page, error)
+	// Pages ranges over the remaining pages; an error ends the sequence.
+	Pages() iter.Seq2[*Page, error]
+	// Rows ranges over the rows of the remaining pages.
+	Rows() iter.Seq2[interface{}, error]
+	GetPageCount() int
+	GetRowCount() int
+}
+
+// Cursor follows a listing whose pages the caller fetches and decodes
+// itself, such as the invoker, which reads XML and streamed responses its
+// own way. Advance returns what locates the page after resp, by Style: the
+// token to send, the offset or page number, or the resolved URL to
+// request. The bool is false after the last page. doc is the decoded page
+// and rowCount the number of rows it held.
+type Cursor interface {
+	Advance(req *http.Request, resp *http.Response, doc interface{}, rowCount int) (string, bool, error)
+}
+
+// Resolver serves body paths from a decoded page that is not plain JSON.
+// A doc passed to a Cursor may implement it.
+type Resolver interface {
+	Lookup(path string) (interface{}, bool)
+}
+
+// NewCursor returns a cursor for cfg, with the same safeguards as a
+// Paginator. MaxPages and MaxRows are left to the caller.
+func NewCursor(cfg Config) Cursor {
+	return &standardPaginator{
+		cfg:     cfg,
+		seen:    make(map[string]struct{}),
+		offset:  cfg.StartOffset,
+		pageNum: cfg.getStartPage(),
+	}
+}
+
+type standardPaginator struct {
+	cfg      Config
+	doer     Doer
+	next     *http.Request
+	body     []byte
+	pages    int
+	rows     int
+	offset   int
+	pageNum  int
+	done     bool
+	seen     map[string]struct{}
+	firstErr error
+}
+
+// New returns a paginator starting from req. A request body is buffered so
+// that token-in-body paging can rewrite it for every page.
+func New(req *http.Request, doer Doer, cfg Config) Paginator {
+	if doer == nil {
+		doer = http.DefaultClient.Do
+	}
+	rv := &standardPaginator{
+		cfg:     cfg,
+		doer:    doer,
+		seen:    make(map[string]struct{}),
+		offset:  cfg.StartOffset,
+		pageNum: cfg.getStartPage(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			rv.firstErr = err
+		}
+		rv.body = b
+	}
+	first, err := rv.prepare(req, "")
+	if err != nil && rv.firstErr == nil {
+		rv.firstErr = err
+	}
+	rv.next = first
+	return rv
+}
+
+func (p *standardPaginator) GetPageCount() int {
+	return p.pages
+}
+
+func (p *standardPaginator) GetRowCount() int {
+	return p.rows
+}
+
+func (p *standardPaginator) Pages() iter.Seq2[*Page, error] {
+	return func(yield func(*Page, error) bool) {
+		for {
+			pg, err := p.Next()
+			if errors.Is(err, ErrDone) {
+				return
+			}
+			if !yield(pg, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (p *standardPaginator) Rows() iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		for pg, err := range p.Pages() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, row := range pg.Rows {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *standardPaginator) Next() (*Page, error) {
+	if p.firstErr != nil {
+		err := p.firstErr
+		p.firstErr = nil
+		p.done = true
+		return nil, err
+	}
+	if p.done || p.next == nil {
+		return nil, ErrDone
+	}
+	req := p.next
+	p.next = nil
+	resp, err := p.doer(req)
+	if err != nil {
+		p.done = true
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		p.done = true
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	p.pages++
+	pg := &Page{Number: p.pages, Request: req, Response: resp, Body: body}
+	if resp.StatusCode >= http.StatusBadRequest {
+		p.done = true
+		return pg, fmt.Errorf("page %d: http error: %s", p.pages, resp.Status)
+	}
+	var doc interface{}
+	if len(body) > 0 {
+		_ = json.Unmarshal(body, &doc)
+	}
+	pg.Rows = p.rowsOf(doc)
+	if p.cfg.MaxRows > 0 && p.rows+len(pg.Rows) >= p.cfg.MaxRows {
+		pg.Rows = pg.Rows[:p.cfg.MaxRows-p.rows]
+		p.rows += len(pg.Rows)
+		p.done = true
+		return pg, nil
+	}
+	p.rows += len(pg.Rows)
+	if p.cfg.MaxPages > 0 && p.pages >= p.cfg.MaxPages {
+		p.done = true
+		return pg, nil
+	}
+	next, err := p.nextRequest(req, resp, doc, len(pg.Rows))
+	if err != nil {
+		p.done = true
+		return pg, err
+	}
+	if next == nil {
+		p.done = true
+	}
+	p.next = next
+	return pg, nil
+}
+
+func (p *standardPaginator) rowsOf(doc interface{}) []interface{} {
+	target := doc
+	if p.cfg.ItemsPath != "" {
+		v, ok := lookup(doc, p.cfg.ItemsPath)
+		if !ok {
+			return nil
+		}
+		target = v
+	}
+	switch t := target.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return t
+	default:
+		return []interface{}{t}
+	}
+}
+
+// nextRequest computes the request for the following page, or nil at the
+// end.
+func (p *standardPaginator) nextRequest(req *http.Request, resp *http.Response, doc interface{}, rowCount int) (*http.Request, error) {
+	next, hasNext, err := p.Advance(req, resp, doc, rowCount)
+	if err != nil || !hasNext {
+		return nil, err
+	}
+	switch strings.ToLower(p.cfg.Style) {
+	case StyleLink, StyleNextURL:
+		return p.follow(req, next)
+	case StyleOffset, StylePageNumber:
+		return p.prepare(req, "")
+	default:
+		return p.prepare(req, next)
+	}
+}
+
+// Advance implements Cursor. It advances the offset or page number, and
+// records tokens and URLs so that a repeat is an error.
+func (p *standardPaginator) Advance(req *http.Request, resp *http.Response, doc interface{}, rowCount int) (string, bool, error) {
+	switch strings.ToLower(p.cfg.Style) {
+	case StyleLink:
+		header := "Link"
+		if strings.ToLower(p.cfg.ResponseTokenLocation) == LocationHeader && p.cfg.ResponseToken != "" {
+			header = p.cfg.ResponseToken
+		}
+		target, ok := nextLink(resp.Header.Values(header))
+		if !ok {
+			return "", false, nil
+		}
+		return p.resolve(req, target)
+	case StyleNextURL:
+		target := p.responseToken(resp, doc)
+		if target == "" {
+			return "", false, nil
+		}
+		return p.resolve(req, target)
+	case StyleOffset:
+		if rowCount == 0 || (p.cfg.PageSize > 0 && rowCount < p.cfg.PageSize) {
+			return "", false, nil
+		}
+		p.offset += rowCount
+		if total, ok := lookupInt(doc, p.cfg.TotalPath); ok && p.offset >= total {
+			return "", false, nil
+		}
+		return strconv.Itoa(p.offset), true, nil
+	case StylePageNumber:
+		current, hasCurrent := lookupInt(doc, p.cfg.CurrentPagePath)
+		total, hasTotal := lookupInt(doc, p.cfg.TotalPagesPath)
+		if !hasCurrent || !hasTotal || current >= total {
+			return "", false, nil
+		}
+		if current+1 <= p.pageNum {
+			return "", false, fmt.Errorf("%w: page %d", ErrRepeatedToken, current+1)
+		}
+		p.pageNum = current + 1
+		return strconv.Itoa(p.pageNum), true, nil
+	default:
+		if p.cfg.HasMorePath != "" {
+			if v, ok := lookup(doc, p.cfg.HasMorePath); ok && fmt.Sprintf("%v", v) == "false" {
+				return "", false, nil
+			}
+		}
+		token := p.responseToken(resp, doc)
+		if token == "" {
+			return "", false, nil
+		}
+		if err := p.markSeen(token); err != nil {
+			return "", false, err
+		}
+		return token, true, nil
+	}
+}
+
+// resolve resolves a next page target against the request URL.
+func (p *standardPaginator) resolve(req *http.Request, target string) (string, bool, error) {
+	ref, err := url.Parse(target)
+	if err != nil {
+		return "", false, err
+	}
+	resolved := req.URL.ResolveReference(ref).String()
+	if err = p.markSeen(resolved); err != nil {
+		return "", false, err
+	}
+	return resolved, true, nil
+}
+
+func (p *standardPaginator) markSeen(token string) error {
+	if _, repeated := p.seen[token]; repeated {
+		return fmt.Errorf("%w: '%s'", ErrRepeatedToken, token)
+	}
+	p.seen[token] = struct{}{}
+	return nil
+}
+
+func (p *standardPaginator) follow(req *http.Request, target string) (*http.Request, error) {
+	resolved, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	rv := p.clone(req, p.body)
+	rv.URL = resolved
+	rv.Host = ""
+	return rv, nil
+}
+
+func (p *standardPaginator) responseToken(resp *http.Response, doc interface{}) string {
+	if strings.ToLower(p.cfg.ResponseTokenLocation) == LocationHeader {
+		return resp.Header.Get(p.cfg.ResponseToken)
+	}
+	v, ok := lookup(doc, p.cfg.ResponseToken)
+	if !ok {
+		return ""
+	}
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		if len(t) == 1 {
+			return fmt.Sprintf("%v", t[0])
+		}
+		return ""
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// prepare builds the request for the current position: the token for token
+// styles, or the offset or page number.
+func (p *standardPaginator) prepare(req *http.Request, token string) (*http.Request, error) {
+	body := p.body
+	if token != "" && strings.ToLower(p.cfg.RequestTokenLocation) == LocationBody {
+		var err error
+		body, err = setBodyToken(p.body, p.cfg.RequestToken, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rv := p.clone(req, body)
+	q := rv.URL.Query()
+	switch strings.ToLower(p.cfg.Style) {
+	case StyleOffset:
+		q.Set(p.cfg.OffsetParam, strconv.Itoa(p.offset))
+		if p.cfg.LimitParam != "" && p.cfg.PageSize > 0 {
+			q.Set(p.cfg.LimitParam, strconv.Itoa(p.cfg.PageSize))
+		}
+		rv.URL.RawQuery = q.Encode()
+	case StylePageNumber:
+		q.Set(p.cfg.PageParam, strconv.Itoa(p.pageNum))
+		rv.URL.RawQuery = q.Encode()
+	case StyleToken, StyleCursor, "":
+		if token == "" {
+			break
+		}
+		switch strings.ToLower(p.cfg.RequestTokenLocation) {
+		case LocationHeader:
+			rv.Header.Set(p.cfg.RequestToken, token)
+		case LocationBody:
+		default:
+			q.Set(p.cfg.RequestToken, token)
+			rv.URL.RawQuery = q.Encode()
+		}
+	}
+	return rv, nil
+}
+
+func (p *standardPaginator) clone(req *http.Request, body []byte) *http.Request {
+	rv := req.Clone(req.Context())
+	if body == nil {
+		return rv
+	}
+	rv.Body = io.NopCloser(bytes.NewReader(body))
+	rv.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	rv.ContentLength = int64(len(body))
+	return rv
+}
+
+// setBodyToken sets a dotted key (e.g. "paging.cursor") in a JSON object body.
+func setBodyToken(body []byte, key string, token string) ([]byte, error) {
+	doc := make(map[string]interface{})
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("cannot set pagination token in non object body: %w", err)
+		}
+	}
+	parts := strings.Split(strings.TrimPrefix(key, "$."), ".")
+	m := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = token
+	return json.Marshal(doc)
+}
+
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	if doc == nil || path == "" {
+		return nil, false
+	}
+	if r, ok := doc.(Resolver); ok {
+		v, found := r.Lookup(path)
+		return v, found && v != nil
+	}
+	if !strings.HasPrefix(path, "$") {
+		path = "$." + path
+	}
+	v, err := jsonpath.Get(path, doc)
+	if err != nil || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func lookupInt(doc interface{}, path string) (int, bool) {
+	v, ok := lookup(doc, path)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(fmt.Sprintf("%v", v)))
+	if err != nil {
+		f, fErr := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+		if fErr != nil {
+			return 0, false
+		}
+		return int(f), true
+	}
+	return i, true
+}
+
+// nextLink returns the target of the first Link header entry whose rel
+// includes "next" (RFC 8288).
+func nextLink(values []string) (string, bool) {
+	for _, l := range ParseLinkHeader(values) {
+		for _, rel := range l.Rel {
+			if strings.EqualFold(rel, "next") {
+				return l.Target, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Link is one link-value of an RFC 8288 Link header.
+type Link struct {
+	Target string
+	Rel    []string
+	Params map[string]string
+}
+
+// ParseLinkHeader parses Link header values. Commas inside the target or
+// inside quoted parameter values do not split links.
+func ParseLinkHeader(values []string) []Link {
+	var rv []Link
+	for _, v := range values {
+		s := v
+		for {
+			s = strings.TrimLeft(s, " \t,")
+			if !strings.HasPrefix(s, "<") {
+				break
+			}
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				break
+			}
+			l := Link{Target: s[1:end], Params: make(map[string]string)}
+			s = s[end+1:]
+			for {
+				s = strings.TrimLeft(s, " \t")
+				if !strings.HasPrefix(s, ";") {
+					break
+				}
+				s = strings.TrimLeft(s[1:], " \t")
+				nameEnd := strings.IndexAny(s, "=;,")
+				if nameEnd < 0 {
+					l.Params[strings.ToLower(strings.TrimSpace(s))] = ""
+					s = ""
+					break
+				}
+				name := strings.ToLower(strings.TrimSpace(s[:nameEnd]))
+				if s[nameEnd] != '=' {
+					l.Params[name] = ""
+					s = s[nameEnd:]
+					continue
+				}
+				s = strings.TrimLeft(s[nameEnd+1:], " \t")
+				var value string
+				if strings.HasPrefix(s, `"`) {
+					closing := strings.IndexByte(s[1:], '"')
+					if closing < 0 {
+						value, s = s[1:], ""
+					} else {
+						value, s = s[1:closing+1], s[closing+2:]
+					}
+				} else {
+					valueEnd := strings.IndexAny(s, ";,")
+					if valueEnd < 0 {
+						value, s = s, ""
+					} else {
+						value, s = s[:valueEnd], s[valueEnd:]
+					}
+				}
+				l.Params[name] = strings.TrimSpace(value)
+			}
+			if rel, ok := l.Params["rel"]; ok {
+				l.Rel = strings.Fields(rel)
+			}
+			rv = append(rv, l)
+		}
+	}
+	return rv
+}
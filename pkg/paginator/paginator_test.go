@@ -0,0 +1,212 @@
+package paginator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *int64) {
+	t.Helper()
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newRequest(t *testing.T, method string, url string, body io.Reader) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return req
+}
+
+func collectRows(t *testing.T, p Paginator) []interface{} {
+	t.Helper()
+	var rv []interface{}
+	for row, err := range p.Rows() {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rv = append(rv, row)
+	}
+	return rv
+}
+
+func TestPaginator_TokenInQuery(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			_, _ = io.WriteString(w, `{"items":[1,2],"meta":{"next":"b"}}`)
+		case "b":
+			_, _ = io.WriteString(w, `{"items":[3],"meta":{"next":""}}`)
+		}
+	})
+	p := New(newRequest(t, http.MethodGet, srv.URL, nil), nil, Config{
+		Style:                 StyleToken,
+		ResponseTokenLocation: LocationBody,
+		ResponseToken:         "meta.next",
+		RequestTokenLocation:  LocationQuery,
+		RequestToken:          "pageToken",
+		ItemsPath:             "items",
+	})
+	if rows := collectRows(t, p); len(rows) != 3 || p.GetPageCount() != 2 {
+		t.Fatalf("expected 3 rows over 2 pages, got %v over %d", rows, p.GetPageCount())
+	}
+}
+
+func TestPaginator_RepeatedTokenStops(t *testing.T) {
+	srv, calls := newServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Next", "same")
+		_, _ = io.WriteString(w, `[1]`)
+	})
+	p := New(newRequest(t, http.MethodGet, srv.URL, nil), nil, Config{
+		Style:                 StyleToken,
+		ResponseTokenLocation: LocationHeader,
+		ResponseToken:         "X-Next",
+		RequestTokenLocation:  LocationHeader,
+		RequestToken:          "X-Continuation",
+	})
+	var err error
+	for _, err = range p.Pages() {
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrRepeatedToken) || atomic.LoadInt64(calls) != 2 {
+		t.Fatalf("expected a repeated token error after 2 calls, got %v after %d", err, atomic.LoadInt64(calls))
+	}
+}
+
+func TestPaginator_LinkHeader(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 3 {
+			w.Header().Add("Link", fmt.Sprintf(`</items?page=%d&a=1,2>; rel="next last", </items?page=1>; rel=first`, page+1))
+		}
+		_, _ = io.WriteString(w, `[{"page":`+strconv.Itoa(page)+`}]`)
+	})
+	p := New(newRequest(t, http.MethodGet, srv.URL+"/items?page=1", nil), nil, Config{Style: StyleLink})
+	if rows := collectRows(t, p); len(rows) != 3 {
+		t.Fatalf("expected 3 pages of rows, got %v", rows)
+	}
+}
+
+func TestPaginator_OffsetAndMaxRows(t *testing.T) {
+	srv, calls := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if r.URL.Query().Get("limit") != "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"total":100,"data":[%d,%d]}`, offset, offset+1)
+	})
+	p := New(newRequest(t, http.MethodGet, srv.URL, nil), nil, Config{
+		Style:       StyleOffset,
+		OffsetParam: "offset",
+		LimitParam:  "limit",
+		PageSize:    2,
+		TotalPath:   "total",
+		ItemsPath:   "data",
+		MaxRows:     5,
+	})
+	rows := collectRows(t, p)
+	if len(rows) != 5 || rows[4] != float64(4) {
+		t.Fatalf("expected rows 0..4, got %v", rows)
+	}
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Fatalf("expected paging to stop once the row limit was met, got %d calls", got)
+	}
+}
+
+func TestPaginator_PageNumber(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"result":[0],"result_info":{"page":%s,"total_pages":3}}`, r.URL.Query().Get("page"))
+	})
+	p := New(newRequest(t, http.MethodGet, srv.URL, nil), nil, Config{
+		Style:           StylePageNumber,
+		PageParam:       "page",
+		CurrentPagePath: "result_info.page",
+		TotalPagesPath:  "result_info.total_pages",
+		ItemsPath:       "result",
+	})
+	if rows := collectRows(t, p); len(rows) != 3 {
+		t.Fatalf("expected 3 pages, got %d rows", len(rows))
+	}
+}
+
+func TestPaginator_CursorInBody(t *testing.T) {
+	srv, _ := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if string(b) == `{"filter":"x"}` {
+			_, _ = io.WriteString(w, `{"rows":[1],"cursor":"c1","has_more":true}`)
+			return
+		}
+		if string(b) == `{"filter":"x","paging":{"cursor":"c1"}}` {
+			_, _ = io.WriteString(w, `{"rows":[2],"cursor":"c2","has_more":false}`)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	p := New(newRequest(t, http.MethodPost, srv.URL, strings.NewReader(`{"filter":"x"}`)), nil, Config{
+		Style:                 StyleCursor,
+		ResponseTokenLocation: LocationBody,
+		ResponseToken:         "cursor",
+		RequestTokenLocation:  LocationBody,
+		RequestToken:          "paging.cursor",
+		HasMorePath:           "has_more",
+		ItemsPath:             "rows",
+	})
+	if rows := collectRows(t, p); len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", rows)
+	}
+}
+
+type mapResolver map[string]interface{}
+
+func (m mapResolver) Lookup(path string) (interface{}, bool) {
+	v, ok := m[path]
+	return v, ok
+}
+
+func TestCursor_ResolverAndNamedLinkHeader(t *testing.T) {
+	req := newRequest(t, http.MethodGet, "https://example.com/v1/items", nil)
+	resp := &http.Response{Header: http.Header{}}
+
+	c := NewCursor(Config{Style: StyleToken, ResponseTokenLocation: LocationBody, ResponseToken: "$.NextToken"})
+	next, hasNext, err := c.Advance(req, resp, mapResolver{"$.NextToken": "t2"}, 1)
+	if err != nil || !hasNext || next != "t2" {
+		t.Fatalf("expected token t2, got %q %v %v", next, hasNext, err)
+	}
+	if _, _, err = c.Advance(req, resp, mapResolver{"$.NextToken": "t2"}, 1); !errors.Is(err, ErrRepeatedToken) {
+		t.Fatalf("expected a repeated token error, got %v", err)
+	}
+
+	resp.Header.Set("X-Links", `</v1/items?page=2>; rel="next"`)
+	c = NewCursor(Config{Style: StyleLink, ResponseTokenLocation: LocationHeader, ResponseToken: "X-Links"})
+	if next, hasNext, err = c.Advance(req, resp, nil, 1); err != nil || !hasNext || next != "https://example.com/v1/items?page=2" {
+		t.Fatalf("expected the resolved next link, got %q %v %v", next, hasNext, err)
+	}
+	resp.Header.Del("X-Links")
+	if _, hasNext, _ = c.Advance(req, resp, nil, 1); hasNext {
+		t.Fatalf("expected the last page")
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := ParseLinkHeader([]string{`<https://x/a?b=1,2>; rel="next"; title="a, b", <https://x/c>; rel=prev`})
+	if len(links) != 2 || links[0].Target != "https://x/a?b=1,2" || links[0].Params["title"] != "a, b" || links[1].Rel[0] != "prev" {
+		t.Fatalf("unexpected links %+v", links)
+	}
+}
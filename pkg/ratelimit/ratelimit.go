@@ -0,0 +1,297 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ResetFormatAuto         = "auto"
+	ResetFormatEpochSeconds = "epoch_seconds"
+	ResetFormatDeltaSeconds = "delta_seconds"
+
+	DefaultRemainingHeader = "X-RateLimit-Remaining"
+	DefaultResetHeader     = "X-RateLimit-Reset"
+
+	// epochThreshold separates "seconds since epoch" from "seconds from now"
+	// when the reset format is auto-detected; no sane reset window is
+	// anywhere near 30 years long.
+	epochThreshold = 1e9
+)
+
+var (
+	_ Limiter  = &standardLimiter{}
+	_ Registry = &standardRegistry{}
+
+	defaultRegistry = NewRegistry() //nolint:gochecknoglobals // process-wide budget sharing is the point
+)
+
+// Config describes a single limiter. The zero value imposes no limits.
+type Config struct {
+	// RequestsPerSecond is the steady token refill rate; <= 0 disables the bucket.
+	RequestsPerSecond float64
+	// Burst is the bucket capacity; <= 0 defaults to ceil(RequestsPerSecond).
+	Burst int
+	// MaxConcurrency bounds in-flight requests; <= 0 is unbounded.
+	MaxConcurrency int
+	// IsAdaptive enables pausing on exhausted server-reported budgets.
+	IsAdaptive      bool
+	RemainingHeader string
+	ResetHeader     string
+	ResetFormat     string
+}
+
+func (c Config) getBurst() float64 {
+	if c.Burst > 0 {
+		return float64(c.Burst)
+	}
+	return math.Max(1, math.Ceil(c.RequestsPerSecond))
+}
+
+func (c Config) getRemainingHeader() string {
+	if c.RemainingHeader == "" {
+		return DefaultRemainingHeader
+	}
+	return c.RemainingHeader
+}
+
+func (c Config) getResetHeader() string {
+	if c.ResetHeader == "" {
+		return DefaultResetHeader
+	}
+	return c.ResetHeader
+}
+
+// Limiter gates outbound requests for one budget (typically a provider/host pair).
+//
+// Acquire blocks until a request may be sent, honouring ctx cancellation.
+// The returned release func must be called once the response headers are in
+// hand, to free the concurrency slot. Observe feeds response headers back so
+// adaptive limiters can pause when the server reports an exhausted budget.
+type Limiter interface {
+	Acquire(ctx context.Context) (func(), error)
+	Observe(header http.Header)
+	GetConfig() Config
+}
+
+// Registry shares limiters across every caller in the process.
+type Registry interface {
+	GetLimiter(key string, cfg Config) Limiter
+}
+
+type standardRegistry struct {
+	mutex    sync.Mutex
+	limiters map[string]*standardLimiter
+}
+
+func NewRegistry() Registry {
+	return &standardRegistry{
+		limiters: make(map[string]*standardLimiter),
+	}
+}
+
+// GetDefaultRegistry returns the process-wide registry.
+func GetDefaultRegistry() Registry {
+	return defaultRegistry
+}
+
+// GetLimiter returns the limiter for key, creating it on first use. A
+// changed config (eg: a registry reload) is applied to the live limiter in
+// place, so its bucket and held concurrency slots carry over.
+func (r *standardRegistry) GetLimiter(key string, cfg Config) Limiter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if existing, ok := r.limiters[key]; ok {
+		existing.reconfigure(cfg)
+		return existing
+	}
+	rv := newLimiter(cfg, time.Now)
+	r.limiters[key] = rv
+	return rv
+}
+
+type standardLimiter struct {
+	cfg          Config
+	mutex        sync.Mutex
+	tokens       float64
+	lastRefill   time.Time
+	blockedUntil time.Time
+	slots        chan struct{}
+	now          func() time.Time
+}
+
+func NewLimiter(cfg Config) Limiter {
+	return newLimiter(cfg, time.Now)
+}
+
+func newLimiter(cfg Config, now func() time.Time) *standardLimiter {
+	rv := &standardLimiter{
+		cfg:        cfg,
+		tokens:     cfg.getBurst(),
+		lastRefill: now(),
+		now:        now,
+	}
+	if cfg.MaxConcurrency > 0 {
+		rv.slots = make(chan struct{}, cfg.MaxConcurrency)
+	}
+	return rv
+}
+
+func (l *standardLimiter) GetConfig() Config {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.cfg
+}
+
+// reconfigure applies cfg in place. The bucket keeps its level, capped at
+// the new burst, and requests in flight keep their concurrency slots, up to
+// the new bound.
+func (l *standardLimiter) reconfigure(cfg Config) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if cfg == l.cfg {
+		return
+	}
+	if cfg.MaxConcurrency != l.cfg.MaxConcurrency {
+		held := len(l.slots)
+		l.slots = nil
+		if cfg.MaxConcurrency > 0 {
+			l.slots = make(chan struct{}, cfg.MaxConcurrency)
+			for i := 0; i < held && i < cfg.MaxConcurrency; i++ {
+				l.slots <- struct{}{}
+			}
+		}
+	}
+	l.cfg = cfg
+	l.tokens = math.Min(l.tokens, cfg.getBurst())
+}
+
+func (l *standardLimiter) getSlots() chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.slots
+}
+
+func (l *standardLimiter) Acquire(ctx context.Context) (func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if slots := l.getSlots(); slots != nil {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := l.releaseFunc()
+	for {
+		wait := l.reserve()
+		if wait <= 0 {
+			return release, nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// releaseFunc frees a slot of the current bound, which may have changed
+// since the slot was taken.
+func (l *standardLimiter) releaseFunc() func() {
+	if l.getSlots() == nil {
+		return func() {}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			select {
+			case <-l.getSlots():
+			default:
+			}
+		})
+	}
+}
+
+// reserve takes a token if one is available and returns zero; otherwise it
+// returns how long the caller should wait before trying again.
+func (l *standardLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	if now.Before(l.blockedUntil) {
+		return l.blockedUntil.Sub(now)
+	}
+	if l.cfg.RequestsPerSecond <= 0 {
+		return 0
+	}
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	l.lastRefill = now
+	l.tokens = math.Min(l.cfg.getBurst(), l.tokens+elapsed*l.cfg.RequestsPerSecond)
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	deficit := 1 - l.tokens
+	return time.Duration(deficit / l.cfg.RequestsPerSecond * float64(time.Second))
+}
+
+func (l *standardLimiter) Observe(header http.Header) {
+	cfg := l.GetConfig()
+	if !cfg.IsAdaptive || header == nil {
+		return
+	}
+	remainingStr := strings.TrimSpace(header.Get(cfg.getRemainingHeader()))
+	resetStr := strings.TrimSpace(header.Get(cfg.getResetHeader()))
+	if remainingStr == "" || resetStr == "" {
+		return
+	}
+	remaining, remainingErr := strconv.ParseFloat(remainingStr, 64)
+	if remainingErr != nil || remaining > 0 {
+		return
+	}
+	now := l.now()
+	resetAt, ok := ParseReset(resetStr, cfg.ResetFormat, now)
+	if !ok || !resetAt.After(now) {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if resetAt.After(l.blockedUntil) {
+		l.blockedUntil = resetAt
+	}
+}
+
+// ParseReset interprets a rate limit reset header value relative to now,
+// according to format (one of the ResetFormat* constants).
+func ParseReset(raw string, format string, now time.Time) (time.Time, bool) {
+	val, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || val < 0 {
+		return time.Time{}, false
+	}
+	switch format {
+	case ResetFormatEpochSeconds:
+		return epochSecondsToTime(val), true
+	case ResetFormatDeltaSeconds:
+		return now.Add(time.Duration(val * float64(time.Second))), true
+	default:
+		if val >= epochThreshold {
+			return epochSecondsToTime(val), true
+		}
+		return now.Add(time.Duration(val * float64(time.Second))), true
+	}
+}
+
+func epochSecondsToTime(val float64) time.Time {
+	sec, frac := math.Modf(val)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
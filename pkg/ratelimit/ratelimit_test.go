@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (fc *fakeClock) now() time.Time {
+	return fc.t
+}
+
+func TestReserve_BurstThenRefill(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := newLimiter(Config{RequestsPerSecond: 2, Burst: 3}, clk.now)
+	for i := 0; i < 3; i++ {
+		if wait := l.reserve(); wait != 0 {
+			t.Fatalf("expected burst token %d to be free, got wait %s", i, wait)
+		}
+	}
+	wait := l.reserve()
+	if wait != 500*time.Millisecond {
+		t.Fatalf("expected 500ms wait once burst is spent, got %s", wait)
+	}
+	clk.t = clk.t.Add(500 * time.Millisecond)
+	if wait := l.reserve(); wait != 0 {
+		t.Fatalf("expected refilled token after 500ms, got wait %s", wait)
+	}
+}
+
+func TestReserve_ZeroRateIsUnlimited(t *testing.T) {
+	l := NewLimiter(Config{}).(*standardLimiter)
+	for i := 0; i < 100; i++ {
+		if wait := l.reserve(); wait != 0 {
+			t.Fatalf("expected no wait for zero config, got %s", wait)
+		}
+	}
+}
+
+func TestObserve_AdaptivePausesUntilReset(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := newLimiter(Config{IsAdaptive: true}, clk.now)
+	h := http.Header{}
+	h.Set(DefaultRemainingHeader, "0")
+	h.Set(DefaultResetHeader, strconv.FormatInt(clk.t.Add(30*time.Second).Unix(), 10))
+	l.Observe(h)
+	if wait := l.reserve(); wait != 30*time.Second {
+		t.Fatalf("expected 30s pause for epoch reset, got %s", wait)
+	}
+	clk.t = clk.t.Add(30 * time.Second)
+	if wait := l.reserve(); wait != 0 {
+		t.Fatalf("expected no pause after reset, got %s", wait)
+	}
+}
+
+func TestObserve_DeltaSecondsAndRemainingBudget(t *testing.T) {
+	clk := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := newLimiter(Config{IsAdaptive: true, ResetHeader: "RateLimit-Reset"}, clk.now)
+	h := http.Header{}
+	h.Set(DefaultRemainingHeader, "5")
+	h.Set("RateLimit-Reset", "10")
+	l.Observe(h)
+	if wait := l.reserve(); wait != 0 {
+		t.Fatalf("expected no pause while budget remains, got %s", wait)
+	}
+	h.Set(DefaultRemainingHeader, "0")
+	l.Observe(h)
+	if wait := l.reserve(); wait != 10*time.Second {
+		t.Fatalf("expected 10s pause for delta reset, got %s", wait)
+	}
+}
+
+func TestAcquire_ConcurrencySlots(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrency: 1})
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); err == nil {
+		t.Fatalf("expected second acquire to block until the deadline")
+	}
+	release()
+	release() // idempotent
+	if _, err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("expected slot to be free after release, got %v", err)
+	}
+}
+
+func TestRegistry_ReconfiguresLimitersInPlace(t *testing.T) {
+	r := NewRegistry()
+	cfg := Config{RequestsPerSecond: 1, MaxConcurrency: 1}
+	a := r.GetLimiter("github/api.github.com", cfg)
+	if r.GetLimiter("github/api.github.com", cfg) != a {
+		t.Fatalf("expected the same limiter for an unchanged config")
+	}
+	release, err := a.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+	changed := Config{RequestsPerSecond: 100, MaxConcurrency: 2}
+	if r.GetLimiter("github/api.github.com", changed) != a {
+		t.Fatalf("expected a changed config to keep the live limiter")
+	}
+	if a.GetConfig() != changed {
+		t.Fatalf("expected the changed config to be applied, got %+v", a.GetConfig())
+	}
+	second, err := a.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer second()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := a.Acquire(ctx); err == nil {
+		t.Fatalf("expected the slot held before the change to still count")
+	}
+}
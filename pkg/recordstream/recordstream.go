@@ -0,0 +1,328 @@
+package recordstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatYAML   = "yaml"
+
+	ColumnTypeString  = "string"
+	ColumnTypeInteger = "integer"
+	ColumnTypeNumber  = "number"
+	ColumnTypeBoolean = "boolean"
+	ColumnTypeObject  = "object"
+	ColumnTypeArray   = "array"
+)
+
+// Decoder yields one record at a time from a response body, so that large
+// exports are decoded without buffering the whole body. Next returns io.EOF
+// after the last record.
+type Decoder interface {
+	Next() (interface{}, error)
+}
+
+// Options tune decoding. ColumnTypes maps a CSV/TSV column name to one of
+// the ColumnType constants; columns without a type stay strings.
+type Options struct {
+	ColumnTypes map[string]string
+}
+
+// NewDecoder returns a streaming decoder for one of the Format constants.
+func NewDecoder(format string, r io.Reader, opts Options) (Decoder, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONDecoder(r), nil
+	case FormatCSV:
+		return newDelimitedDecoder(r, ',', opts.ColumnTypes), nil
+	case FormatTSV:
+		return newDelimitedDecoder(r, '\t', opts.ColumnTypes), nil
+	case FormatYAML:
+		return newYAMLDecoder(r), nil
+	default:
+		return nil, fmt.Errorf("record format '%s' not supported", format)
+	}
+}
+
+// ReadAll drains d into a slice.
+func ReadAll(d Decoder) ([]interface{}, error) {
+	rv := []interface{}{}
+	for {
+		rec, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return rv, nil
+		}
+		if err != nil {
+			return rv, err
+		}
+		rv = append(rv, rec)
+	}
+}
+
+// ndjsonDecoder reads one JSON value per line. Blank lines are skipped and
+// a value may not span lines, so that a malformed line is reported by number.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// maxNDJSONLineBytes bounds a single line of NDJSON or TSV.
+const maxNDJSONLineBytes = 64 << 20
+
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineBytes)
+	return &ndjsonDecoder{scanner: scanner}
+}
+
+func (d *ndjsonDecoder) Next() (interface{}, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if d.line == 1 {
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var rv interface{}
+		if err := json.Unmarshal(line, &rv); err != nil {
+			return nil, fmt.Errorf("ndjson line %d: %w", d.line, err)
+		}
+		return rv, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF} //nolint:gochecknoglobals // constant bytes
+
+// delimitedDecoder reads CSV or TSV. The first row is the header; empty or
+// repeated names, and cells beyond the header, are named column_<n>.
+type delimitedDecoder struct {
+	rows        rowReader
+	header      []string
+	columnTypes map[string]string
+}
+
+type rowReader interface {
+	Read() ([]string, error)
+	Line() int
+}
+
+func newDelimitedDecoder(r io.Reader, comma rune, columnTypes map[string]string) *delimitedDecoder {
+	if comma == '\t' {
+		return &delimitedDecoder{rows: newTSVReader(r), columnTypes: columnTypes}
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return &delimitedDecoder{rows: &csvReader{reader: reader}, columnTypes: columnTypes}
+}
+
+func (d *delimitedDecoder) Next() (interface{}, error) {
+	if d.header == nil {
+		header, err := d.rows.Read()
+		if err != nil {
+			return nil, err
+		}
+		d.header = inferHeader(header)
+	}
+	record, err := d.rows.Read()
+	if err != nil {
+		return nil, err
+	}
+	rv := make(map[string]interface{}, len(d.header))
+	for i, cell := range record {
+		name := columnName(d.header, i)
+		v, convErr := convertCell(cell, d.columnTypes[name])
+		if convErr != nil {
+			return nil, fmt.Errorf("line %d, column '%s': %w", d.rows.Line(), name, convErr)
+		}
+		rv[name] = v
+	}
+	for i := len(record); i < len(d.header); i++ {
+		rv[d.header[i]] = nil
+	}
+	return rv, nil
+}
+
+type csvReader struct {
+	reader *csv.Reader
+}
+
+func (r *csvReader) Read() ([]string, error) {
+	return r.reader.Read()
+}
+
+func (r *csvReader) Line() int {
+	line, _ := r.reader.FieldPos(0)
+	return line
+}
+
+// tsvReader follows the IANA text/tab-separated-values definition: fields
+// are split on tabs and never quoted. Blank lines are skipped, as by
+// encoding/csv.
+type tsvReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newTSVReader(r io.Reader) *tsvReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineBytes)
+	return &tsvReader{scanner: scanner}
+}
+
+func (r *tsvReader) Read() ([]string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSuffix(r.scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		return strings.Split(line, "\t"), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *tsvReader) Line() int {
+	return r.line
+}
+
+func inferHeader(raw []string) []string {
+	rv := make([]string, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for i, name := range raw {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			name = strings.TrimPrefix(name, string(utf8BOM))
+		}
+		if _, dup := seen[name]; name == "" || dup {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		seen[name] = struct{}{}
+		rv[i] = name
+	}
+	return rv
+}
+
+func columnName(header []string, i int) string {
+	if i < len(header) {
+		return header[i]
+	}
+	return fmt.Sprintf("column_%d", i+1)
+}
+
+// convertCell types a cell per its column type. An empty cell of a non
+// string column is null.
+func convertCell(cell string, columnType string) (interface{}, error) {
+	if columnType == "" || columnType == ColumnTypeString {
+		return cell, nil
+	}
+	if strings.TrimSpace(cell) == "" {
+		return nil, nil
+	}
+	cell = strings.TrimSpace(cell)
+	switch columnType {
+	case ColumnTypeInteger:
+		i, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer '%s'", cell)
+		}
+		return i, nil
+	case ColumnTypeNumber:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", cell)
+		}
+		return f, nil
+	case ColumnTypeBoolean:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean '%s'", cell)
+		}
+		return b, nil
+	case ColumnTypeObject, ColumnTypeArray:
+		var rv interface{}
+		if err := json.Unmarshal([]byte(cell), &rv); err != nil {
+			return nil, fmt.Errorf("invalid JSON %s: %w", columnType, err)
+		}
+		return rv, nil
+	default:
+		return cell, nil
+	}
+}
+
+// yamlDecoder yields one record per YAML document, converted to the same
+// shapes encoding/json produces so that JSON paths and transforms apply.
+type yamlDecoder struct {
+	decoder *yaml.Decoder
+}
+
+func newYAMLDecoder(r io.Reader) *yamlDecoder {
+	return &yamlDecoder{decoder: yaml.NewDecoder(r)}
+}
+
+func (d *yamlDecoder) Next() (interface{}, error) {
+	for {
+		var doc interface{}
+		if err := d.decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		return toJSONCompatible(doc)
+	}
+}
+
+func toJSONCompatible(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(stringifyKeys(v))
+	if err != nil {
+		return nil, err
+	}
+	var rv interface{}
+	err = json.Unmarshal(b, &rv)
+	return rv, err
+}
+
+func stringifyKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = stringifyKeys(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		rv := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			rv[fmt.Sprintf("%v", k)] = stringifyKeys(val)
+		}
+		return rv
+	case []interface{}:
+		for i, val := range t {
+			t[i] = stringifyKeys(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,96 @@
+package recordstream
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, format string, body string, opts Options) string {
+	t.Helper()
+	d, err := NewDecoder(format, strings.NewReader(body), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := ReadAll(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := json.Marshal(records)
+	return string(b)
+}
+
+func TestNDJSON(t *testing.T) {
+	got := readAll(t, FormatNDJSON, "\xEF\xBB\xBF{\"a\":1}\n\n{\"a\":2}\r\n", Options{})
+	if got != `[{"a":1},{"a":2}]` {
+		t.Fatalf("unexpected records %s", got)
+	}
+}
+
+func TestNDJSON_ReportsLine(t *testing.T) {
+	d, _ := NewDecoder(FormatNDJSON, strings.NewReader("{\"a\":1}\n{oops}\n"), Options{})
+	if _, err := d.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := d.Next(); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected a line 2 error, got %v", err)
+	}
+}
+
+func TestCSV_HeaderInferenceAndTypes(t *testing.T) {
+	body := "id,name,,score,active,tags,id\n" +
+		"1,\"Smith, J\",x,9.5,true,\"[\"\"a\"\"]\",dup,extra\n" +
+		"2,Lee\n"
+	opts := Options{ColumnTypes: map[string]string{
+		"id":     ColumnTypeInteger,
+		"score":  ColumnTypeNumber,
+		"active": ColumnTypeBoolean,
+		"tags":   ColumnTypeArray,
+	}}
+	got := readAll(t, FormatCSV, body, opts)
+	expected := `[{"active":true,"column_3":"x","column_7":"dup","column_8":"extra","id":1,"name":"Smith, J","score":9.5,"tags":["a"]},` +
+		`{"active":null,"column_3":null,"column_7":null,"id":2,"name":"Lee","score":null,"tags":null}]`
+	if got != expected {
+		t.Fatalf("unexpected records:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestCSV_InvalidTypedCell(t *testing.T) {
+	d, _ := NewDecoder(FormatCSV, strings.NewReader("n\nabc\n"), Options{ColumnTypes: map[string]string{"n": ColumnTypeInteger}})
+	if _, err := d.Next(); err == nil || !strings.Contains(err.Error(), "line 2, column 'n'") {
+		t.Fatalf("expected a typed cell error, got %v", err)
+	}
+}
+
+func TestTSV(t *testing.T) {
+	got := readAll(t, FormatTSV, "a\tb\n1\t\"2\n", Options{})
+	if got != `[{"a":"1","b":"\"2"}]` {
+		t.Fatalf("unexpected records %s", got)
+	}
+}
+
+func TestYAML_MultiDocument(t *testing.T) {
+	body := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: a\n---\n---\nkind: Pod\nmetadata:\n  name: b\n  labels:\n    1: one\n"
+	got := readAll(t, FormatYAML, body, Options{})
+	expected := `[{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a"}},{"kind":"Pod","metadata":{"labels":{"1":"one"},"name":"b"}}]`
+	if got != expected {
+		t.Fatalf("unexpected records:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestEmptyBodies(t *testing.T) {
+	for _, format := range []string{FormatNDJSON, FormatCSV, FormatTSV, FormatYAML} {
+		d, _ := NewDecoder(format, strings.NewReader(""), Options{})
+		if _, err := d.Next(); !errors.Is(err, io.EOF) {
+			t.Fatalf("%s: expected io.EOF, got %v", format, err)
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewDecoder("parquet", strings.NewReader(""), Options{}); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/antchfx/xmlquery"
+	"github.com/stackql/any-sdk/pkg/apierror"
 	"github.com/stackql/any-sdk/pkg/httpelement"
 	"github.com/stackql/any-sdk/pkg/jsonpath"
 	"github.com/stackql/any-sdk/pkg/media"
@@ -26,6 +27,9 @@ type Response interface {
 	Error() string
 	HasError() bool
 	SetError(string)
+	// GetAPIError returns the normalised error of a non-2xx response.
+	GetAPIError() (*apierror.APIError, bool)
+	SetAPIError(*apierror.APIError)
 	String() string
 }
 
@@ -36,6 +40,7 @@ type basicResponse struct {
 	httpResponse        *http.Response
 	bodyMediaType       string
 	errorStringOverride string
+	apiError            *apierror.APIError
 }
 
 func (r *basicResponse) GetHttpResponse() *http.Response {
@@ -87,6 +92,14 @@ func (r *basicResponse) SetError(err string) {
 	r.errorStringOverride = err
 }
 
+func (r *basicResponse) GetAPIError() (*apierror.APIError, bool) {
+	return r.apiError, r.apiError != nil
+}
+
+func (r *basicResponse) SetAPIError(apiError *apierror.APIError) {
+	r.apiError = apiError
+}
+
 func (r *basicResponse) Error() string {
 	if r.errorStringOverride != "" {
 		return r.errorStringOverride
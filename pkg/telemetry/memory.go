@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	_ Instrumentation = &InMemory{}
+)
+
+type memorySpanKey struct{}
+
+// RecordedSpan is a span captured by InMemory.
+type RecordedSpan struct {
+	Name       string
+	Parent     string
+	Attributes map[string]interface{}
+	Err        error
+	Ended      bool
+}
+
+// InMemory records spans and HTTP attempts, for tests.
+type InMemory struct {
+	mu       sync.Mutex
+	spans    []*RecordedSpan
+	attempts []HTTPAttempt
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{}
+}
+
+func (im *InMemory) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	rs := &RecordedSpan{
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+	}
+	if parent, ok := ctx.Value(memorySpanKey{}).(*memorySpan); ok {
+		rs.Parent = parent.recorded.Name
+	}
+	span := &memorySpan{owner: im, recorded: rs}
+	span.SetAttributes(attrs...)
+	im.mu.Lock()
+	im.spans = append(im.spans, rs)
+	im.mu.Unlock()
+	return context.WithValue(ctx, memorySpanKey{}, span), span
+}
+
+func (im *InMemory) RecordHTTPAttempt(_ context.Context, attempt HTTPAttempt) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.attempts = append(im.attempts, attempt)
+}
+
+// GetSpans returns copies of the spans started so far, in start order.
+func (im *InMemory) GetSpans() []RecordedSpan {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	rv := make([]RecordedSpan, 0, len(im.spans))
+	for _, s := range im.spans {
+		cp := *s
+		cp.Attributes = make(map[string]interface{}, len(s.Attributes))
+		for k, v := range s.Attributes {
+			cp.Attributes[k] = v
+		}
+		rv = append(rv, cp)
+	}
+	return rv
+}
+
+// GetSpansNamed returns the spans started so far with the given name.
+func (im *InMemory) GetSpansNamed(name string) []RecordedSpan {
+	var rv []RecordedSpan
+	for _, s := range im.GetSpans() {
+		if s.Name == name {
+			rv = append(rv, s)
+		}
+	}
+	return rv
+}
+
+// GetHTTPAttempts returns the HTTP attempts recorded so far.
+func (im *InMemory) GetHTTPAttempts() []HTTPAttempt {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	return append([]HTTPAttempt{}, im.attempts...)
+}
+
+type memorySpan struct {
+	owner    *InMemory
+	recorded *RecordedSpan
+}
+
+func (ms *memorySpan) SetAttributes(attrs ...Attribute) {
+	ms.owner.mu.Lock()
+	defer ms.owner.mu.Unlock()
+	for _, a := range attrs {
+		ms.recorded.Attributes[a.Key] = a.Value
+	}
+}
+
+func (ms *memorySpan) RecordError(err error) {
+	ms.owner.mu.Lock()
+	defer ms.owner.mu.Unlock()
+	ms.recorded.Err = err
+}
+
+func (ms *memorySpan) End() {
+	ms.owner.mu.Lock()
+	defer ms.owner.mu.Unlock()
+	ms.recorded.Ended = true
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/stackql/any-sdk"
+)
+
+var (
+	_ Instrumentation = &otelInstrumentation{}
+)
+
+type otelInstrumentation struct {
+	tracer   trace.Tracer
+	attempts metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// NewOpenTelemetry returns Instrumentation that emits spans through tp and
+// metrics through mp, for example the global providers from the otel
+// package once an SDK is configured.
+func NewOpenTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (Instrumentation, error) {
+	meter := mp.Meter(instrumentationName)
+	attempts, err := meter.Int64Counter(
+		MetricHTTPAttempts,
+		metric.WithDescription("HTTP attempts sent to provider APIs, retries included."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram(
+		MetricHTTPDuration,
+		metric.WithDescription("Latency of HTTP attempts sent to provider APIs."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &otelInstrumentation{
+		tracer:   tp.Tracer(instrumentationName),
+		attempts: attempts,
+		duration: duration,
+	}, nil
+}
+
+func (oi *otelInstrumentation) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	ctx, span := oi.tracer.Start(ctx, name, trace.WithAttributes(toKeyValues(attrs)...))
+	return ctx, &otelSpan{span: span}
+}
+
+func (oi *otelInstrumentation) RecordHTTPAttempt(ctx context.Context, attempt HTTPAttempt) {
+	attrs := metric.WithAttributes(
+		attribute.String(AttrHTTPMethod, attempt.Method),
+		attribute.String(AttrServerAddress, attempt.Host),
+		attribute.Int(AttrHTTPStatusCode, attempt.StatusCode),
+	)
+	oi.attempts.Add(ctx, 1, attrs)
+	oi.duration.Record(ctx, attempt.Duration.Seconds(), attrs)
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttributes(attrs ...Attribute) {
+	s.span.SetAttributes(toKeyValues(attrs)...)
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+func toKeyValues(attrs []Attribute) []attribute.KeyValue {
+	rv := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			rv = append(rv, attribute.String(a.Key, v))
+		case int:
+			rv = append(rv, attribute.Int(a.Key, v))
+		case int64:
+			rv = append(rv, attribute.Int64(a.Key, v))
+		case float64:
+			rv = append(rv, attribute.Float64(a.Key, v))
+		case bool:
+			rv = append(rv, attribute.Bool(a.Key, v))
+		default:
+			rv = append(rv, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	return rv
+}
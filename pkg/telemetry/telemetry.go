@@ -0,0 +1,144 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Span names emitted by any-sdk.
+const (
+	SpanAuth        = "anysdk.auth"
+	SpanHTTPRequest = "anysdk.http.request"
+	SpanHTTPAttempt = "anysdk.http.attempt"
+	SpanPage        = "anysdk.page"
+	SpanTransform   = "anysdk.transform"
+	SpanLocalExec   = "anysdk.local_exec"
+)
+
+// Attribute keys used on spans and metrics.
+const (
+	AttrProvider       = "anysdk.provider"
+	AttrOperation      = "anysdk.operation"
+	AttrAuthType       = "anysdk.auth.type"
+	AttrAttempt        = "anysdk.http.attempt"
+	AttrPage           = "anysdk.page"
+	AttrTransformType  = "anysdk.transform.type"
+	AttrCommand        = "anysdk.command"
+	AttrExitCode       = "anysdk.command.exit_code"
+	AttrHTTPMethod     = "http.request.method"
+	AttrServerAddress  = "server.address"
+	AttrHTTPStatusCode = "http.response.status_code"
+)
+
+// Metric names emitted by any-sdk.
+const (
+	MetricHTTPAttempts = "anysdk.http.client.attempts"
+	MetricHTTPDuration = "anysdk.http.client.duration"
+)
+
+var (
+	instrumentationMu sync.RWMutex                            //nolint:gochecknoglobals // process wide hook, as with logging
+	instrumentation   Instrumentation = noopInstrumentation{} //nolint:gochecknoglobals // process wide hook, as with logging
+)
+
+// Attribute is a key and a string, integer, floating point or boolean value.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a unit of work started by Instrumentation.StartSpan.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// HTTPAttempt describes one HTTP attempt, retries included.
+type HTTPAttempt struct {
+	Method string
+	Host   string
+	// StatusCode is zero when the attempt failed without a response.
+	StatusCode int
+	Attempt    int
+	Duration   time.Duration
+	Err        error
+}
+
+// Instrumentation receives spans and metrics. Implementations must be safe
+// for concurrent use.
+type Instrumentation interface {
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+	RecordHTTPAttempt(ctx context.Context, attempt HTTPAttempt)
+}
+
+// SetInstrumentation installs i for the process. Nil restores the default,
+// which discards everything.
+func SetInstrumentation(i Instrumentation) {
+	instrumentationMu.Lock()
+	defer instrumentationMu.Unlock()
+	if i == nil {
+		i = noopInstrumentation{}
+	}
+	instrumentation = i
+}
+
+func GetInstrumentation() Instrumentation {
+	instrumentationMu.RLock()
+	defer instrumentationMu.RUnlock()
+	return instrumentation
+}
+
+// StartSpan starts a span on the installed instrumentation. A nil ctx is
+// treated as context.Background.
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return GetInstrumentation().StartSpan(ctx, name, attrs...)
+}
+
+// RecordHTTPAttempt records an attempt on the installed instrumentation.
+func RecordHTTPAttempt(ctx context.Context, attempt HTTPAttempt) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	GetInstrumentation().RecordHTTPAttempt(ctx, attempt)
+}
+
+// EndSpan records err, if any, on span and ends it.
+func EndSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopInstrumentation struct{}
+
+func (noopInstrumentation) StartSpan(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopInstrumentation) RecordHTTPAttempt(context.Context, HTTPAttempt) {}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDefaultInstrumentationIsNoop(t *testing.T) {
+	SetInstrumentation(nil)
+	ctx, span := StartSpan(nil, SpanAuth) //nolint:staticcheck // nil context is tolerated
+	if ctx == nil {
+		t.Fatalf("expected a context")
+	}
+	EndSpan(span, errors.New("ignored"))
+	RecordHTTPAttempt(ctx, HTTPAttempt{StatusCode: 200})
+}
+
+func TestInMemoryNestsSpans(t *testing.T) {
+	im := NewInMemory()
+	SetInstrumentation(im)
+	defer SetInstrumentation(nil)
+	ctx, parent := StartSpan(context.Background(), SpanHTTPRequest, String(AttrProvider, "google"))
+	_, child := StartSpan(ctx, SpanHTTPAttempt, Int(AttrAttempt, 1))
+	EndSpan(child, errors.New("boom"))
+	parent.End()
+	RecordHTTPAttempt(ctx, HTTPAttempt{Method: "GET", StatusCode: 503, Attempt: 1, Duration: time.Millisecond})
+
+	spans := im.GetSpans()
+	if len(spans) != 2 || spans[1].Parent != SpanHTTPRequest || spans[1].Err == nil || !spans[0].Ended {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+	if spans[0].Attributes[AttrProvider] != "google" || spans[1].Attributes[AttrAttempt] != 1 {
+		t.Fatalf("unexpected attributes %+v", spans)
+	}
+	if attempts := im.GetHTTPAttempts(); len(attempts) != 1 || attempts[0].StatusCode != 503 {
+		t.Fatalf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestOpenTelemetryExportsSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	oi, err := NewOpenTelemetry(tp, metricnoop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, parent := oi.StartSpan(context.Background(), SpanPage, Int(AttrPage, 2))
+	_, child := oi.StartSpan(ctx, SpanTransform, String(AttrTransformType, "golang_template_v0.1.0"))
+	child.SetAttributes(Bool("ok", false), Attribute{Key: "other", Value: []string{"x"}})
+	EndSpan(child, errors.New("bad template"))
+	parent.End()
+	oi.RecordHTTPAttempt(ctx, HTTPAttempt{Method: "GET", Host: "example.com", StatusCode: 200})
+
+	stubs := exporter.GetSpans()
+	if len(stubs) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(stubs))
+	}
+	child0, parent0 := stubs[0], stubs[1]
+	if child0.Name != SpanTransform || parent0.Name != SpanPage || child0.Parent.SpanID() != parent0.SpanContext.SpanID() {
+		t.Fatalf("unexpected span tree %s %s", child0.Name, parent0.Name)
+	}
+	if child0.Status.Code != codes.Error || len(child0.Events) != 1 || len(child0.Attributes) != 3 {
+		t.Fatalf("unexpected child span %+v", child0)
+	}
+}
@@ -1,16 +1,22 @@
 package formulation
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/apierror"
 	"github.com/stackql/any-sdk/pkg/client"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/internaldto"
+	"github.com/stackql/any-sdk/pkg/paginator"
 	"github.com/stackql/any-sdk/pkg/providerinvoker"
+	"github.com/stackql/any-sdk/pkg/recordstream"
 	"github.com/stackql/any-sdk/pkg/streaming"
 	"github.com/stackql/any-sdk/public/discovery"
 	"github.com/stackql/any-sdk/public/persistence"
@@ -165,6 +171,60 @@ var DefaultLinkHeaderTransformer = anysdk.DefaultLinkHeaderTransformer
 
 var NewAnySdkClientConfigurator = anysdk.NewAnySdkClientConfigurator
 
+// ResponseViolation is one way a response body departs from its schema.
+type ResponseViolation = anysdk.ResponseViolation
+
+const (
+	ResponseValidationOff   = anysdk.ResponseValidationOff
+	ResponseValidationWarn  = anysdk.ResponseValidationWarn
+	ResponseValidationError = anysdk.ResponseValidationError
+)
+
+// APIError is a failed API call, normalised across provider error formats.
+type APIError = apierror.APIError
+
+const (
+	UpsertActionCreated   = anysdk.UpsertActionCreated
+	UpsertActionUpdated   = anysdk.UpsertActionUpdated
+	UpsertActionUnchanged = anysdk.UpsertActionUnchanged
+	UpsertActionUpserted  = anysdk.UpsertActionUpserted
+)
+
+// UpsertResult is the outcome of Upsert. Response is the open response of
+// the call that made the change; it is nil when nothing changed.
+type UpsertResult struct {
+	Action   string
+	Method   StandardOperationStore
+	Response *http.Response
+}
+
+// Upsert creates the resource addressed by params if it is absent and
+// updates it if it is present, following a plan from the resource's
+// GetUpsertPlan. The change is awaited and recorded in uow as
+// anysdkhttp.Upsert describes; uow may be nil.
+func Upsert(
+	ctx context.Context,
+	plan UpsertPlan,
+	params map[string]interface{},
+	authCtx *dto.AuthCtx,
+	runtimeCtx dto.RuntimeCtx,
+	outErrFile io.Writer,
+	defaultHTTPClient *http.Client,
+	isAwait bool,
+	uow anysdkhttp.UnitOfWork,
+) (UpsertResult, error) {
+	rv, err := anysdkhttp.Upsert(
+		ctx, plan.unwrap(), params, authCtx, runtimeCtx, outErrFile, defaultHTTPClient, isAwait, uow)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	result := UpsertResult{Action: rv.Action, Response: rv.Response}
+	if rv.Method != nil {
+		result.Method = &wrappedStandardOperationStore{inner: rv.Method}
+	}
+	return result, nil
+}
+
 func NewStringSchema(svc OpenAPIService, key string, path string) Schema {
 	raw := anysdk.NewStringSchema(svc.unwrapOpenapi3Service(), key, path)
 	return newWrappedSchemaFromAnySdkSchema(raw)
@@ -425,6 +485,53 @@ func GetMonitorRequest(urlStr string) (client.AnySdkArgList, error) {
 	return anysdk.GetMonitorRequest(urlStr)
 }
 
+// AuditLocalExecution writes an audit record for a local templated call of
+// op, made with params, to the audit log named by runtimeCtx, if any. The
+// caller runs it once the command has run; callErr is the command's error.
+func AuditLocalExecution(
+	runtimeCtx dto.RuntimeCtx,
+	op OperationStore,
+	params map[string]interface{},
+	callErr error,
+	start time.Time,
+) error {
+	return anysdk.AuditLocalExecution(runtimeCtx, op.unwrap(), params, callErr, start)
+}
+
+// NewPaginatorConfig maps the operation's pagination spec onto a
+// paginator.Config, for use with paginator.New.
+func NewPaginatorConfig(method OperationStore) (paginator.Config, bool) {
+	return anysdk.NewPaginatorConfig(method.unwrap())
+}
+
+// MarshalPatchBody marshals the desired state of an update. For merge patch
+// and JSON patch request media types the body is diffed against current,
+// which may be nil. It returns the body and the media type to send.
+// HTTPPreparator does not fetch current, so callers wanting a minimal patch
+// fetch the resource and call this before sending the update themselves.
+func MarshalPatchBody(method OperationStore, desired interface{}, current interface{}) ([]byte, string, error) {
+	op := method.unwrap()
+	expectedRequest, ok := op.GetRequest()
+	if !ok {
+		return nil, "", fmt.Errorf("operation '%s' declares no request body", method.GetName())
+	}
+	marshalledBody := op.MarshalPatchBody(desired, current, expectedRequest)
+	if err, hasErr := marshalledBody.GetError(); hasErr {
+		return nil, "", err
+	}
+	if contentType, hasContentType := marshalledBody.GetContentType(); hasContentType {
+		return marshalledBody.GetBytes(), contentType, nil
+	}
+	return marshalledBody.GetBytes(), expectedRequest.GetBodyMediaType(), nil
+}
+
+// NewRecordDecoder returns a streaming decoder for an NDJSON, CSV, TSV or
+// YAML response to method, so that large exports are read record by record.
+// The bool is false for other media types.
+func NewRecordDecoder(method OperationStore, r *http.Response) (recordstream.Decoder, bool, error) {
+	return anysdk.NewOperationRecordDecoder(method.unwrap(), r)
+}
+
 type methodElider interface {
 	IsElide(string, ...any) bool
 }
@@ -252,6 +252,8 @@ type OperationStore interface {
 	IsRequestBodyAttributeRenamed(p0 string) bool
 	IsRequiredRequestBodyProperty(key string) bool
 	ProcessResponse(p0 *http.Response) (ProcessedOperationResponse, error)
+	ValidateResponseBody(body interface{}) []ResponseViolation
+	ParseAPIError(httpResponse *http.Response) (*APIError, bool)
 	RenameRequestBodyAttribute(p0 string) (string, error)
 	RevertRequestBodyAttributeRename(p0 string) (string, error)
 	GetProjections() map[string]string
@@ -424,10 +426,23 @@ type Resource interface {
 	GetMethodsMatched() Methods
 	GetName() string
 	GetViewsForSqlDialect(sqlDialect string) ([]View, bool)
+	GetUpsertPlan(parameters map[string]interface{}) (UpsertPlan, error)
 	ToMap(extended bool) map[string]interface{}
 	unwrap() anysdk.Resource
 }
 
+// UpsertPlan mirrors methods on UpsertPlan
+type UpsertPlan interface {
+	IsNative() bool
+	GetUpsertMethod() (StandardOperationStore, bool)
+	GetGetMethod() (StandardOperationStore, bool)
+	GetCreateMethod() (StandardOperationStore, bool)
+	GetUpdateMethod() (StandardOperationStore, bool)
+	GetIdentifierParameters() []string
+	GetOnConflict() string
+	unwrap() anysdk.UpsertPlan
+}
+
 // SQLExternalColumn mirrors methods on SQLExternalColumn
 type SQLExternalColumn interface {
 	GetName() string
@@ -709,6 +724,7 @@ type Response interface {
 	GetHttpResponse() *http.Response
 	GetProcessedBody() interface{}
 	HasError() bool
+	GetAPIError() (*APIError, bool)
 }
 
 // HTTPHTTPElement mirrors methods on HTTPHTTPElement (httpelement-backed)
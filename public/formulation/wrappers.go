@@ -971,6 +971,14 @@ func (w *wrappedOperationStore) ProcessResponse(p0 *http.Response) (ProcessedOpe
 	return &wrappedProcessedOperationResponse{inner: r0}, r1
 }
 
+func (w *wrappedOperationStore) ValidateResponseBody(body interface{}) []ResponseViolation {
+	return w.inner.ValidateResponseBody(body)
+}
+
+func (w *wrappedOperationStore) ParseAPIError(httpResponse *http.Response) (*APIError, bool) {
+	return w.inner.ParseAPIError(httpResponse)
+}
+
 func (w *wrappedOperationStore) RenameRequestBodyAttribute(p0 string) (string, error) {
 	r0, r1 := w.inner.RenameRequestBodyAttribute(p0)
 	return r0, r1
@@ -1217,6 +1225,14 @@ func (w *wrappedResource) GetFirstNamespaceMethodMatchFromSQLVerb(sqlVerb string
 	return &wrappedStandardOperationStore{inner: r0}, r1, r2
 }
 
+func (w *wrappedResource) GetUpsertPlan(parameters map[string]interface{}) (UpsertPlan, error) {
+	r0, r1 := w.inner.GetUpsertPlan(parameters)
+	if r1 != nil {
+		return nil, r1
+	}
+	return &wrappedUpsertPlan{inner: r0}, nil
+}
+
 func (w *wrappedResource) GetID() string {
 	r0 := w.inner.GetID()
 	return r0
@@ -1653,6 +1669,14 @@ func (w *wrappedStandardOperationStore) ProcessResponse(p0 *http.Response) (Proc
 	return &wrappedProcessedOperationResponse{inner: r0}, r1
 }
 
+func (w *wrappedStandardOperationStore) ValidateResponseBody(body interface{}) []ResponseViolation {
+	return w.inner.ValidateResponseBody(body)
+}
+
+func (w *wrappedStandardOperationStore) ParseAPIError(httpResponse *http.Response) (*APIError, bool) {
+	return w.inner.ParseAPIError(httpResponse)
+}
+
 func (w *wrappedStandardOperationStore) RenameRequestBodyAttribute(p0 string) (string, error) {
 	r0, r1 := w.inner.RenameRequestBodyAttribute(p0)
 	return r0, r1
@@ -2567,6 +2591,10 @@ func (w *wrappedResponse) HasError() bool {
 	return r0
 }
 
+func (w *wrappedResponse) GetAPIError() (*APIError, bool) {
+	return w.inner.GetAPIError()
+}
+
 type wrappedStreamTransformer struct {
 	inner stream_transform.StreamTransformer
 }
@@ -3259,3 +3287,46 @@ func (w *wrappedTTLDiscoveryStore) PersistServiceShard(prov Provider, sh Provide
 func (w *wrappedTTLDiscoveryStore) unwrap() discovery.IDiscoveryStore {
 	return w.inner
 }
+
+type wrappedUpsertPlan struct {
+	inner anysdk.UpsertPlan
+}
+
+func wrapUpsertMethod(m anysdk.StandardOperationStore, ok bool) (StandardOperationStore, bool) {
+	if !ok {
+		return nil, false
+	}
+	return &wrappedStandardOperationStore{inner: m}, true
+}
+
+func (w *wrappedUpsertPlan) IsNative() bool {
+	return w.inner.IsNative()
+}
+
+func (w *wrappedUpsertPlan) GetUpsertMethod() (StandardOperationStore, bool) {
+	return wrapUpsertMethod(w.inner.GetUpsertMethod())
+}
+
+func (w *wrappedUpsertPlan) GetGetMethod() (StandardOperationStore, bool) {
+	return wrapUpsertMethod(w.inner.GetGetMethod())
+}
+
+func (w *wrappedUpsertPlan) GetCreateMethod() (StandardOperationStore, bool) {
+	return wrapUpsertMethod(w.inner.GetCreateMethod())
+}
+
+func (w *wrappedUpsertPlan) GetUpdateMethod() (StandardOperationStore, bool) {
+	return wrapUpsertMethod(w.inner.GetUpdateMethod())
+}
+
+func (w *wrappedUpsertPlan) GetIdentifierParameters() []string {
+	return w.inner.GetIdentifierParameters()
+}
+
+func (w *wrappedUpsertPlan) GetOnConflict() string {
+	return w.inner.GetOnConflict()
+}
+
+func (w *wrappedUpsertPlan) unwrap() anysdk.UpsertPlan {
+	return w.inner
+}
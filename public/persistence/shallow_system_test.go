@@ -1,7 +1,10 @@
 package persistence_test
 
 import (
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
 	"github.com/stackql/any-sdk/pkg/dto"
@@ -71,3 +74,45 @@ func TestPersistence01(t *testing.T) {
 		t.Fatalf("Unexpected cached value: %v", string(cachedVal))
 	}
 }
+
+func TestPersistenceCacheExpiry(t *testing.T) {
+	controlAttributes := sqlcontrol.GetControlAttributes("standard")
+	sqlCfg, err := dto.GetSQLBackendCfg("{}")
+	if err != nil {
+		t.Fatalf("Failed to get SQL backend config: %v", err)
+	}
+	sqlEngine, engineErr := sqlengine.NewSQLEngine(
+		sqlCfg,
+		controlAttributes,
+	)
+	if engineErr != nil {
+		t.Fatalf("Failed to create SQL engine: %v", engineErr)
+	}
+	persistenceSystem, err := persistence.NewSQLPersistenceSystem("naive", sqlEngine)
+	if err != nil {
+		t.Fatalf("Failed to create persistence system: %v", err)
+	}
+	setUpScript, scriptErr := sqlengine.GetSQLEngineSetupDDL("sqlite")
+	if scriptErr != nil {
+		t.Fatalf("Failed to get SQL engine setup DDL: %v", scriptErr)
+	}
+	if scriptRunErr := sqlEngine.ExecInTxn([]string{setUpScript}); scriptRunErr != nil {
+		t.Fatalf("Failed to run SQL engine setup DDL: %v", scriptRunErr)
+	}
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	if putErr := persistenceSystem.CacheStorePut("expired-key", []byte("stale"), past, 3600); putErr != nil {
+		t.Fatalf("Failed to put expired value: %v", putErr)
+	}
+	if _, getErr := persistenceSystem.CacheStoreGet("expired-key"); !errors.Is(getErr, sql.ErrNoRows) {
+		t.Fatalf("Expected expired value to be absent, got err = %v", getErr)
+	}
+	if putErr := persistenceSystem.CacheStorePut("durable-key", []byte("value"), "", 0); putErr != nil {
+		t.Fatalf("Failed to put durable value: %v", putErr)
+	}
+	if cachedVal, getErr := persistenceSystem.CacheStoreGet("durable-key"); getErr != nil || string(cachedVal) != "value" {
+		t.Fatalf("Expected durable value, got '%s', err = %v", string(cachedVal), getErr)
+	}
+	if putErr := persistenceSystem.CacheStorePut("bad-key", []byte("value"), "tomorrow", 0); putErr == nil {
+		t.Fatalf("Expected malformed expiration to be rejected")
+	}
+}
@@ -0,0 +1,294 @@
+package anysdkhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/fanout"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/any-sdk/pkg/providerinvoker"
+)
+
+var (
+	_ providerinvoker.ActionInsertResult = &actionInsertResult{}
+	_ InsertPreparator                   = &bufferedInsertPreparator{}
+	_ PolyHandler                        = &bufferedPolyHandler{}
+	_ methodElider                       = &lockedElider{}
+)
+
+// isConcurrentExecution reports whether the runtime context asks for armoury
+// requests to be fanned out. Limits of 0 and 1 retain the historical
+// sequential behaviour; a negative limit means unbounded.
+func isConcurrentExecution(runtimeCtx dto.RuntimeCtx, requestCount int) bool {
+	if requestCount < 2 {
+		return false
+	}
+	return runtimeCtx.ExecutionConcurrencyLimit < 0 || runtimeCtx.ExecutionConcurrencyLimit > 1
+}
+
+func newFanoutExecutor(runtimeCtx dto.RuntimeCtx) fanout.Executor {
+	return fanout.NewExecutor(runtimeCtx.ExecutionConcurrencyLimit, !runtimeCtx.ExecutionConcurrencyUnordered)
+}
+
+// lockedElider serialises access to an elider that was written
+// on the assumption of sequential use.
+type lockedElider struct {
+	mutex sync.Mutex
+	inner methodElider
+}
+
+func newLockedElider(inner methodElider) methodElider {
+	return &lockedElider{inner: inner}
+}
+
+func (le *lockedElider) IsElide(reqEncoding string, args ...any) bool {
+	le.mutex.Lock()
+	defer le.mutex.Unlock()
+	return le.inner.IsElide(reqEncoding, args...)
+}
+
+// bufferedInsertPreparator records insert preparations made by a processor
+// running on a worker goroutine, so they can be replayed against the real
+// preparator on commit.
+type bufferedInsertPreparator struct {
+	payloads []providerinvoker.ActionInsertPayload
+}
+
+func (bp *bufferedInsertPreparator) ActionInsertPreparation(
+	payload providerinvoker.ActionInsertPayload,
+) providerinvoker.ActionInsertResult {
+	bp.payloads = append(bp.payloads, payload)
+	return &actionInsertResult{isHousekeepingDone: true}
+}
+
+// replay threads housekeeping state through the recorded payloads exactly
+// as a sequential processor would have.
+func (bp *bufferedInsertPreparator) replay(target InsertPreparator) error {
+	if target == nil {
+		return nil
+	}
+	housekeepingDone := false
+	for _, p := range bp.payloads {
+		result := target.ActionInsertPreparation(
+			newHTTPActionInsertPayload(
+				p.GetItemisationResult(),
+				housekeepingDone,
+				p.GetTableName(),
+				p.GetParamsUsed(),
+				p.GetReqEncoding(),
+			),
+		)
+		housekeepingDone = result.IsHousekeepingDone()
+		if err, hasErr := result.GetError(); hasErr {
+			return err
+		}
+	}
+	return nil
+}
+
+// bufferedPolyHandler records message and response log calls in order.
+type bufferedPolyHandler struct {
+	actions  []func(PolyHandler)
+	messages []string
+}
+
+func (bh *bufferedPolyHandler) LogHTTPResponseMap(target interface{}) {
+	bh.actions = append(bh.actions, func(ph PolyHandler) {
+		ph.LogHTTPResponseMap(target)
+	})
+}
+
+func (bh *bufferedPolyHandler) MessageHandler(messages []string) {
+	bh.messages = append(bh.messages, messages...)
+	bh.actions = append(bh.actions, func(ph PolyHandler) {
+		ph.MessageHandler(messages)
+	})
+}
+
+func (bh *bufferedPolyHandler) GetMessages() []string {
+	return bh.messages
+}
+
+func (bh *bufferedPolyHandler) replay(target PolyHandler) {
+	if target == nil {
+		return
+	}
+	for _, action := range bh.actions {
+		action(target)
+	}
+}
+
+// processorSink holds everything a concurrently running processor would
+// otherwise write directly to shared consumers.
+type processorSink struct {
+	outErr           bytes.Buffer
+	polyHandler      *bufferedPolyHandler
+	insertPreparator *bufferedInsertPreparator
+}
+
+func newProcessorSink() *processorSink {
+	return &processorSink{
+		polyHandler:      &bufferedPolyHandler{},
+		insertPreparator: &bufferedInsertPreparator{},
+	}
+}
+
+func (ps *processorSink) flush(
+	outErrFile io.Writer,
+	polyHandler PolyHandler,
+	insertPreparator InsertPreparator,
+) error {
+	if outErrFile != nil && ps.outErr.Len() > 0 {
+		if _, err := outErrFile.Write(ps.outErr.Bytes()); err != nil {
+			return err
+		}
+	}
+	ps.polyHandler.replay(polyHandler)
+	return ps.insertPreparator.replay(insertPreparator)
+}
+
+// agnosticateConcurrently is the fan-out counterpart of the sequential loop
+// in agnosticate(). Each armoury request, along with its page chain, runs on
+// a worker; results are committed to the shared sinks serially, in request
+// order unless the runtime context opts out of ordering. Unlike the
+// sequential loop, a failed request does not abort its siblings; all
+// failures are aggregated into the returned error, and all responses into
+// the returned response.
+func agnosticateConcurrently(
+	ctx context.Context,
+	agPayload AgnosticatePayload,
+	reqParams []anysdk.HTTPArmouryParameters,
+) (ProcessorResponse, error) {
+	runtimeCtx := agPayload.GetRuntimeCtx()
+	outErrFile := agPayload.GetOutErrFile()
+	polyHandler := agPayload.GetPolyHandler()
+	insertPreparator := agPayload.GetInsertPreparator()
+	elider := newLockedElider(agPayload.GetElider())
+	executor := newFanoutExecutor(runtimeCtx)
+	logging.GetLogger().Infof(
+		"agnosticateConcurrently() req param count = %d, concurrency limit = %d, ordered = %t",
+		len(reqParams),
+		executor.GetConcurrencyLimit(),
+		executor.IsOrdered(),
+	)
+	responses := make([]ProcessorResponse, len(reqParams))
+	reversals := make([][]anysdk.HTTPPreparator, len(reqParams))
+	tasks := make([]fanout.Task, len(reqParams))
+	for i, rc := range reqParams {
+		idx := i
+		rq := rc
+		sink := newProcessorSink()
+		tasks[idx] = fanout.NewTask(
+			func(taskCtx context.Context) error {
+				if req := rq.GetRequest(); req != nil {
+					rq.SetRequest(withTaskContext(taskCtx, req))
+				}
+				processor := NewProcessor(
+					NewProcessorPayload(
+						rq,
+						elider,
+						agPayload.GetProvider(),
+						agPayload.GetMethod(),
+						agPayload.GetTableName(),
+						runtimeCtx,
+						agPayload.GetAuthContext(),
+						&sink.outErr,
+						sink.polyHandler,
+						agPayload.GetSelectItemsKey(),
+						sink.insertPreparator,
+						agPayload.IsNilResponseAcceptable(),
+						false,
+						agPayload.IsAwait(),
+						false,
+						agPayload.IsMutation(),
+						"",
+						agPayload.GetDefaultHTTPClient(),
+					),
+				)
+				responses[idx] = processor.Process()
+				if responses[idx] != nil {
+					return responses[idx].GetError()
+				}
+				return nil
+			},
+			func() error {
+				reversals[idx] = peekReversals(responses[idx])
+				if uow, hasUnitOfWork := agPayload.GetUnitOfWork(); hasUnitOfWork {
+					uow.record(agPayload, responses[idx])
+				}
+				return sink.flush(outErrFile, polyHandler, insertPreparator)
+			},
+		)
+	}
+	execErr := executor.Execute(ctx, tasks)
+	return aggregateProcessorResponses(responses, reversals, execErr), execErr
+}
+
+// withTaskContext binds req to the cancellation of a fanned out task's ctx,
+// so that cancelling the fan out reaches requests in flight, while keeping
+// the values of the request's own context.
+func withTaskContext(ctx context.Context, req *http.Request) *http.Request {
+	reqCtx, cancel := context.WithCancelCause(req.Context())
+	context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	return req.WithContext(reqCtx)
+}
+
+// peekReversals returns the reversals queued on a response, leaving its
+// stream as it was for the unit of work to drain.
+func peekReversals(resp ProcessorResponse) []anysdk.HTTPPreparator {
+	if resp == nil || resp.GetReversalStream() == nil {
+		return nil
+	}
+	var reversals []anysdk.HTTPPreparator
+	stream := resp.GetReversalStream()
+	for rev, ok := stream.Next(); ok; rev, ok = stream.Next() {
+		reversals = append(reversals, rev)
+	}
+	for _, rev := range reversals {
+		resp.AppendReversal(rev)
+	}
+	return reversals
+}
+
+// aggregateProcessorResponses folds the responses of a fan-out, in request
+// order, into one: every reversal is kept, the first API error and failure
+// message win, and the body is that of the last response, as it is for the
+// sequential loop.
+func aggregateProcessorResponses(
+	responses []ProcessorResponse,
+	reversals [][]anysdk.HTTPPreparator,
+	err error,
+) ProcessorResponse {
+	aggregate := &httpProcessorResponse{
+		err:            err,
+		reversalStream: anysdk.NewHttpPreparatorStream(),
+	}
+	isEmpty := true
+	for i, r := range responses {
+		if r == nil {
+			continue
+		}
+		isEmpty = false
+		aggregate.body = r.GetSingletonBody()
+		aggregate.successMessages = append(aggregate.successMessages, r.GetSuccessMessages()...)
+		if apiError, isAPIError := r.GetAPIError(); isAPIError && aggregate.apiError == nil {
+			aggregate.apiError = apiError
+		}
+		if r.IsFailed() && !aggregate.isFailed {
+			aggregate.isFailed = true
+			aggregate.failedMessage = r.GetFailedMessage()
+		}
+		for _, rev := range reversals[i] {
+			aggregate.AppendReversal(rev)
+		}
+	}
+	if isEmpty && err == nil {
+		return nil
+	}
+	return aggregate
+}
@@ -0,0 +1,133 @@
+package anysdkhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/apierror"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/providerinvoker"
+
+	"gotest.tools/assert"
+)
+
+type recordingInsertPreparator struct {
+	housekeepingSeen []bool
+	tableNames       []string
+	batches          [][]interface{}
+}
+
+func (rp *recordingInsertPreparator) ActionInsertPreparation(
+	payload providerinvoker.ActionInsertPayload,
+) providerinvoker.ActionInsertResult {
+	rp.housekeepingSeen = append(rp.housekeepingSeen, payload.IsHousekeepingDone())
+	rp.tableNames = append(rp.tableNames, payload.GetTableName())
+	if itemisationResult := payload.GetItemisationResult(); itemisationResult != nil {
+		if items, ok := itemisationResult.GetItems(); ok {
+			rp.batches = append(rp.batches, items.([]interface{}))
+		}
+	}
+	return &actionInsertResult{isHousekeepingDone: true}
+}
+
+// TestBufferedInsertPreparator_ReplayThreadsHousekeeping: the buffered
+// preparator always claims housekeeping is done to the processor, but the
+// replay must present the real preparator with the same sequence a
+// sequential processor would have, ie: false on the first page only.
+func TestBufferedInsertPreparator_ReplayThreadsHousekeeping(t *testing.T) {
+	buffered := &bufferedInsertPreparator{}
+	for _, tbl := range []string{"page_1", "page_2", "page_3"} {
+		res := buffered.ActionInsertPreparation(
+			newHTTPActionInsertPayload(nil, false, tbl, nil, ""),
+		)
+		assert.Equal(t, res.IsHousekeepingDone(), true)
+	}
+	target := &recordingInsertPreparator{}
+	err := buffered.replay(target)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, target.housekeepingSeen, []bool{false, true, true})
+	assert.DeepEqual(t, target.tableNames, []string{"page_1", "page_2", "page_3"})
+}
+
+func TestIsConcurrentExecution(t *testing.T) {
+	assert.Equal(t, isConcurrentExecution(dto.RuntimeCtx{}, 5), false)
+	assert.Equal(t, isConcurrentExecution(dto.RuntimeCtx{ExecutionConcurrencyLimit: 1}, 5), false)
+	assert.Equal(t, isConcurrentExecution(dto.RuntimeCtx{ExecutionConcurrencyLimit: 4}, 1), false)
+	assert.Equal(t, isConcurrentExecution(dto.RuntimeCtx{ExecutionConcurrencyLimit: 4}, 5), true)
+	assert.Equal(t, isConcurrentExecution(dto.RuntimeCtx{ExecutionConcurrencyLimit: -1}, 5), true)
+}
+
+type namedReversal struct {
+	anysdk.HTTPPreparator
+	name string
+}
+
+func newReversedResponse(body map[string]interface{}, names ...string) ProcessorResponse {
+	resp := newHTTPProcessorResponse(body, anysdk.NewHttpPreparatorStream(), false, nil)
+	for _, name := range names {
+		resp.AppendReversal(namedReversal{name: name})
+	}
+	return resp
+}
+
+func drainReversalNames(stream anysdk.HttpPreparatorStream) []string {
+	var names []string
+	for rev, ok := stream.Next(); ok; rev, ok = stream.Next() {
+		names = append(names, rev.(namedReversal).name)
+	}
+	return names
+}
+
+// TestAggregateProcessorResponses_KeepsEveryResponse: the fan-out result
+// carries the reversals of every request and the first API error, not just
+// the last response, and peeking at reversals leaves them for the unit of
+// work.
+func TestAggregateProcessorResponses_KeepsEveryResponse(t *testing.T) {
+	first := newReversedResponse(map[string]interface{}{"id": "a"}, "a1", "a2")
+	second := newReversedResponse(map[string]interface{}{"id": "b"}).
+		WithAPIError(&apierror.APIError{StatusCode: 409, Code: "CONFLICT"})
+	third := newReversedResponse(map[string]interface{}{"id": "c"}, "c1")
+	third.(*httpProcessorResponse).isFailed = true
+	third.(*httpProcessorResponse).failedMessage = "third failed"
+	responses := []ProcessorResponse{first, nil, second, third}
+	reversals := make([][]anysdk.HTTPPreparator, len(responses))
+	for i, r := range responses {
+		reversals[i] = peekReversals(r)
+	}
+	assert.DeepEqual(t, drainReversalNames(first.GetReversalStream()), []string{"a1", "a2"})
+
+	execErr := errors.New("request 4 failed")
+	agg := aggregateProcessorResponses(responses, reversals, execErr)
+	assert.Equal(t, agg.GetError(), execErr)
+	assert.DeepEqual(t, drainReversalNames(agg.GetReversalStream()), []string{"a1", "a2", "c1"})
+	apiError, isAPIError := agg.GetAPIError()
+	assert.Assert(t, isAPIError)
+	assert.Equal(t, apiError.Code, "CONFLICT")
+	assert.Assert(t, agg.IsFailed())
+	assert.Equal(t, agg.GetFailedMessage(), "third failed")
+	assert.DeepEqual(t, agg.GetSingletonBody(), map[string]interface{}{"id": "c"})
+
+	assert.Assert(t, aggregateProcessorResponses(make([]ProcessorResponse, 2), make([][]anysdk.HTTPPreparator, 2), nil) == nil)
+}
+
+type fanoutTestKey struct{}
+
+func TestWithTaskContext_CancelsAndKeepsValues(t *testing.T) {
+	req, err := http.NewRequestWithContext(
+		context.WithValue(context.Background(), fanoutTestKey{}, "kept"),
+		http.MethodGet,
+		"https://example.com/items",
+		nil,
+	)
+	assert.NilError(t, err)
+	taskCtx, cancel := context.WithCancel(context.Background())
+	bound := withTaskContext(taskCtx, req)
+	assert.Equal(t, bound.Context().Value(fanoutTestKey{}), "kept")
+	assert.NilError(t, bound.Context().Err())
+	cancel()
+	<-bound.Context().Done()
+	assert.Assert(t, errors.Is(bound.Context().Err(), context.Canceled))
+}
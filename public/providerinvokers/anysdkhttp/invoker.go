@@ -1,18 +1,25 @@
 package anysdkhttp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/apierror"
 	"github.com/stackql/any-sdk/pkg/client"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/httpelement"
 	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/any-sdk/pkg/paginator"
 	"github.com/stackql/any-sdk/pkg/response"
+	"github.com/stackql/any-sdk/pkg/telemetry"
 
 	sdk_internal_dto "github.com/stackql/any-sdk/pkg/internaldto"
 	"github.com/stackql/any-sdk/pkg/providerinvoker"
@@ -67,6 +74,14 @@ type actionInsertResult struct {
 	isHousekeepingDone bool
 }
 
+func (r *actionInsertResult) GetError() (error, bool) {
+	return r.err, r.err != nil
+}
+
+func (r *actionInsertResult) IsHousekeepingDone() bool {
+	return r.isHousekeepingDone
+}
+
 type ArmouryGenerator interface {
 	GetHTTPArmoury() (anysdk.HTTPArmoury, error)
 }
@@ -134,6 +149,7 @@ type standardPayload struct {
 	IsAwait           bool
 	DefaultHTTPClient *http.Client
 	messageHandler    providerinvoker.MessageHandler
+	unitOfWork        UnitOfWork
 }
 
 type Invoker struct{}
@@ -169,9 +185,16 @@ func (i *Invoker) Invoke(ctx context.Context, req providerinvoker.Request) (prov
 		p.IsMutation,
 		p.IsAwait,
 		p.DefaultHTTPClient,
+		p.unitOfWork,
 	)
 
-	processorResponse, err := agnosticate(agPayload)
+	processorResponse, err := agnosticate(ctx, agPayload)
+	if err != nil && p.unitOfWork != nil {
+		// Undo what the unit of work has done so far, even if the failure
+		// was the caller cancelling.
+		report := p.unitOfWork.Rollback(context.WithoutCancel(ctx))
+		return providerinvoker.Result{Messages: report.Messages()}, &RollbackError{Err: err, Report: report}
+	}
 	if err != nil {
 		return providerinvoker.Result{}, err
 	}
@@ -210,6 +233,7 @@ type AgnosticatePayload interface {
 	IsMutation() bool
 	IsAwait() bool
 	GetDefaultHTTPClient() *http.Client // testing purposes only
+	GetUnitOfWork() (UnitOfWork, bool)
 }
 
 type httpAgnosticatePayload struct {
@@ -230,6 +254,7 @@ type httpAgnosticatePayload struct {
 	isMutation              bool
 	isAwait                 bool
 	defaultHTTPClient       *http.Client // testing purposes only
+	unitOfWork              UnitOfWork
 }
 
 func newHTTPAgnosticatePayload(
@@ -250,6 +275,7 @@ func newHTTPAgnosticatePayload(
 	isMutation bool,
 	isAwait bool,
 	defaultHTTPClient *http.Client,
+	unitOfWork UnitOfWork,
 ) AgnosticatePayload {
 	return &httpAgnosticatePayload{
 		armouryGenerator:        armouryGenerator,
@@ -269,9 +295,14 @@ func newHTTPAgnosticatePayload(
 		isMutation:              isMutation,
 		isAwait:                 isAwait,
 		defaultHTTPClient:       defaultHTTPClient,
+		unitOfWork:              unitOfWork,
 	}
 }
 
+func (ap *httpAgnosticatePayload) GetUnitOfWork() (UnitOfWork, bool) {
+	return ap.unitOfWork, ap.unitOfWork != nil
+}
+
 func (ap *httpAgnosticatePayload) GetPolyHandler() PolyHandler {
 	return ap.polyHandler
 }
@@ -371,6 +402,7 @@ func NewStandardPolyHandler(messageHandler providerinvoker.MessageHandler) PolyH
 }
 
 func agnosticate(
+	ctx context.Context,
 	agPayload AgnosticatePayload,
 ) (ProcessorResponse, error) {
 	outErrFile := agPayload.GetOutErrFile()
@@ -419,6 +451,9 @@ func agnosticate(
 	}
 	reqParams := armoury.GetRequestParams()
 	logging.GetLogger().Infof("monoValentExecution.Execute() req param count = %d", len(reqParams))
+	if isConcurrentExecution(runtimeCtx, len(reqParams)) {
+		return agnosticateConcurrently(ctx, agPayload, reqParams)
+	}
 	var processorResponse ProcessorResponse
 	for _, rc := range reqParams {
 		rq := rc
@@ -445,6 +480,9 @@ func agnosticate(
 			),
 		)
 		processorResponse = processor.Process()
+		if uow, hasUnitOfWork := agPayload.GetUnitOfWork(); hasUnitOfWork {
+			uow.record(agPayload, processorResponse)
+		}
 		if processorResponse != nil && processorResponse.GetError() != nil {
 			return processorResponse, processorResponse.GetError()
 		}
@@ -616,6 +654,11 @@ type ProcessorResponse interface {
 	GetSingletonBody() map[string]interface{}
 	WithSuccessMessages([]string) ProcessorResponse
 	GetSuccessMessages() []string
+	WithAPIError(*apierror.APIError) ProcessorResponse
+	// GetAPIError returns the normalised error of a failed API call, for
+	// callers to branch on its code. Such failures are also reported
+	// through GetError or the PolyHandler.
+	GetAPIError() (*apierror.APIError, bool)
 	AppendReversal(rev anysdk.HTTPPreparator)
 	GetReversalStream() anysdk.HttpPreparatorStream
 	IsFailed() bool
@@ -629,6 +672,7 @@ type httpProcessorResponse struct {
 	reversalStream  anysdk.HttpPreparatorStream
 	isFailed        bool
 	failedMessage   string
+	apiError        *apierror.APIError
 }
 
 func (hpr *httpProcessorResponse) IsFailed() bool {
@@ -657,6 +701,15 @@ func (hpr *httpProcessorResponse) GetSuccessMessages() []string {
 	return hpr.successMessages
 }
 
+func (hpr *httpProcessorResponse) WithAPIError(apiError *apierror.APIError) ProcessorResponse {
+	hpr.apiError = apiError
+	return hpr
+}
+
+func (hpr *httpProcessorResponse) GetAPIError() (*apierror.APIError, bool) {
+	return hpr.apiError, hpr.apiError != nil
+}
+
 func (hpr *httpProcessorResponse) GetError() error {
 	return hpr.err
 }
@@ -695,7 +748,7 @@ func NewProcessor(payload ProcessorPayload) Processor {
 	}
 }
 
-func inferNextPageRequestElement(provider anysdk.Provider, method anysdk.OperationStore) sdk_internal_dto.HTTPElement {
+func inferNextPageRequestElement(method anysdk.OperationStore) sdk_internal_dto.HTTPElement {
 	st, ok := method.GetPaginationRequestTokenSemantic()
 	if ok {
 		if tp, err := sdk_internal_dto.ExtractHTTPElement(st.GetLocation()); err == nil {
@@ -710,19 +763,16 @@ func inferNextPageRequestElement(provider anysdk.Provider, method anysdk.Operati
 			return rv
 		}
 	}
-	providerStr := provider.GetName()
-	switch providerStr {
-	case "github", "okta":
+	if anysdk.ResolvePaginationAlgorithm(method) == anysdk.PaginationAlgorithmLinkHeader {
 		return sdk_internal_dto.NewHTTPElement(
 			sdk_internal_dto.RequestString,
 			"",
 		)
-	default:
-		return sdk_internal_dto.NewHTTPElement(
-			sdk_internal_dto.QueryParam,
-			"pageToken",
-		)
 	}
+	return sdk_internal_dto.NewHTTPElement(
+		sdk_internal_dto.QueryParam,
+		"pageToken",
+	)
 }
 
 type PagingState interface {
@@ -772,6 +822,127 @@ func newPagingState(
 	}
 }
 
+// validateResponse checks a decoded response body against the method's
+// response schema, when the runtime context opts in. Violations are written
+// to outErrFile as warnings, or returned as one error.
+func validateResponse(
+	runtimeCtx dto.RuntimeCtx,
+	method anysdk.OperationStore,
+	res response.Response,
+	outErrFile io.Writer,
+) error {
+	isValidating, modeErr := isResponseValidating(runtimeCtx)
+	if !isValidating || modeErr != nil {
+		return modeErr
+	}
+	return reportResponseViolations(runtimeCtx, method, method.ValidateResponseBody(res.GetBody()), outErrFile)
+}
+
+// isResponseValidating reports whether the runtime context asks for
+// response validation, failing on an unknown mode.
+func isResponseValidating(runtimeCtx dto.RuntimeCtx) (bool, error) {
+	mode := runtimeCtx.ResponseValidation
+	if mode == "" || mode == anysdk.ResponseValidationOff {
+		return false, nil
+	}
+	if mode != anysdk.ResponseValidationWarn && mode != anysdk.ResponseValidationError {
+		return false, fmt.Errorf("unsupported response validation mode '%s'", mode)
+	}
+	return true, nil
+}
+
+// reportResponseViolations fails on violations in error mode, and writes
+// them to outErrFile in warn mode.
+func reportResponseViolations(
+	runtimeCtx dto.RuntimeCtx,
+	method anysdk.OperationStore,
+	violations []anysdk.ResponseViolation,
+	outErrFile io.Writer,
+) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.String()
+	}
+	if runtimeCtx.ResponseValidation == anysdk.ResponseValidationError {
+		return fmt.Errorf("response for method '%s' does not match its schema: %s", method.GetName(), strings.Join(msgs, "; "))
+	}
+	for _, msg := range msgs {
+		logging.GetLogger().Warnf("response for method '%s' does not match its schema: %s", method.GetName(), msg)
+		//nolint:errcheck // best effort, like other diagnostics
+		outErrFile.Write(
+			[]byte(fmt.Sprintf("response validation warning: method '%s': %s\n", method.GetName(), msg)),
+		)
+	}
+	return nil
+}
+
+// getErroneousProcessorResponse returns the processor response for a
+// response carrying an error, handing its message to the poly handler.
+// The bool is false when the response carries none.
+func getErroneousProcessorResponse(
+	res response.Response,
+	polyHandler PolyHandler,
+	reversalStream anysdk.HttpPreparatorStream,
+) (ProcessorResponse, bool) {
+	if !res.HasError() {
+		return nil, false
+	}
+	polyHandler.MessageHandler([]string{res.Error()})
+	if structuredErr, isStructured := res.GetAPIError(); isStructured {
+		return newHTTPProcessorResponse(nil, reversalStream, false, nil).WithAPIError(structuredErr), true
+	}
+	return newHTTPProcessorResponse(nil, reversalStream, false, nil), true
+}
+
+// awaitLongRunningOperation polls an operation declaring an lro policy to
+// completion, when the caller awaits it or the policy asks to wait, and
+// returns the response holding its result. Status and result requests are
+// sent through the same client with the operation's auth and host guards,
+// but as reads: see anysdk.NewLROPollDesignation.
+func awaitLongRunningOperation(
+	cc client.AnySdkClientConfigurator,
+	runtimeCtx dto.RuntimeCtx,
+	authCtx *dto.AuthCtx,
+	outErrFile io.Writer,
+	provider anysdk.Provider,
+	method anysdk.OperationStore,
+	argList client.AnySdkArgList,
+	httpResponse *http.Response,
+	isAwait bool,
+) (*http.Response, error) {
+	lroPolicy, hasLRO := method.GetLROPolicy()
+	if !hasLRO || !(isAwait || lroPolicy.IsWait()) || httpResponse == nil {
+		return httpResponse, nil
+	}
+	var initialReq *http.Request
+	if args := argList.GetArgs(); len(args) > 0 {
+		if arg, hasArg := args[0].GetArg(); hasArg {
+			initialReq, _ = arg.(*http.Request)
+		}
+	}
+	poller := anysdk.NewLROPoller(lroPolicy, func(req *http.Request) (*http.Response, error) {
+		pollResponse, pollErr := anysdk.CallFromSignature(
+			cc,
+			runtimeCtx,
+			authCtx,
+			authCtx.Type,
+			false,
+			outErrFile,
+			provider,
+			anysdk.NewLROPollDesignation(method),
+			anysdk.NewwHTTPAnySdkArgList(req),
+		)
+		if pollErr != nil {
+			return nil, pollErr
+		}
+		return pollResponse.GetHttpResponse()
+	})
+	return poller.Await(initialReq, httpResponse)
+}
+
 //nolint:funlen,bodyclose,gocognit,gocyclo,cyclop // acceptable for now
 func (sp *standardProcessor) Process() ProcessorResponse {
 	processorPayload := sp.payload
@@ -829,16 +1000,35 @@ func (sp *standardProcessor) Process() ProcessorResponse {
 		return newHTTPProcessorResponse(nil, reversalStream, false, nil)
 	}
 	httpResponse, httpResponseErr := response.GetHttpResponse()
+	if httpResponseErr == nil && apiErr == nil {
+		httpResponse, httpResponseErr = awaitLongRunningOperation(
+			cc,
+			runtimeCtx,
+			authCtx,
+			outErrFile,
+			provider,
+			method,
+			reqCtx.GetArgList(),
+			httpResponse,
+			isAwait,
+		)
+	}
 	if httpResponse != nil && httpResponse.Body != nil {
 		defer httpResponse.Body.Close()
 	}
 	if httpResponse != nil && httpResponse.StatusCode >= 400 && isMaterialiseResponse {
+		structuredErr, isStructured := method.ParseAPIError(httpResponse)
+		if isStructured {
+			generatedErr := fmt.Errorf("%s over HTTP error: %w", verb, structuredErr)
+			return newHTTPProcessorResponse(nil, reversalStream, true, generatedErr).WithAPIError(structuredErr)
+		}
 		generatedErr := fmt.Errorf("%s over HTTP error: %s", verb, httpResponse.Status)
 		return newHTTPProcessorResponse(nil, reversalStream, true, generatedErr)
 	}
 	// TODO: refactor into package !!TECH_DEBT!!
 	housekeepingDone := false
-	nptRequest := inferNextPageRequestElement(provider, method)
+	nptRequest := inferNextPageRequestElement(method)
+	cursor := paginator.NewCursor(newPagingConfig(method))
 	pageCount := 1
 	for {
 		if apiErr != nil {
@@ -847,7 +1037,51 @@ func (sp *standardProcessor) Process() ProcessorResponse {
 		if httpResponseErr != nil {
 			return newHTTPProcessorResponse(nil, reversalStream, false, httpResponseErr)
 		}
-		// TODO: add async monitor here
+		if !isMaterialiseResponse && !isReverseRequired && !isMutation {
+			if streamer, isStreamable := anysdk.NewResponseRowStreamer(method, httpResponse); isStreamable {
+				streamedRes, isStreamed, streamHousekeepingDone, streamErr := streamResponseRows(
+					streamer,
+					method,
+					httpResponse,
+					insertPreparator,
+					housekeepingDone,
+					tableName,
+					paramsUsed,
+					reqEncoding,
+					runtimeCtx,
+					outErrFile,
+				)
+				if streamErr != nil {
+					return newHTTPProcessorResponse(nil, reversalStream, false, streamErr)
+				}
+				housekeepingDone = streamHousekeepingDone
+				if isStreamed {
+					if erroneous, isErroneous := getErroneousProcessorResponse(streamedRes, polyHandler, reversalStream); isErroneous {
+						return erroneous
+					}
+					pageResult := page(
+						streamedRes,
+						streamer.GetCount(),
+						cursor,
+						method,
+						provider,
+						reqCtx,
+						pageCount,
+						runtimeCtx,
+						authCtx,
+						outErrFile,
+						sp.defaultHTTPClient,
+					)
+					httpResponse, httpResponseErr = pageResult.GetHTTPResponse()
+					if httpResponseErr != nil || pageResult.IsFinished() {
+						return newHTTPProcessorResponse(nil, reversalStream, false, nil)
+					}
+					pageCount = pageResult.GetPageCount()
+					apiErr = pageResult.GetAPIError()
+					continue
+				}
+			}
+		}
 		processed, resErr := method.ProcessResponse(httpResponse)
 		if resErr != nil {
 			if isSkipResponse && isMutation && httpResponse.StatusCode < 300 {
@@ -877,9 +1111,11 @@ func (sp *standardProcessor) Process() ProcessorResponse {
 		if !respOk {
 			return newHTTPProcessorResponse(nil, reversalStream, false, fmt.Errorf("response is not a valid response"))
 		}
-		if res.HasError() {
-			polyHandler.MessageHandler([]string{res.Error()})
-			return newHTTPProcessorResponse(nil, reversalStream, false, nil)
+		if erroneous, isErroneous := getErroneousProcessorResponse(res, polyHandler, reversalStream); isErroneous {
+			return erroneous
+		}
+		if validationErr := validateResponse(runtimeCtx, method, res, outErrFile); validationErr != nil {
+			return newHTTPProcessorResponse(nil, reversalStream, false, validationErr)
 		}
 		polyHandler.LogHTTPResponseMap(res.GetProcessedBody())
 		logging.GetLogger().Infoln(fmt.Sprintf("monoValentExecution.Execute() response = %v", res))
@@ -933,6 +1169,8 @@ func (sp *standardProcessor) Process() ProcessorResponse {
 
 		pageResult := page(
 			res,
+			countItems(itemisationResult),
+			cursor,
 			method,
 			provider,
 			reqCtx,
@@ -970,6 +1208,101 @@ func (sp *standardProcessor) Process() ProcessorResponse {
 	return newHTTPProcessorResponse(nil, reversalStream, false, nil)
 }
 
+// streamResponseRows reads the rows of a list response one at a time, from
+// the objectKey of a JSON body or record by record otherwise, and hands
+// them to the insert preparator in batches, so that the response is never
+// held in memory whole. Rows, then the rest of the document, are validated
+// as the buffered path validates the whole body. The returned response
+// carries the rest of the document, for paging and error detection. When the objectKey does not lead to
+// an array, nothing is inserted and the response body is replaced by the
+// decoded document, to be processed whole.
+func streamResponseRows(
+	streamer anysdk.ResponseRowStreamer,
+	method anysdk.OperationStore,
+	httpResponse *http.Response,
+	insertPreparator InsertPreparator,
+	housekeepingDone bool,
+	tableName string,
+	paramsUsed map[string]interface{},
+	reqEncoding string,
+	runtimeCtx dto.RuntimeCtx,
+	outErrFile io.Writer,
+) (response.Response, bool, bool, error) {
+	isValidating, modeErr := isResponseValidating(runtimeCtx)
+	if modeErr != nil {
+		return nil, false, housekeepingDone, modeErr
+	}
+	batchRows := anysdk.DefaultResponseStreamingBatchRows
+	if policy, hasPolicy := method.GetResponseStreamingPolicy(); hasPolicy {
+		batchRows = policy.GetBatchRows()
+	}
+	batch := make([]interface{}, 0, batchRows)
+	flushed := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if isValidating {
+			violations := anysdk.ValidateStreamedRows(method, batch, flushed)
+			if err := reportResponseViolations(runtimeCtx, method, violations, outErrFile); err != nil {
+				return err
+			}
+		}
+		flushed += len(batch)
+		result := insertPreparator.ActionInsertPreparation(
+			newHTTPActionInsertPayload(
+				newItemisationResult(batch, true, false, nil),
+				housekeepingDone,
+				tableName,
+				paramsUsed,
+				reqEncoding,
+			),
+		)
+		housekeepingDone = result.IsHousekeepingDone()
+		batch = make([]interface{}, 0, batchRows)
+		if err, hasErr := result.GetError(); hasErr {
+			return err
+		}
+		return nil
+	}
+	for {
+		row, err := streamer.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, housekeepingDone, err
+		}
+		batch = append(batch, row)
+		if len(batch) >= batchRows {
+			if flushErr := flush(); flushErr != nil {
+				return nil, false, housekeepingDone, flushErr
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, false, housekeepingDone, err
+	}
+	remainder := streamer.Remainder()
+	if !streamer.Found() {
+		b, err := json.Marshal(remainder)
+		if err != nil {
+			return nil, false, housekeepingDone, err
+		}
+		httpResponse.Body = io.NopCloser(bytes.NewReader(b))
+		httpResponse.ContentLength = int64(len(b))
+		return nil, false, housekeepingDone, nil
+	}
+	if isValidating {
+		violations := anysdk.ValidateStreamedRemainder(method, remainder)
+		if err := reportResponseViolations(runtimeCtx, method, violations, outErrFile); err != nil {
+			return nil, false, housekeepingDone, err
+		}
+	}
+	logging.GetLogger().Infoln(fmt.Sprintf("streamed %d rows from response", streamer.GetCount()))
+	return response.NewResponse(remainder, remainder, httpResponse), true, housekeepingDone, nil
+}
+
 func shallowGenerateSuccessMessagesFromHeirarchy(isAwait bool) []string {
 	baseSuccessString := "The operation completed successfully"
 	if !isAwait {
@@ -997,84 +1330,87 @@ func newHTTPActionInsertPayload(
 	}
 }
 
-func inferNextPageResponseElement(provider anysdk.Provider, method anysdk.OperationStore) sdk_internal_dto.HTTPElement {
-	st, ok := method.GetPaginationResponseTokenSemantic()
-	if ok {
-		if tp, err := sdk_internal_dto.ExtractHTTPElement(st.GetLocation()); err == nil {
-			rv := sdk_internal_dto.NewHTTPElement(
-				tp,
-				st.GetKey(),
-			)
-			transformer, tErr := st.GetTransformer()
-			if tErr == nil && transformer != nil {
-				rv.SetTransformer(transformer)
-			}
-			return rv
-		}
+// newPagingConfig maps the operation's pagination onto the paginator.
+// Undeclared pagination is a "nextPageToken" body token sent back as a
+// "pageToken" query parameter, or Link headers when the provider declares
+// link_header pagination.
+func newPagingConfig(method anysdk.OperationStore) paginator.Config {
+	if cfg, ok := anysdk.NewPaginatorConfig(method); ok {
+		return cfg
 	}
-	providerStr := provider.GetName()
-	switch providerStr {
-	case "github", "okta":
-		rv := sdk_internal_dto.NewHTTPElement(
-			sdk_internal_dto.Header,
-			"Link",
-		)
-		rv.SetTransformer(anysdk.DefaultLinkHeaderTransformer)
-		return rv
-	default:
-		return sdk_internal_dto.NewHTTPElement(
-			sdk_internal_dto.BodyAttribute,
-			"nextPageToken",
-		)
+	if anysdk.ResolvePaginationAlgorithm(method) == anysdk.PaginationAlgorithmLinkHeader {
+		return paginator.Config{Style: paginator.StyleLink}
+	}
+	return paginator.Config{
+		Style:                 paginator.StyleToken,
+		ResponseTokenLocation: paginator.LocationBody,
+		ResponseToken:         "nextPageToken",
+		RequestTokenLocation:  paginator.LocationQuery,
+		RequestToken:          "pageToken",
 	}
 }
 
-func inferPageNumberTerminatorElement(method anysdk.OperationStore) sdk_internal_dto.HTTPElement {
-	st, ok := method.GetPaginationResponseTerminatorTokenSemantic()
-	if !ok {
-		return nil
-	}
-	tp, err := sdk_internal_dto.ExtractHTTPElement(st.GetLocation())
-	if err != nil {
-		return nil
+// pageDocument serves the paginator's body paths from a processed
+// response, whose raw body may be XML.
+type pageDocument struct {
+	res response.Response
+}
+
+func (pd *pageDocument) Lookup(path string) (interface{}, bool) {
+	key := strings.TrimPrefix(path, "$.")
+	if elem, err := httpelement.NewHTTPElement(key, "body"); err == nil {
+		if v, extractErr := pd.res.ExtractElement(elem); extractErr == nil && v != nil {
+			return v, true
+		}
 	}
-	rv := sdk_internal_dto.NewHTTPElement(tp, st.GetKey())
-	transformer, tErr := st.GetTransformer()
-	if tErr == nil && transformer != nil {
-		rv.SetTransformer(transformer)
+	m, isMap := pd.res.GetProcessedBody().(map[string]interface{})
+	if !isMap {
+		return nil, false
 	}
-	return rv
+	v, ok := m[key]
+	return v, ok
 }
 
-// extractPageNumberNextToken implements the `page_number` pagination algorithm:
-// it reads the current page number and the page-count terminator from the
-// response, and returns the next page number to request. When the current
-// page is the last (>= total) or either value is missing/unparseable,
-// `finished` is true so the loop terminates rather than spinning forever
-// on a response that echoes the same page number back.
-func extractPageNumberNextToken(
+// nextPageToken returns what locates the page after res: the token, page
+// number or URL to send. The bool is false after the last page.
+//
+//nolint:bodyclose // the response body belongs to the caller
+func nextPageToken(
+	cursor paginator.Cursor,
 	res response.Response,
-	currentPageElem sdk_internal_dto.HTTPElement,
-	totalPagesElem sdk_internal_dto.HTTPElement,
-) (string, bool) {
-	if currentPageElem == nil || totalPagesElem == nil {
-		return "", true
+	rowCount int,
+	reqCtx anysdk.HTTPArmouryParameters,
+) (string, bool, error) {
+	httpResponse := res.GetHttpResponse()
+	if httpResponse == nil {
+		return "", false, nil
 	}
-	currStr := extractNextPageToken(res, currentPageElem)
-	totalStr := extractNextPageToken(res, totalPagesElem)
-	curr, currErr := strconv.Atoi(currStr)
-	total, totalErr := strconv.Atoi(totalStr)
-	if currErr != nil || totalErr != nil {
-		return "", true
+	req := httpResponse.Request
+	if req == nil {
+		req = reqCtx.GetRequest()
 	}
-	if curr >= total {
-		return "", true
+	if req == nil {
+		return "", false, nil
+	}
+	return cursor.Advance(req, httpResponse, &pageDocument{res: res}, rowCount)
+}
+
+func countItems(itemisationResult providerinvoker.ItemisationResult) int {
+	items, _ := itemisationResult.GetItems()
+	switch t := items.(type) {
+	case []interface{}:
+		return len(t)
+	case []map[string]interface{}:
+		return len(t)
+	default:
+		return 0
 	}
-	return strconv.Itoa(curr + 1), false
 }
 
 func page(
 	res response.Response,
+	rowCount int,
+	cursor paginator.Cursor,
 	method anysdk.OperationStore,
 	provider anysdk.Provider,
 	reqCtx anysdk.HTTPArmouryParameters,
@@ -1084,23 +1420,15 @@ func page(
 	outErrFile io.Writer,
 	defaultHTTPClient *http.Client,
 ) PagingState {
-	npt := inferNextPageResponseElement(provider, method)
-	nptRequest := inferNextPageRequestElement(provider, method)
-	if npt == nil || nptRequest == nil {
+	nptRequest := inferNextPageRequestElement(method)
+	if rtCtx.HTTPPageLimit > 0 && pageCount >= rtCtx.HTTPPageLimit {
 		return newPagingState(pageCount, true, nil, nil)
 	}
-	var tk string
-	if method.GetPaginationAlgorithm() == anysdk.PaginationAlgorithmPageNumber {
-		terminator := inferPageNumberTerminatorElement(method)
-		next, finished := extractPageNumberNextToken(res, npt, terminator)
-		if finished {
-			return newPagingState(pageCount, true, nil, nil)
-		}
-		tk = next
-	} else {
-		tk = extractNextPageToken(res, npt)
+	tk, hasNext, tkErr := nextPageToken(cursor, res, rowCount, reqCtx)
+	if tkErr != nil {
+		return newPagingState(pageCount, true, nil, tkErr)
 	}
-	if tk == "" || tk == "<nil>" || tk == "[]" || (rtCtx.HTTPPageLimit > 0 && pageCount >= rtCtx.HTTPPageLimit) {
+	if !hasNext {
 		return newPagingState(pageCount, true, nil, nil)
 	}
 	pageCount++
@@ -1108,12 +1436,23 @@ func page(
 	if reqErr != nil {
 		return newPagingState(pageCount, true, nil, reqErr)
 	}
+	ctx, pageSpan := telemetry.StartSpan(
+		req.Context(),
+		telemetry.SpanPage,
+		telemetry.Int(telemetry.AttrPage, pageCount),
+		telemetry.String(telemetry.AttrProvider, provider.GetName()),
+		telemetry.String(telemetry.AttrOperation, method.GetName()),
+	)
+	if ctx != req.Context() {
+		req = req.WithContext(ctx)
+	}
 	cc := anysdk.NewAnySdkClientConfigurator(rtCtx, provider.GetName(), defaultHTTPClient)
 	response, apiErr := anysdk.CallFromSignature(
 		cc, rtCtx, authCtx, authCtx.Type, false, outErrFile, provider,
 		anysdk.NewAnySdkOpStoreDesignation(method),
 		anysdk.NewwHTTPAnySdkArgList(req), // TODO: abstract
 	)
+	telemetry.EndSpan(pageSpan, apiErr)
 	return newPagingState(pageCount, false, response, apiErr)
 }
 
@@ -1189,73 +1528,3 @@ func itemise(
 	}
 	return newItemisationResult(items, ok, false, singletonResponse)
 }
-
-func extractNextPageToken(res response.Response, tokenKey sdk_internal_dto.HTTPElement) string {
-	//nolint:exhaustive // TODO: review
-	switch tokenKey.GetType() {
-	case sdk_internal_dto.BodyAttribute:
-		return extractNextPageTokenFromBody(res, tokenKey)
-	case sdk_internal_dto.Header:
-		return extractNextPageTokenFromHeader(res, tokenKey)
-	}
-	return ""
-}
-
-//nolint:bodyclose // acceptable for now
-func extractNextPageTokenFromHeader(res response.Response, tokenKey sdk_internal_dto.HTTPElement) string {
-	r := res.GetHttpResponse()
-	if r == nil {
-		return ""
-	}
-	header := r.Header
-	if tokenKey.IsTransformerPresent() {
-		tf, err := tokenKey.Transformer(header)
-		if err != nil {
-			return ""
-		}
-		rv, ok := tf.(string)
-		if !ok {
-			return ""
-		}
-		return rv
-	}
-	vals := header.Values(tokenKey.GetName())
-	if len(vals) == 1 {
-		return vals[0]
-	}
-	return ""
-}
-
-func extractNextPageTokenFromBody(res response.Response, tokenKey sdk_internal_dto.HTTPElement) string {
-	elem, err := httpelement.NewHTTPElement(tokenKey.GetName(), "body")
-	if err == nil {
-		rawVal, rawErr := res.ExtractElement(elem)
-		if rawErr == nil {
-			switch v := rawVal.(type) {
-			case []interface{}:
-				if len(v) == 1 {
-					return fmt.Sprintf("%v", v[0])
-				}
-			default:
-				return fmt.Sprintf("%v", v)
-			}
-		}
-	}
-	body := res.GetProcessedBody()
-	switch target := body.(type) { //nolint:gocritic // TODO: review
-	case map[string]interface{}:
-		tokenName := tokenKey.GetName()
-		nextPageToken, ok := target[tokenName]
-		if !ok || nextPageToken == "" {
-			logging.GetLogger().Infoln("breaking out")
-			return ""
-		}
-		tk, ok := nextPageToken.(string)
-		if !ok {
-			logging.GetLogger().Infoln("breaking out")
-			return ""
-		}
-		return tk
-	}
-	return ""
-}
@@ -1,10 +1,19 @@
 package anysdkhttp
 
 import (
+	"bytes"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/dto"
 	sdk_internal_dto "github.com/stackql/any-sdk/pkg/internaldto"
+	"github.com/stackql/any-sdk/pkg/jsonstream"
+	"github.com/stackql/any-sdk/pkg/paginator"
 	"github.com/stackql/any-sdk/pkg/response"
 
 	"gotest.tools/assert"
@@ -18,84 +27,249 @@ func makeBodyResponse(body map[string]interface{}) response.Response {
 	return response.NewResponse(body, body, httpResp)
 }
 
-// TestExtractPageNumberNextToken_NotLast covers the common case: current page
+func pageNumberCursor() paginator.Cursor {
+	return paginator.NewCursor(paginator.Config{
+		Style:           paginator.StylePageNumber,
+		PageParam:       "page",
+		CurrentPagePath: "$.page",
+		TotalPagesPath:  "$.total_pages",
+	})
+}
+
+func nextPageOf(t *testing.T, cursor paginator.Cursor, res response.Response, rowCount int) (string, bool, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/zones", nil)
+	assert.NilError(t, err)
+	reqCtx := anysdk.NewHTTPArmouryParameters()
+	reqCtx.SetRequest(req)
+	return nextPageToken(cursor, res, rowCount, reqCtx)
+}
+
+// TestNextPageToken_PageNumberNotLast covers the common case: current page
 // is below the terminator, so the next page (current+1) must be requested.
-func TestExtractPageNumberNextToken_NotLast(t *testing.T) {
+func TestNextPageToken_PageNumberNotLast(t *testing.T) {
 	body := map[string]interface{}{
 		"page":        float64(1),
 		"total_pages": float64(3),
 	}
-	res := makeBodyResponse(body)
-	curr := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.page")
-	total := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.total_pages")
-
-	next, finished := extractPageNumberNextToken(res, curr, total)
-	assert.Equal(t, finished, false)
+	next, hasNext, err := nextPageOf(t, pageNumberCursor(), makeBodyResponse(body), 1)
+	assert.NilError(t, err)
+	assert.Equal(t, hasNext, true)
 	assert.Equal(t, next, "2")
 }
 
-// TestExtractPageNumberNextToken_LastPage covers Cloudflare's actual failure
+// TestNextPageToken_PageNumberLastPage covers Cloudflare's actual failure
 // mode from issue #91: when page == total_pages the loop must terminate.
 // Without the page_number algorithm, stackql instead re-requested page=1
 // indefinitely because the response token never went empty.
-func TestExtractPageNumberNextToken_LastPage(t *testing.T) {
+func TestNextPageToken_PageNumberLastPage(t *testing.T) {
 	body := map[string]interface{}{
 		"page":        float64(3),
 		"total_pages": float64(3),
 	}
-	res := makeBodyResponse(body)
-	curr := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.page")
-	total := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.total_pages")
-
-	next, finished := extractPageNumberNextToken(res, curr, total)
-	assert.Equal(t, finished, true)
+	next, hasNext, err := nextPageOf(t, pageNumberCursor(), makeBodyResponse(body), 1)
+	assert.NilError(t, err)
+	assert.Equal(t, hasNext, false)
 	assert.Equal(t, next, "")
 }
 
-// TestExtractPageNumberNextToken_SinglePage covers the single-row Cloudflare
+// TestNextPageToken_PageNumberSinglePage covers the single-row Cloudflare
 // case in the issue reproducer (page==1, total_pages==1).
-func TestExtractPageNumberNextToken_SinglePage(t *testing.T) {
+func TestNextPageToken_PageNumberSinglePage(t *testing.T) {
 	body := map[string]interface{}{
 		"page":        float64(1),
 		"total_pages": float64(1),
 	}
-	res := makeBodyResponse(body)
-	curr := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.page")
-	total := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.total_pages")
-
-	next, finished := extractPageNumberNextToken(res, curr, total)
-	assert.Equal(t, finished, true)
-	assert.Equal(t, next, "")
+	_, hasNext, err := nextPageOf(t, pageNumberCursor(), makeBodyResponse(body), 1)
+	assert.NilError(t, err)
+	assert.Equal(t, hasNext, false)
 }
 
-// TestExtractPageNumberNextToken_MissingTerminator: if the terminator element
-// is nil (misconfigured yaml) we must terminate rather than loop forever.
-// Strictly safer than today's behaviour.
-func TestExtractPageNumberNextToken_MissingTerminator(t *testing.T) {
+// TestNextPageToken_PageNumberMissingTerminator: if the terminator element
+// is not declared (misconfigured yaml) we must terminate rather than loop
+// forever. Strictly safer than today's behaviour.
+func TestNextPageToken_PageNumberMissingTerminator(t *testing.T) {
 	body := map[string]interface{}{
 		"page":        float64(1),
 		"total_pages": float64(3),
 	}
-	res := makeBodyResponse(body)
-	curr := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.page")
-
-	next, finished := extractPageNumberNextToken(res, curr, nil)
-	assert.Equal(t, finished, true)
+	cursor := paginator.NewCursor(paginator.Config{
+		Style:           paginator.StylePageNumber,
+		PageParam:       "page",
+		CurrentPagePath: "$.page",
+	})
+	next, hasNext, err := nextPageOf(t, cursor, makeBodyResponse(body), 1)
+	assert.NilError(t, err)
+	assert.Equal(t, hasNext, false)
 	assert.Equal(t, next, "")
 }
 
-// TestExtractPageNumberNextToken_Unparseable: if either field is missing or
+// TestNextPageToken_PageNumberUnparseable: if either field is missing or
 // non-numeric, we terminate rather than spin.
-func TestExtractPageNumberNextToken_Unparseable(t *testing.T) {
+func TestNextPageToken_PageNumberUnparseable(t *testing.T) {
 	body := map[string]interface{}{
 		"page":        "notanumber",
 		"total_pages": float64(3),
 	}
-	res := makeBodyResponse(body)
-	curr := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.page")
-	total := sdk_internal_dto.NewHTTPElement(sdk_internal_dto.BodyAttribute, "$.total_pages")
-
-	next, finished := extractPageNumberNextToken(res, curr, total)
-	assert.Equal(t, finished, true)
+	next, hasNext, err := nextPageOf(t, pageNumberCursor(), makeBodyResponse(body), 1)
+	assert.NilError(t, err)
+	assert.Equal(t, hasNext, false)
 	assert.Equal(t, next, "")
 }
+
+const linkHeaderTestService = `openapi: 3.0.0
+info:
+  title: items
+  version: v1
+servers:
+  - url: https://example.com
+paths:
+  /items:
+    get:
+      operationId: items.list
+      responses:
+        '200':
+          description: ok
+components:
+  x-stackQL-resources:
+    items:
+      id: test.items.items
+      methods:
+        list:
+          operation:
+            $ref: '#/paths/~1items/get'
+          response:
+            openAPIDocKey: '200'
+      sqlVerbs:
+        select:
+          - $ref: '#/components/x-stackQL-resources/items/methods/list'
+`
+
+// mustLoadOperation loads the list method of a one method service under a
+// provider named name with the given config block.
+func mustLoadOperation(t *testing.T, name string, config string) anysdk.OperationStore {
+	t.Helper()
+	dir := t.TempDir()
+	provPath := filepath.Join(dir, "provider.yaml")
+	svcPath := filepath.Join(dir, "items.yaml")
+	provDoc := "id: " + name + "\nname: " + name + "\nversion: v1\n" + config
+	assert.NilError(t, os.WriteFile(provPath, []byte(provDoc), 0o600))
+	assert.NilError(t, os.WriteFile(svcPath, []byte(linkHeaderTestService), 0o600))
+	svc, err := anysdk.LoadProviderAndServiceFromPaths(provPath, svcPath)
+	assert.NilError(t, err)
+	rsc, err := svc.GetResource("items")
+	assert.NilError(t, err)
+	op, err := rsc.FindMethod("list")
+	assert.NilError(t, err)
+	return op
+}
+
+// TestLinkHeaderPagination_Declared proves link_header pagination comes
+// from the provider document, not from the provider's name.
+func TestLinkHeaderPagination_Declared(t *testing.T) {
+	op := mustLoadOperation(t, "my-scm", "config:\n  pagination:\n    algorithm: link_header\n")
+	req := inferNextPageRequestElement(op)
+	assert.Equal(t, req.GetType(), sdk_internal_dto.RequestString)
+	assert.Equal(t, req.GetName(), "")
+	assert.Equal(t, newPagingConfig(op).Style, paginator.StyleLink)
+	httpResp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Link": {`<https://example.com/items?page=2>; rel="next"`}},
+	}
+	next, hasNext, err := nextPageOf(t, paginator.NewCursor(newPagingConfig(op)), response.NewResponse(nil, nil, httpResp), 1)
+	assert.NilError(t, err)
+	assert.Assert(t, hasNext)
+	assert.Equal(t, next, "https://example.com/items?page=2")
+
+	for _, name := range []string{"github", "okta", "other"} {
+		undeclared := mustLoadOperation(t, name, "")
+		assert.Equal(t, inferNextPageRequestElement(undeclared).GetName(), "pageToken")
+		assert.Equal(t, newPagingConfig(undeclared).ResponseToken, "nextPageToken")
+	}
+}
+
+type singleRowStreamingPolicy struct{}
+
+func (singleRowStreamingPolicy) IsEnabled() bool            { return true }
+func (singleRowStreamingPolicy) GetBatchRows() int          { return 1 }
+func (singleRowStreamingPolicy) GetMaxResponseBytes() int64 { return 0 }
+
+type singleRowStreamingOperationStore struct {
+	anysdk.OperationStore
+}
+
+func (op *singleRowStreamingOperationStore) GetResponseStreamingPolicy() (anysdk.ResponseStreamingPolicy, bool) {
+	return singleRowStreamingPolicy{}, true
+}
+
+// TestStreamResponseRows_BatchesRowsAndKeepsPagingTokens proves rows reach
+// the insert preparator incrementally and the paging token that follows the
+// streamed array is still available.
+func TestStreamResponseRows_BatchesRowsAndKeepsPagingTokens(t *testing.T) {
+	op := &singleRowStreamingOperationStore{OperationStore: anysdk.NewEmptyOperationStore()}
+	httpResp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	streamer, err := jsonstream.NewStreamer(
+		strings.NewReader(`{"items":[{"id":1},{"id":2},{"id":3}],"nextPageToken":"p2"}`), "$.items")
+	assert.NilError(t, err)
+	prep := &recordingInsertPreparator{}
+	res, isStreamed, housekeepingDone, err := streamResponseRows(
+		streamer, op, httpResp, prep, false, "tbl", nil, "", dto.RuntimeCtx{}, io.Discard)
+	assert.NilError(t, err)
+	assert.Assert(t, isStreamed)
+	assert.Assert(t, housekeepingDone)
+	assert.Equal(t, len(prep.batches), 3)
+	assert.DeepEqual(t, prep.housekeepingSeen, []bool{false, true, true})
+	next, hasNext, err := nextPageOf(t, paginator.NewCursor(newPagingConfig(op)), res, 3)
+	assert.NilError(t, err)
+	assert.Assert(t, hasNext)
+	assert.Equal(t, next, "p2")
+}
+
+func TestStreamResponseRows_FallsBackForSingletons(t *testing.T) {
+	op := anysdk.NewEmptyOperationStore()
+	httpResp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	streamer, _ := jsonstream.NewStreamer(strings.NewReader(`{"id":1,"name":"a"}`), "$.items")
+	prep := &recordingInsertPreparator{}
+	_, isStreamed, _, err := streamResponseRows(streamer, op, httpResp, prep, false, "tbl", nil, "", dto.RuntimeCtx{}, io.Discard)
+	assert.NilError(t, err)
+	assert.Assert(t, !isStreamed)
+	assert.Equal(t, len(prep.batches), 0)
+	b, _ := io.ReadAll(httpResp.Body)
+	assert.Equal(t, string(b), `{"id":1,"name":"a"}`)
+}
+
+type violatingOperationStore struct {
+	anysdk.OperationStore
+	violations []anysdk.ResponseViolation
+}
+
+func (op *violatingOperationStore) GetName() string {
+	return "list"
+}
+
+func (op *violatingOperationStore) ValidateResponseBody(body interface{}) []anysdk.ResponseViolation {
+	return op.violations
+}
+
+func TestValidateResponse_Modes(t *testing.T) {
+	op := &violatingOperationStore{
+		OperationStore: anysdk.NewEmptyOperationStore(),
+		violations: []anysdk.ResponseViolation{
+			{Pointer: "/items/0/id", Keyword: "required", Message: "missing required property 'id'"},
+		},
+	}
+	res := makeBodyResponse(map[string]interface{}{"items": []interface{}{}})
+
+	var out bytes.Buffer
+	assert.NilError(t, validateResponse(dto.RuntimeCtx{}, op, res, &out))
+	assert.Equal(t, out.Len(), 0)
+
+	assert.NilError(t, validateResponse(dto.RuntimeCtx{ResponseValidation: anysdk.ResponseValidationWarn}, op, res, &out))
+	assert.Equal(t, out.String(), "response validation warning: method 'list': #/items/0/id: missing required property 'id'\n")
+
+	err := validateResponse(dto.RuntimeCtx{ResponseValidation: anysdk.ResponseValidationError}, op, res, &out)
+	assert.ErrorContains(t, err, "#/items/0/id: missing required property 'id'")
+
+	err = validateResponse(dto.RuntimeCtx{ResponseValidation: "strict"}, op, res, &out)
+	assert.ErrorContains(t, err, "unsupported response validation mode")
+}
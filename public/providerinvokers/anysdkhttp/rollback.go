@@ -0,0 +1,319 @@
+package anysdkhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+var (
+	_ UnitOfWork = &standardUnitOfWork{}
+	_ error      = &RollbackError{}
+
+	// ErrNoInverse marks a mutation that could not be undone because its
+	// method declares no inverse.
+	ErrNoInverse = errors.New("method declares no inverse") //nolint:gochecknoglobals // sentinel
+)
+
+// UnitOfWork records the inverse of each successful mutation made through
+// the invoker, so that a failed multi-step change can be undone. It is
+// opt-in: attach one to a payload with WithUnitOfWork. When an invocation
+// carrying a unit of work fails, the unit is rolled back: recorded inverses
+// are replayed most recent first. Callers may also roll back explicitly,
+// for example when a later step outside the invoker fails, or Commit to
+// forget the recorded inverses once the work has succeeded.
+//
+// A UnitOfWork is safe for concurrent use.
+type UnitOfWork interface {
+	// Len is the number of mutations recorded since the last commit or
+	// rollback.
+	Len() int
+	// Commit forgets the recorded mutations.
+	Commit()
+	// Rollback replays the recorded inverses in reverse order, carrying on
+	// past failures, and forgets them.
+	Rollback(ctx context.Context) *RollbackReport
+	record(agPayload AgnosticatePayload, resp ProcessorResponse)
+	recordMutation(base rollbackEntry, reversal anysdk.HTTPPreparator)
+}
+
+// RollbackStep describes one recorded mutation and how rolling it back went.
+type RollbackStep struct {
+	Provider string
+	Resource string
+	// Method is the mutating method, such as insert.
+	Method string
+	// Inverse is the method replayed to undo it; empty when there is none.
+	Inverse string
+	// Err is why the mutation was not undone; nil when it was.
+	Err error
+}
+
+func (s RollbackStep) String() string {
+	target := s.Method
+	if s.Resource != "" {
+		target = fmt.Sprintf("%s on %s.%s", s.Method, s.Provider, s.Resource)
+	}
+	if s.Err != nil {
+		return fmt.Sprintf("rollback: could not undo %s: %s", target, s.Err.Error())
+	}
+	return fmt.Sprintf("rollback: undid %s with %s", target, s.Inverse)
+}
+
+// RollbackReport lists the mutations a rollback undid and those it did
+// not, each in the order they were attempted, most recent mutation first.
+type RollbackReport struct {
+	Undone    []RollbackStep
+	NotUndone []RollbackStep
+}
+
+// IsComplete reports whether every recorded mutation was undone.
+func (r *RollbackReport) IsComplete() bool {
+	return len(r.NotUndone) == 0
+}
+
+// Messages describes each step, undone steps first.
+func (r *RollbackReport) Messages() []string {
+	rv := make([]string, 0, len(r.Undone)+len(r.NotUndone))
+	for _, s := range r.Undone {
+		rv = append(rv, s.String())
+	}
+	for _, s := range r.NotUndone {
+		rv = append(rv, s.String())
+	}
+	return rv
+}
+
+// RollbackError is returned by an invocation that failed and rolled back
+// its unit of work. It wraps the original failure.
+type RollbackError struct {
+	Err    error
+	Report *RollbackReport
+}
+
+func (re *RollbackError) Error() string {
+	total := len(re.Report.Undone) + len(re.Report.NotUndone)
+	return fmt.Sprintf("%s; rolled back %d of %d mutations", re.Err.Error(), len(re.Report.Undone), total)
+}
+
+func (re *RollbackError) Unwrap() error {
+	return re.Err
+}
+
+type rollbackEntry struct {
+	step              RollbackStep
+	reversal          anysdk.HTTPPreparator
+	runtimeCtx        dto.RuntimeCtx
+	authCtx           *dto.AuthCtx
+	outErrFile        io.Writer
+	defaultHTTPClient *http.Client
+}
+
+type standardUnitOfWork struct {
+	mu      sync.Mutex
+	entries []rollbackEntry
+}
+
+func NewUnitOfWork() UnitOfWork {
+	return &standardUnitOfWork{}
+}
+
+// WithUnitOfWork returns a copy of a payload built by NewPayload whose
+// successful mutations are recorded in uow, and which rolls uow back when
+// it fails.
+func WithUnitOfWork(payload any, uow UnitOfWork) any {
+	p, ok := payload.(standardPayload)
+	if !ok {
+		return payload
+	}
+	p.unitOfWork = uow
+	return p
+}
+
+func (u *standardUnitOfWork) Len() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.entries)
+}
+
+func (u *standardUnitOfWork) Commit() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.entries = nil
+}
+
+// record keeps the reversals of a successful mutation. Failed calls, and
+// calls that are not mutations, are ignored.
+func (u *standardUnitOfWork) record(agPayload AgnosticatePayload, resp ProcessorResponse) {
+	if resp == nil || resp.GetError() != nil || resp.IsFailed() {
+		return
+	}
+	if _, isAPIError := resp.GetAPIError(); isAPIError || !agPayload.IsMutation() {
+		return
+	}
+	base := newRollbackEntry(
+		agPayload.GetProvider(),
+		agPayload.GetMethod(),
+		agPayload.GetRuntimeCtx(),
+		agPayload.GetAuthContext(),
+		agPayload.GetOutErrFile(),
+		agPayload.GetDefaultHTTPClient(),
+	)
+	var entries []rollbackEntry
+	if stream := resp.GetReversalStream(); stream != nil {
+		for {
+			reversal, hasNext := stream.Next()
+			if !hasNext {
+				break
+			}
+			entries = append(entries, base.withReversal(reversal))
+		}
+	}
+	if len(entries) == 0 {
+		entries = append(entries, base)
+	}
+	u.add(entries...)
+}
+
+// recordMutation keeps a successful mutation made outside the processor,
+// such as an upsert step. reversal is nil when the method declares no
+// inverse.
+func (u *standardUnitOfWork) recordMutation(base rollbackEntry, reversal anysdk.HTTPPreparator) {
+	if reversal != nil {
+		base = base.withReversal(reversal)
+	}
+	u.add(base)
+}
+
+func (u *standardUnitOfWork) add(entries ...rollbackEntry) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.entries = append(u.entries, entries...)
+}
+
+func newRollbackEntry(
+	prov anysdk.Provider,
+	method anysdk.OperationStore,
+	runtimeCtx dto.RuntimeCtx,
+	authCtx *dto.AuthCtx,
+	outErrFile io.Writer,
+	defaultHTTPClient *http.Client,
+) rollbackEntry {
+	step := RollbackStep{Method: method.GetName()}
+	if prov != nil {
+		step.Provider = prov.GetName()
+	}
+	if rsc := method.GetResource(); rsc != nil {
+		step.Resource = rsc.GetID()
+	}
+	return rollbackEntry{
+		step:              step,
+		runtimeCtx:        runtimeCtx,
+		authCtx:           authCtx,
+		outErrFile:        outErrFile,
+		defaultHTTPClient: defaultHTTPClient,
+	}
+}
+
+func (e rollbackEntry) withReversal(reversal anysdk.HTTPPreparator) rollbackEntry {
+	e.reversal = reversal
+	e.step.Inverse = reversal.GetOperationStore().GetName()
+	return e
+}
+
+func (u *standardUnitOfWork) Rollback(ctx context.Context) *RollbackReport {
+	u.mu.Lock()
+	entries := u.entries
+	u.entries = nil
+	u.mu.Unlock()
+	report := &RollbackReport{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		step := entries[i].step
+		switch {
+		case entries[i].reversal == nil:
+			step.Err = ErrNoInverse
+		case ctx.Err() != nil:
+			step.Err = ctx.Err()
+		default:
+			step.Err = undo(entries[i])
+		}
+		if step.Err != nil {
+			report.NotUndone = append(report.NotUndone, step)
+			continue
+		}
+		report.Undone = append(report.Undone, step)
+	}
+	return report
+}
+
+// undo sends every request of a recorded reversal. Any error response
+// fails the step.
+func undo(entry rollbackEntry) error {
+	armoury, err := entry.reversal.BuildHTTPRequestCtx(anysdk.NewHTTPPreparatorConfig(false))
+	if err != nil {
+		return err
+	}
+	prov := entry.reversal.GetProvider()
+	method := entry.reversal.GetOperationStore()
+	provName := entry.step.Provider
+	if prov != nil {
+		provName = prov.GetName()
+	}
+	for _, p := range armoury.GetRequestParams() {
+		httpResponse, sendErr := send(
+			provName, prov, method, p, entry.authCtx, entry.runtimeCtx, entry.outErrFile, entry.defaultHTTPClient)
+		if sendErr != nil {
+			return sendErr
+		}
+		apiErr, isAPIError := method.ParseAPIError(httpResponse)
+		if httpResponse.Body != nil {
+			httpResponse.Body.Close()
+		}
+		if isAPIError {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// send makes one prepared request outside the processor, for follow-up
+// calls such as rollbacks and upserts.
+func send(
+	provName string,
+	prov anysdk.Provider,
+	method anysdk.OperationStore,
+	p anysdk.HTTPArmouryParameters,
+	authCtx *dto.AuthCtx,
+	runtimeCtx dto.RuntimeCtx,
+	outErrFile io.Writer,
+	defaultHTTPClient *http.Client,
+) (*http.Response, error) {
+	if authCtx == nil {
+		authCtx = &dto.AuthCtx{}
+	}
+	if outErrFile == nil {
+		outErrFile = io.Discard
+	}
+	cc := anysdk.NewAnySdkClientConfigurator(runtimeCtx, provName, defaultHTTPClient)
+	response, err := anysdk.CallFromSignature(
+		cc,
+		runtimeCtx,
+		authCtx,
+		authCtx.Type,
+		false,
+		outErrFile,
+		prov,
+		anysdk.NewAnySdkOpStoreDesignation(method),
+		p.GetArgList(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetHttpResponse()
+}
@@ -0,0 +1,172 @@
+package anysdkhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/apierror"
+	"github.com/stackql/any-sdk/pkg/dto"
+
+	"gotest.tools/assert"
+)
+
+type namedOperationStore struct {
+	anysdk.OperationStore
+	name string
+}
+
+func (op *namedOperationStore) GetName() string {
+	return op.name
+}
+
+func (op *namedOperationStore) GetResource() anysdk.Resource {
+	return nil
+}
+
+// inversePreparator undoes a mutation with a single DELETE to url.
+type inversePreparator struct {
+	anysdk.HTTPPreparator
+	prov   anysdk.Provider
+	method anysdk.OperationStore
+	url    string
+}
+
+func (ip *inversePreparator) GetProvider() anysdk.Provider {
+	return ip.prov
+}
+
+func (ip *inversePreparator) GetOperationStore() anysdk.OperationStore {
+	return ip.method
+}
+
+func (ip *inversePreparator) BuildHTTPRequestCtx(cfg anysdk.HTTPPreparatorConfig) (anysdk.HTTPArmoury, error) {
+	req, err := http.NewRequest(http.MethodDelete, ip.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	params := anysdk.NewHTTPArmouryParameters()
+	params.SetRequest(req)
+	armoury := anysdk.NewHTTPArmoury(ip, cfg)
+	armoury.AddRequestParams(params)
+	return armoury, nil
+}
+
+func rollbackPayload(method string, isMutation bool, server *httptest.Server) AgnosticatePayload {
+	return newHTTPAgnosticatePayload(
+		nil,
+		anysdk.NewProvider("test", "test", "test", "v1"),
+		&namedOperationStore{OperationStore: anysdk.NewEmptyOperationStore(), name: method},
+		"",
+		&dto.AuthCtx{Type: dto.AuthNullStr},
+		dto.RuntimeCtx{},
+		io.Discard,
+		nil,
+		nil,
+		false,
+		nil,
+		"",
+		nil,
+		false,
+		isMutation,
+		false,
+		server.Client(),
+		nil,
+	)
+}
+
+func mutationResponse(server *httptest.Server, inverses ...string) ProcessorResponse {
+	stream := anysdk.NewHttpPreparatorStream()
+	for _, path := range inverses {
+		//nolint:errcheck // in memory
+		stream.Write(&inversePreparator{
+			prov:   anysdk.NewProvider("test", "test", "test", "v1"),
+			method: &namedOperationStore{OperationStore: anysdk.NewEmptyOperationStore(), name: "delete"},
+			url:    server.URL + path,
+		})
+	}
+	return newHTTPProcessorResponse(nil, stream, false, nil)
+}
+
+func TestUnitOfWork_RollbackReplaysInversesInReverse(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		deleted = append(deleted, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/subnets/b" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			//nolint:errcheck // test server
+			w.Write([]byte(`{"error": {"code": "InUse", "message": "subnet has attached interfaces"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	uow := NewUnitOfWork()
+	uow.record(rollbackPayload("insert", true, server), mutationResponse(server, "/networks/a"))
+	uow.record(rollbackPayload("insert", true, server), mutationResponse(server, "/subnets/b"))
+	uow.record(rollbackPayload("exec", true, server), mutationResponse(server))
+	uow.record(rollbackPayload("insert", true, server), mutationResponse(server, "/instances/c"))
+	assert.Equal(t, uow.Len(), 4)
+
+	report := uow.Rollback(context.Background())
+	assert.Equal(t, uow.Len(), 0)
+	assert.DeepEqual(t, deleted, []string{"/instances/c", "/subnets/b", "/networks/a"})
+	assert.Equal(t, report.IsComplete(), false)
+	assert.Equal(t, len(report.Undone), 2)
+	assert.Equal(t, report.Undone[0].Inverse, "delete")
+	assert.Equal(t, len(report.NotUndone), 2)
+	assert.Equal(t, report.NotUndone[0].Method, "exec")
+	assert.Assert(t, errors.Is(report.NotUndone[0].Err, ErrNoInverse))
+	apiErr, isAPIError := apierror.As(report.NotUndone[1].Err)
+	assert.Assert(t, isAPIError)
+	assert.Equal(t, apiErr.Code, "InUse")
+	assert.DeepEqual(t, report.Messages(), []string{
+		"rollback: undid insert with delete",
+		"rollback: undid insert with delete",
+		"rollback: could not undo exec: method declares no inverse",
+		"rollback: could not undo insert: 409 Conflict: InUse: subnet has attached interfaces",
+	})
+}
+
+func TestUnitOfWork_RecordsOnlySuccessfulMutations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	uow := NewUnitOfWork()
+	uow.record(rollbackPayload("list", false, server), mutationResponse(server, "/ignored"))
+	uow.record(rollbackPayload("insert", true, server), newHTTPProcessorResponse(nil, anysdk.NewHttpPreparatorStream(), true, nil))
+	uow.record(rollbackPayload("insert", true, server), newHTTPProcessorResponse(nil, anysdk.NewHttpPreparatorStream(), false, errors.New("boom")))
+	uow.record(rollbackPayload("insert", true, server), nil)
+	assert.Equal(t, uow.Len(), 0)
+
+	uow.record(rollbackPayload("insert", true, server), mutationResponse(server, "/networks/a"))
+	assert.Equal(t, uow.Len(), 1)
+	uow.Commit()
+	assert.Equal(t, uow.Len(), 0)
+	assert.Equal(t, uow.Rollback(context.Background()).IsComplete(), true)
+}
+
+func TestRollbackError_WrapsCause(t *testing.T) {
+	cause := errors.New("insert over HTTP error: 400 Bad Request")
+	err := &RollbackError{
+		Err: cause,
+		Report: &RollbackReport{
+			Undone:    []RollbackStep{{Method: "insert", Inverse: "delete"}},
+			NotUndone: []RollbackStep{{Method: "exec", Err: ErrNoInverse}},
+		},
+	}
+	assert.Assert(t, errors.Is(err, cause))
+	assert.Equal(t, err.Error(), "insert over HTTP error: 400 Bad Request; rolled back 1 of 2 mutations")
+}
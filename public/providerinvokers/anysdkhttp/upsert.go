@@ -0,0 +1,127 @@
+package anysdkhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+// Upsert creates the resource addressed by params if it is absent and
+// updates it if it is present, following plan, which comes from the
+// resource's GetUpsertPlan. params holds both the identifier parameters
+// and the request body attributes, as for an insert. The caller closes
+// the result's response, if any.
+//
+// A create, update or native upsert declaring an lro policy is awaited as
+// it would be by the processor: when isAwait is set or the policy asks to
+// wait. With a unit of work, the change is recorded in uow, with its
+// inverse, and a failed upsert rolls uow back and returns a
+// *RollbackError; uow may be nil.
+//
+//nolint:funlen // one closure per upsert step
+func Upsert(
+	ctx context.Context,
+	plan anysdk.UpsertPlan,
+	params map[string]interface{},
+	authCtx *dto.AuthCtx,
+	runtimeCtx dto.RuntimeCtx,
+	outErrFile io.Writer,
+	defaultHTTPClient *http.Client,
+	isAwait bool,
+	uow UnitOfWork,
+) (anysdk.UpsertResult, error) {
+	if authCtx == nil {
+		authCtx = &dto.AuthCtx{}
+	}
+	if outErrFile == nil {
+		outErrFile = io.Discard
+	}
+	getMethod, _ := plan.GetGetMethod()
+	result, err := plan.Execute(params, func(method anysdk.StandardOperationStore, callParams map[string]interface{}) (*http.Response, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		prov := method.GetProvider()
+		armoury, err := anysdk.NewHTTPPreparator(
+			prov,
+			method.GetService(),
+			method,
+			map[int]map[string]interface{}{0: callParams},
+			nil,
+			nil,
+			nil,
+		).BuildHTTPRequestCtx(anysdk.NewHTTPPreparatorConfig(false))
+		if err != nil {
+			return nil, err
+		}
+		reqParams := armoury.GetRequestParams()
+		if len(reqParams) != 1 {
+			return nil, fmt.Errorf("method '%s' prepared %d requests, expected 1", method.GetName(), len(reqParams))
+		}
+		provName := ""
+		if prov != nil {
+			provName = prov.GetName()
+		}
+		resp, err := send(provName, prov, method, reqParams[0], authCtx, runtimeCtx, outErrFile, defaultHTTPClient)
+		if err != nil || method == getMethod || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return resp, err
+		}
+		resp, err = awaitLongRunningOperation(
+			anysdk.NewAnySdkClientConfigurator(runtimeCtx, provName, defaultHTTPClient),
+			runtimeCtx,
+			authCtx,
+			outErrFile,
+			prov,
+			method,
+			reqParams[0].GetArgList(),
+			resp,
+			isAwait,
+		)
+		if err != nil || uow == nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return resp, err
+		}
+		reversal, err := getUpsertReversal(method, resp)
+		if err != nil {
+			return nil, err
+		}
+		uow.recordMutation(
+			newRollbackEntry(prov, method, runtimeCtx, authCtx, outErrFile, defaultHTTPClient),
+			reversal,
+		)
+		return resp, nil
+	})
+	if err != nil && uow != nil {
+		report := uow.Rollback(context.WithoutCancel(ctx))
+		return result, &RollbackError{Err: err, Report: report}
+	}
+	return result, err
+}
+
+// getUpsertReversal resolves the inverse of a successful upsert step
+// against its response, as the processor does for a mutation, and leaves
+// the response body to be read again by the caller. It is nil when the
+// method declares no inverse.
+func getUpsertReversal(method anysdk.OperationStore, resp *http.Response) (anysdk.HTTPPreparator, error) {
+	if _, hasInverse := method.GetInverse(); !hasInverse || resp.Body == nil {
+		return nil, nil //nolint:nilnil // no inverse is not an error
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	processed := *resp
+	processed.Body = io.NopCloser(bytes.NewReader(body))
+	rv, err := method.ProcessResponse(&processed)
+	if rv == nil {
+		return nil, err
+	}
+	reversal, _ := rv.GetReversal()
+	return reversal, nil
+}
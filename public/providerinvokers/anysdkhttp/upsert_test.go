@@ -0,0 +1,83 @@
+package anysdkhttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stackql/any-sdk/internal/anysdk"
+	"github.com/stackql/any-sdk/pkg/dto"
+
+	"gotest.tools/assert"
+)
+
+const upsertTestRegistry = "../../../internal/anysdk/testdata/registry/src/googleadmin/v0.1.0"
+
+// TestUpsert_RecordsCreateInUnitOfWork: the create made by an upsert is
+// recorded with the inverse resolved from its response, so rolling the
+// unit back deletes the created user.
+func TestUpsert_RecordsCreateInUnitOfWork(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPost:
+			//nolint:errcheck // test server
+			w.Write([]byte(`{"id": "u-123", "primaryEmail": "jo@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	baseTransport, _ := server.Client().Transport.(*http.Transport)
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: baseTransport.TLSClientConfig,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("tcp", server.Listener.Addr().String())
+			},
+		},
+	}
+
+	svc, err := anysdk.LoadProviderAndServiceFromPaths(
+		upsertTestRegistry+"/provider.yaml",
+		upsertTestRegistry+"/services/admin-directory.yaml",
+	)
+	assert.NilError(t, err)
+	rsc, err := svc.GetResource("users")
+	assert.NilError(t, err)
+	params := map[string]interface{}{"userKey": "jo@example.com", "primaryEmail": "jo@example.com"}
+	plan, err := rsc.GetUpsertPlan(params)
+	assert.NilError(t, err)
+
+	uow := NewUnitOfWork()
+	authCtx := &dto.AuthCtx{Type: dto.AuthNullStr}
+	runtimeCtx := dto.RuntimeCtx{AllowInsecure: true}
+	result, err := Upsert(context.Background(), plan, params, authCtx, runtimeCtx, io.Discard, httpClient, false, uow)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Action, anysdk.UpsertActionCreated)
+	body, err := io.ReadAll(result.Response.Body)
+	result.Response.Body.Close()
+	assert.NilError(t, err)
+	assert.Equal(t, string(body), `{"id": "u-123", "primaryEmail": "jo@example.com"}`)
+	assert.Equal(t, uow.Len(), 1)
+
+	report := uow.Rollback(context.Background())
+	assert.Assert(t, report.IsComplete())
+	assert.Equal(t, len(report.Undone), 1)
+	assert.DeepEqual(t, calls, []string{
+		"GET /admin/directory/v1/users/jo@example.com",
+		"POST /admin/directory/v1/users",
+		"DELETE /admin/directory/v1/users/u-123",
+	})
+}
@@ -378,6 +378,9 @@ func (ns *standardNamespace) Invoke(argList ...any) error {
 				return marshalErr
 			}
 			httpReq.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			if contentType, hasContentType := marshalledBody.GetContentType(); hasContentType {
+				httpReq.Header.Set("Content-Type", contentType)
+			}
 		}
 		ns.request = copiedRequest
 		translatedReq, translateErr := ns.lateTranslator.Translate(copiedRequest)
@@ -0,0 +1,58 @@
+package sqlengine
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheMigration brings a cache table created before entries carried an
+// expiry up to date, once per engine. The setup DDL only creates missing
+// tables, so databases from earlier versions would otherwise lack the
+// expires_at column the cache store reads and writes.
+type cacheMigration struct {
+	mutex sync.Mutex
+	done  bool
+}
+
+// ensure runs migrate until it reports the table was found and migrated.
+// migrate must be idempotent; it reports false when the cache table does not
+// exist yet, so that a table created later is still migrated.
+func (m *cacheMigration) ensure(migrate func() (bool, error)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.done {
+		return nil
+	}
+	done, err := migrate()
+	if err != nil {
+		return fmt.Errorf("cache store migration: %w", err)
+	}
+	m.done = done
+	return nil
+}
+
+// cacheExpiresAt resolves the expiry of a cache entry, in epoch seconds, from
+// the CacheStorePut arguments. expiration is an optional RFC 3339 instant and
+// ttl an optional lifetime in seconds; when both are supplied the earlier
+// wins. Neither yields a null expiry, meaning the entry never expires.
+func cacheExpiresAt(expiration string, ttl int, now time.Time) (sql.NullInt64, error) {
+	var rv sql.NullInt64
+	if ttl > 0 {
+		rv = sql.NullInt64{Int64: now.Add(time.Duration(ttl) * time.Second).Unix(), Valid: true}
+	}
+	expiration = strings.TrimSpace(expiration)
+	if expiration == "" {
+		return rv, nil
+	}
+	at, err := time.Parse(time.RFC3339, expiration)
+	if err != nil {
+		return rv, fmt.Errorf("cache expiration '%s' is not an RFC 3339 timestamp: %w", expiration, err)
+	}
+	if !rv.Valid || at.Unix() < rv.Int64 {
+		rv = sql.NullInt64{Int64: at.Unix(), Valid: true}
+	}
+	return rv, nil
+}
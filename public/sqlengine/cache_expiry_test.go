@@ -0,0 +1,49 @@
+package sqlengine
+
+import (
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+func TestSQLiteCacheStoreMigratesTableWithoutExpiry(t *testing.T) {
+	cfg, err := dto.GetSQLBackendCfg(`{"dsn": "file:cache_migration?mode=memory&cache=shared"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eng, err := newSQLiteEmbeddedEngine(cfg, sqlcontrol.GetControlAttributes("standard"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer eng.db.Close()
+	// The table as created by an earlier version of the setup DDL.
+	if _, err = eng.db.Exec(`CREATE TABLE "__iql__.cache.key_val" (
+   k TEXT NOT NULL UNIQUE
+  ,v BLOB
+  ,tablespace TEXT
+  ,tablespace_id INTEGER
+)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	setupDDL, err := GetSQLEngineSetupDDL("sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = eng.ExecInTxn([]string{setupDDL}); err != nil {
+		t.Fatalf("expected the setup DDL to run over an existing database, got %v", err)
+	}
+
+	if err = eng.CacheStorePut("k", []byte("v"), "", 60); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, getErr := eng.CacheStoreGet("k"); getErr != nil || string(val) != "v" {
+		t.Fatalf("unexpected value %q, %v", val, getErr)
+	}
+	var indexCount int
+	if err = eng.db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx.__iql__.cache.key_val.expires_at'`,
+	).Scan(&indexCount); err != nil || indexCount != 1 {
+		t.Fatalf("expected the expiry index to be created, got %d, %v", indexCount, err)
+	}
+}
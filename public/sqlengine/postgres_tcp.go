@@ -7,6 +7,7 @@ import (
 	"os"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/stackql/any-sdk/pkg/db/db_util"
 	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
@@ -29,6 +30,7 @@ type postgresTCPEngine struct {
 	ctrlMutex         *sync.Mutex
 	sessionMutex      *sync.Mutex
 	discoveryMutex    *sync.Mutex
+	cacheMigration    *cacheMigration
 }
 
 func (se *postgresTCPEngine) IsMemory() bool {
@@ -63,6 +65,7 @@ func newPostgresTCPEngine(
 		ctrlMutex:         &sync.Mutex{},
 		sessionMutex:      &sync.Mutex{},
 		discoveryMutex:    &sync.Mutex{},
+		cacheMigration:    &cacheMigration{},
 	}
 	if cfg.DbInitFilePath != "" {
 		err = eng.execFile(cfg.DbInitFilePath)
@@ -219,17 +222,50 @@ func (se postgresTCPEngine) getNextSessionID(generationID int) (int, error) {
 	return retVal, err
 }
 
+// migrateCacheStore adds the expires_at column and its index to a cache
+// table that predates them.
+func (se postgresTCPEngine) migrateCacheStore() (bool, error) {
+	var exists bool
+	err := se.db.QueryRow(`SELECT to_regclass('"__iql__.cache.key_val"') IS NOT NULL`).Scan(&exists)
+	if err != nil || !exists {
+		return false, err
+	}
+	_, err = se.db.Exec(`ALTER TABLE "__iql__.cache.key_val" ADD COLUMN IF NOT EXISTS expires_at BIGINT`)
+	if err != nil {
+		return false, err
+	}
+	_, err = se.db.Exec(
+		`CREATE INDEX IF NOT EXISTS "idx.__iql__.cache.key_val.expires_at" ON "__iql__.cache.key_val" (expires_at)`,
+	)
+	return err == nil, err
+}
+
+// CacheStoreGet returns the value stored under key, or sql.ErrNoRows when
+// there is none or it has expired.
 func (se postgresTCPEngine) CacheStoreGet(key string) ([]byte, error) {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return nil, err
+	}
 	var retVal []byte
-	res := se.db.QueryRow(`SELECT v FROM "__iql__.cache.key_val" WHERE k = $1`, key)
+	res := se.db.QueryRow(
+		`SELECT v FROM "__iql__.cache.key_val" WHERE k = $1 AND (expires_at IS NULL OR expires_at > $2)`,
+		key,
+		time.Now().Unix(),
+	)
 	err := res.Scan(&retVal)
 	return retVal, err
 }
 
 func (se postgresTCPEngine) CacheStoreGetAll() ([]internaldto.KeyVal, error) {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return nil, err
+	}
 	var retVal []internaldto.KeyVal
 	//nolint:rowserrcheck // TODO: fix this
-	res, err := se.db.Query(`SELECT k, v FROM "__iql__.cache.key_val"`)
+	res, err := se.db.Query(
+		`SELECT k, v FROM "__iql__.cache.key_val" WHERE expires_at IS NULL OR expires_at > $1`,
+		time.Now().Unix(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -245,23 +281,38 @@ func (se postgresTCPEngine) CacheStoreGetAll() ([]internaldto.KeyVal, error) {
 	return retVal, err
 }
 
-func (se postgresTCPEngine) CacheStorePut(key string, val []byte, tablespace string, tablespaceID int) error {
+// CacheStorePut stores val under key, replacing any previous value. The
+// entry expires after ttl seconds and / or at the RFC 3339 expiration
+// instant, whichever is earlier; with neither it never expires. Expired
+// entries are evicted on every put.
+func (se postgresTCPEngine) CacheStorePut(key string, val []byte, expiration string, ttl int) error {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return err
+	}
+	now := time.Now()
+	expiresAt, expiryErr := cacheExpiresAt(expiration, ttl, now)
+	if expiryErr != nil {
+		return expiryErr
+	}
 	txn, err := se.db.Begin()
 	if err != nil {
 		return err
 	}
-	_, err = txn.Exec(`DELETE FROM "__iql__.cache.key_val" WHERE k = $1`, key)
+	_, err = txn.Exec(
+		`DELETE FROM "__iql__.cache.key_val" WHERE k = $1 OR (expires_at IS NOT NULL AND expires_at <= $2)`,
+		key,
+		now.Unix(),
+	)
 	if err != nil {
 		//nolint:errcheck // intentionally ignoring error TODO: publish variadic error(s)
 		txn.Rollback()
 		return err
 	}
 	_, err = txn.Exec(
-		`INSERT INTO "__iql__.cache.key_val" (k, v, tablespace, tablespace_id) VALUES($1, $2, $3, $4)`,
+		`INSERT INTO "__iql__.cache.key_val" (k, v, expires_at) VALUES($1, $2, $3)`,
 		key,
 		val,
-		tablespace,
-		tablespaceID,
+		expiresAt,
 	)
 	if err != nil {
 		//nolint:errcheck // intentionally ignoring error TODO: publish variadic error(s)
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
 	"github.com/stackql/any-sdk/pkg/dto"
@@ -26,6 +27,7 @@ type sqLiteEmbeddedEngine struct {
 	ctrlMutex         *sync.Mutex
 	sessionMutex      *sync.Mutex
 	discoveryMutex    *sync.Mutex
+	cacheMigration    *cacheMigration
 }
 
 func (se *sqLiteEmbeddedEngine) IsMemory() bool {
@@ -58,6 +60,7 @@ func newSQLiteEmbeddedEngine(
 		ctrlMutex:         &sync.Mutex{},
 		sessionMutex:      &sync.Mutex{},
 		discoveryMutex:    &sync.Mutex{},
+		cacheMigration:    &cacheMigration{},
 	}
 	if err != nil {
 		return eng, err
@@ -217,17 +220,60 @@ func (se sqLiteEmbeddedEngine) getNextSessionID(generationID int) (int, error) {
 	return retVal, err
 }
 
+// migrateCacheStore adds the expires_at column and its index to a cache
+// table that predates them. SQLite has no ADD COLUMN IF NOT EXISTS, so the
+// column is looked up first.
+func (se sqLiteEmbeddedEngine) migrateCacheStore() (bool, error) {
+	var tableCount, columnCount int
+	err := se.db.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = '__iql__.cache.key_val'`,
+	).Scan(&tableCount)
+	if err != nil || tableCount == 0 {
+		return false, err
+	}
+	err = se.db.QueryRow(
+		`SELECT COUNT(*) FROM pragma_table_info('__iql__.cache.key_val') WHERE name = 'expires_at'`,
+	).Scan(&columnCount)
+	if err != nil {
+		return false, err
+	}
+	if columnCount == 0 {
+		if _, err = se.db.Exec(`ALTER TABLE "__iql__.cache.key_val" ADD COLUMN expires_at INTEGER`); err != nil {
+			return false, err
+		}
+	}
+	_, err = se.db.Exec(
+		`CREATE INDEX IF NOT EXISTS "idx.__iql__.cache.key_val.expires_at" ON "__iql__.cache.key_val" (expires_at)`,
+	)
+	return err == nil, err
+}
+
+// CacheStoreGet returns the value stored under key, or sql.ErrNoRows when
+// there is none or it has expired.
 func (se sqLiteEmbeddedEngine) CacheStoreGet(key string) ([]byte, error) {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return nil, err
+	}
 	var retVal []byte
-	res := se.db.QueryRow(`SELECT v FROM "__iql__.cache.key_val" WHERE k = ?`, key)
+	res := se.db.QueryRow(
+		`SELECT v FROM "__iql__.cache.key_val" WHERE k = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		key,
+		time.Now().Unix(),
+	)
 	err := res.Scan(&retVal)
 	return retVal, err
 }
 
 func (se sqLiteEmbeddedEngine) CacheStoreGetAll() ([]internaldto.KeyVal, error) {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return nil, err
+	}
 	var retVal []internaldto.KeyVal
 	//nolint:rowserrcheck // TODO: fix this
-	res, err := se.db.Query(`SELECT k, v FROM "__iql__.cache.key_val"`)
+	res, err := se.db.Query(
+		`SELECT k, v FROM "__iql__.cache.key_val" WHERE expires_at IS NULL OR expires_at > ?`,
+		time.Now().Unix(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -243,23 +289,38 @@ func (se sqLiteEmbeddedEngine) CacheStoreGetAll() ([]internaldto.KeyVal, error)
 	return retVal, err
 }
 
-func (se sqLiteEmbeddedEngine) CacheStorePut(key string, val []byte, tablespace string, tablespaceID int) error {
+// CacheStorePut stores val under key, replacing any previous value. The
+// entry expires after ttl seconds and / or at the RFC 3339 expiration
+// instant, whichever is earlier; with neither it never expires. Expired
+// entries are evicted on every put.
+func (se sqLiteEmbeddedEngine) CacheStorePut(key string, val []byte, expiration string, ttl int) error {
+	if err := se.cacheMigration.ensure(se.migrateCacheStore); err != nil {
+		return err
+	}
+	now := time.Now()
+	expiresAt, expiryErr := cacheExpiresAt(expiration, ttl, now)
+	if expiryErr != nil {
+		return expiryErr
+	}
 	txn, err := se.db.Begin()
 	if err != nil {
 		return err
 	}
-	_, err = txn.Exec(`DELETE FROM "__iql__.cache.key_val" WHERE k = ?`, key)
+	_, err = txn.Exec(
+		`DELETE FROM "__iql__.cache.key_val" WHERE k = ? OR (expires_at IS NOT NULL AND expires_at <= ?)`,
+		key,
+		now.Unix(),
+	)
 	if err != nil {
 		//nolint:errcheck // intentionally ignoring error TODO: publish variadic error(s)
 		txn.Rollback()
 		return err
 	}
 	_, err = txn.Exec(
-		`INSERT INTO "__iql__.cache.key_val" (k, v, tablespace, tablespace_id) VALUES(?, ?, ?, ?)`,
+		`INSERT INTO "__iql__.cache.key_val" (k, v, expires_at) VALUES(?, ?, ?)`,
 		key,
 		val,
-		tablespace,
-		tablespaceID,
+		expiresAt,
 	)
 	if err != nil {
 		//nolint:errcheck // intentionally ignoring error TODO: publish variadic error(s)